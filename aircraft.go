@@ -0,0 +1,210 @@
+// Файл aircraft.go содержит замену самолёта на рейсе с пересадкой пассажиров на места нового самолёта
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akionka/aviasales/internal/reseat"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+func reseatedPassenger(t *store.TicketModel, segmentID int, from, to reseat.Seat) ReseatedPassenger {
+	return ReseatedPassenger{
+		SegmentID:          segmentID,
+		TicketID:           t.ID,
+		PassengerLastName:  t.PassengerLastName,
+		PassengerGivenName: t.PassengerGivenName,
+		PrevSeat:           from.Number,
+		PrevClass:          from.Class,
+		Seat:               to.Number,
+		Class:              to.Class,
+	}
+}
+
+// updateFlight stores the flight. If the new liner is of another model the sold seats are moved onto its seat map,
+// infants without a seat follow their escorts to the new class. Passengers who do not fit are left without a seat
+// and listed in the result
+func (s *server) updateFlight(id int, f *Flight) (*AircraftChange, error) {
+	var result *AircraftChange
+	err := s.store.Transaction(func(tx store.Store) error {
+		prev, err := tx.Flight().Find(id)
+		if err != nil {
+			return err
+		}
+		result = &AircraftChange{Flight: flightFromModel(prev)}
+		result.DepDate, result.LineCode, result.IsHot, result.LinerCode = f.DepDate, f.LineCode, f.IsHot, f.LinerCode
+		if err := tx.Flight().Update(id, &store.FlightModel{
+			DepDate:   f.DepDate,
+			LineCode:  f.LineCode,
+			IsHot:     f.IsHot,
+			LinerCode: f.LinerCode,
+		}); err != nil {
+			return err
+		}
+		if prev.LinerCode == f.LinerCode {
+			return nil
+		}
+		result.PrevLinerCode = prev.LinerCode
+
+		prevLiner, err := tx.Liner().Find(prev.LinerCode)
+		if err != nil {
+			return err
+		}
+		liner, err := tx.Liner().Find(f.LinerCode)
+		if err != nil {
+			return err
+		}
+		if prevLiner.ModelCode == liner.ModelCode {
+			return nil
+		}
+
+		seatModels, err := tx.Seat().FindByModel(liner.ModelCode)
+		if err != nil {
+			return err
+		}
+		seats := make([]reseat.Seat, len(seatModels))
+		for i, v := range seatModels {
+			seats[i] = reseat.Seat{ID: v.ID, Number: v.Number, Class: v.Class}
+		}
+
+		segments, err := tx.FlightInTicket().FindFlightSegments(id)
+		if err != nil {
+			return err
+		}
+		var sold []reseat.Sold
+		tickets := make(map[int]int, len(segments))
+		for _, v := range segments {
+			tickets[v.ID] = v.TicketID
+			if v.SeatID != nil {
+				sold = append(sold, reseat.Sold{SegmentID: v.ID, Seat: reseat.Seat{ID: *v.SeatID, Number: v.SeatNumber, Class: v.SeatClass}})
+			}
+		}
+
+		plan := reseat.Plan(sold, seats)
+		classes := make(map[int]string, len(plan.Placements))
+		for _, p := range plan.Placements {
+			seatID := p.To.ID
+			if err := tx.FlightInTicket().Update(p.SegmentID, &store.FlightInTicketModel{
+				FlightID: id,
+				SeatID:   &seatID,
+				TicketID: tickets[p.SegmentID],
+				Class:    p.To.Class,
+			}); err != nil {
+				return err
+			}
+			t, err := tx.Ticket().Find(tickets[p.SegmentID])
+			if err != nil {
+				return err
+			}
+			classes[t.ID] = p.To.Class
+			result.Reseated = append(result.Reseated, reseatedPassenger(t, p.SegmentID, p.From, p.To))
+		}
+		for _, u := range plan.Unplaced {
+			if err := tx.FlightInTicket().Update(u.SegmentID, &store.FlightInTicketModel{
+				FlightID: id,
+				TicketID: tickets[u.SegmentID],
+				Class:    u.Seat.Class,
+			}); err != nil {
+				return err
+			}
+			t, err := tx.Ticket().Find(tickets[u.SegmentID])
+			if err != nil {
+				return err
+			}
+			result.Unplaced = append(result.Unplaced, reseatedPassenger(t, u.SegmentID, u.Seat, reseat.Seat{}))
+		}
+
+		for _, v := range segments {
+			if v.SeatID != nil {
+				continue
+			}
+			t, err := tx.Ticket().Find(v.TicketID)
+			if err != nil {
+				return err
+			}
+			if t.AccompanyingTicketID == nil {
+				continue
+			}
+			class, ok := classes[*t.AccompanyingTicketID]
+			if !ok || class == v.SeatClass {
+				continue
+			}
+			if err := tx.FlightInTicket().Update(v.ID, &store.FlightInTicketModel{
+				FlightID: id,
+				TicketID: v.TicketID,
+				Class:    class,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.offerFreedSeats(id)
+	return result, nil
+}
+
+// handleFlightAircraftUpdate replaces the liner of the flight keeping the rest of the flight as it is
+func (s *server) handleFlightAircraftUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		req := &AircraftChangeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		flight, err := s.store.Flight().Find(id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if _, err := s.store.Liner().Find(req.LinerCode); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		f := flightFromModel(flight)
+		f.LinerCode = req.LinerCode
+		if err := s.checkRotation(id, &f); err != nil {
+			code, err := rotationErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		change, err := s.updateFlight(id, &f)
+		if err != nil {
+			if err == mysqlstore.ErrNoChanges {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, change)
+	}
+}
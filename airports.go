@@ -0,0 +1,121 @@
+// Файл airports.go содержит координаты аэропортов, расстояния и время в пути по линиям и импорт аэропортов из CSV OurAirports
+package main
+
+import (
+	"database/sql"
+	"io"
+	"math"
+	"time"
+
+	"github.com/akionka/aviasales/internal/geo"
+	"github.com/akionka/aviasales/internal/ourairports"
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+)
+
+// airportPoint returns the point of the airport, false for airports without coordinates
+func airportPoint(a *store.AirportModel) (geo.Point, bool) {
+	if a.Latitude == nil || a.Longitude == nil {
+		return geo.Point{}, false
+	}
+	return geo.Point{Lat: *a.Latitude, Lon: *a.Longitude}, true
+}
+
+// airportFinder returns a function finding airports by their codes, each one read once
+func airportFinder(st store.Store) func(code string) (*store.AirportModel, error) {
+	airports := make(map[string]*store.AirportModel)
+	return func(code string) (*store.AirportModel, error) {
+		if a, ok := airports[code]; ok {
+			return a, nil
+		}
+		a, err := st.Airport().Find(code)
+		if err != nil {
+			return nil, err
+		}
+		airports[code] = a
+		return a, nil
+	}
+}
+
+// distanceKm returns the great-circle distance between the airports in whole kilometres,
+// nil if either of them has no coordinates
+func distanceKm(find func(code string) (*store.AirportModel, error), depAirport, arrAirport string) (*int, error) {
+	dep, err := find(depAirport)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := find(arrAirport)
+	if err != nil {
+		return nil, err
+	}
+	from, ok := airportPoint(dep)
+	if !ok {
+		return nil, nil
+	}
+	to, ok := airportPoint(arr)
+	if !ok {
+		return nil, nil
+	}
+	km := int(math.Round(geo.Distance(from, to)))
+	return &km, nil
+}
+
+// lineGeodata sets the distance and the block time of the line. The block time is of the flight departing
+// today, it changes on the days the airports change their offsets
+func lineGeodata(find func(code string) (*store.AirportModel, error), l *Line) error {
+	distance, err := distanceKm(find, l.DepAirport, l.ArrAirport)
+	if err != nil {
+		return err
+	}
+	dep, err := find(l.DepAirport)
+	if err != nil {
+		return err
+	}
+	arr, err := find(l.ArrAirport)
+	if err != nil {
+		return err
+	}
+	depTime, arrTime, err := schedule.Times(time.Now(), l.DepTime, l.ArrTime, l.ArrDayOffset, dep.Timezone, arr.Timezone)
+	if err != nil {
+		return err
+	}
+	minutes := int(arrTime.Sub(depTime).Minutes())
+	l.DistanceKm, l.BlockMinutes = distance, &minutes
+	return nil
+}
+
+// importAirports sets the codes, the names, the countries and the coordinates of the airports from
+// the airports.csv file of OurAirports. The file has no timezones, so only the airports already known
+// are updated and the others are skipped
+func importAirports(st store.Store, r io.Reader) (*AirportImport, error) {
+	airports, err := ourairports.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	result := &AirportImport{}
+	err = st.Transaction(func(tx store.Store) error {
+		for _, a := range airports {
+			m, err := tx.Airport().Find(a.IATACode)
+			if err == sql.ErrNoRows {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			lat, lon := a.Latitude, a.Longitude
+			m.ICAOCode, m.Name, m.Country, m.Latitude, m.Longitude = a.ICAOCode, a.Name, a.Country, &lat, &lon
+			// Updating an airport with the same data changes no rows, that is not an error here
+			if err := tx.Airport().Update(m.IATACode, m); err != nil && err != mysqlstore.ErrNoChanges {
+				return err
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -8,52 +8,180 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/akionka/aviasales/internal/exchange"
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/overbooking"
+	"github.com/akionka/aviasales/internal/promo"
+	"github.com/akionka/aviasales/internal/schedule"
 	"github.com/akionka/aviasales/internal/store"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
 
-var ErrTooYoung = errors.New("person is too young")
+// ageAt returns the number of full years between the birth date and the given date, negative if the date is before the birth
+func ageAt(birth, date time.Time) int {
+	ty, tm, td := date.Date()
+	date = time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
 
-func checkAgeOver18(value interface{}) error {
-	v, _ := value.(time.Time)
-	today := time.Now().In(v.Location())
-	ty, tm, td := today.Date()
-	today = time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
-
-	by, bm, bd := v.Date()
-	v = time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
-	if today.Before(v) {
-		return ErrTooYoung
+	by, bm, bd := birth.Date()
+	birth = time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	if date.Before(birth) {
+		return -1
 	}
 	age := ty - by
-	if v.AddDate(age, 0, 0).After(today) {
+	if birth.AddDate(age, 0, 0).After(date) {
 		age--
 	}
-	if age < 18 {
-		return ErrTooYoung
-	}
-	return nil
+	return age
+}
+
+const (
+	documentRussianInternal      = "ru_internal"
+	documentRussianInternational = "ru_international"
+	documentForeignPassport      = "foreign_passport"
+	documentBirthCertificate     = "birth_certificate"
+)
+
+type documentRule struct {
+	number *regexp.Regexp
+	// country is the only country issuing the document, empty if any
+	country string
+	// expires tells if the document has an expiry date
+	expires bool
+	// maxAge is the age the holder can use the document until, 0 if any
+	maxAge int
 }
 
+var documentRules = map[string]documentRule{
+	documentRussianInternal:      {number: regexp.MustCompile("^[0-9]{10}$"), country: "RUS"},
+	documentRussianInternational: {number: regexp.MustCompile("^[0-9]{9}$"), country: "RUS", expires: true},
+	documentForeignPassport:      {number: regexp.MustCompile("^[A-Z0-9]{5,20}$"), expires: true},
+	documentBirthCertificate:     {number: regexp.MustCompile("^[IVXLC]{1,6}-[А-ЯЁ]{2}[0-9]{6}$"), country: "RUS", maxAge: 14},
+}
+
+type Document struct {
+	Type           string     `json:"type"`
+	Number         string     `json:"number"`
+	IssuingCountry string     `json:"issuing_country"`
+	ExpiryDate     *time.Time `json:"expiry_date,omitempty"`
+}
+
+func (d Document) Validate() error {
+	rule, ok := documentRules[d.Type]
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Type, validation.Required, validation.In(documentRussianInternal, documentRussianInternational, documentForeignPassport, documentBirthCertificate)),
+		validation.Field(&d.Number, validation.Required, validation.When(ok, validation.Match(rule.number))),
+		validation.Field(&d.IssuingCountry, validation.Required, validation.Length(3, 3), is.UpperCase, validation.When(ok && rule.country != "", validation.In(rule.country))),
+		validation.Field(&d.ExpiryDate, validation.When(ok && rule.expires, validation.Required).Else(validation.Nil)),
+	)
+}
+
+// Airport is an airport. The coordinates in degrees are needed for the distances of the lines from and to it
+// and to accrue miles for their flights. Country is the ISO 3166-1 alpha-2 code
 type Airport struct {
-	IATACode string `json:"iata_code"`
-	City     string `json:"city"`
-	Timezone string `json:"timezone"`
+	IATACode  string   `json:"iata_code"`
+	City      string   `json:"city"`
+	Timezone  string   `json:"timezone"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	ICAOCode  string   `json:"icao_code"`
+	Name      string   `json:"name"`
+	Country   string   `json:"country"`
 }
 
 func (a *Airport) Validate() error {
 	return validation.ValidateStruct(a,
 		validation.Field(&a.IATACode, validation.Required, validation.Length(3, 3), is.Alpha),
 		validation.Field(&a.City, validation.Required, validation.Length(4, 64)),
-		validation.Field(&a.Timezone, validation.Required, validation.Length(4, 64)),
+		validation.Field(&a.Timezone, validation.Required, validation.Length(1, 64), validation.By(validTimezone)),
+		validation.Field(&a.Latitude, validation.When(a.Longitude != nil, validation.NotNil), validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&a.Longitude, validation.When(a.Latitude != nil, validation.NotNil), validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&a.ICAOCode, validation.Match(regexp.MustCompile("^[A-Z0-9]{4}$"))),
+		validation.Field(&a.Name, validation.Length(0, 128)),
+		validation.Field(&a.Country, validation.Match(regexp.MustCompile("^[A-Z]{2}$"))),
 	)
 }
 
+// nonNegativeAmount checks that an amount of money is not negative. Amounts are not numbers
+// for the rules of the validation package
+func nonNegativeAmount(value interface{}) error {
+	if m, ok := value.(money.Money); ok && m.IsNegative() {
+		return errors.New("must be no less than 0")
+	}
+	return nil
+}
+
+// positiveAmount checks that an amount of money is greater than zero
+func positiveAmount(value interface{}) error {
+	if m, ok := value.(money.Money); ok && m.Minor <= 0 {
+		return errors.New("must be greater than 0")
+	}
+	return nil
+}
+
+// storedCurrency checks that the amounts in the currency can be stored
+func storedCurrency(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	return exchange.ValidCurrency(money.Currency(s))
+}
+
+func validTimezone(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	if _, err := schedule.Location(s); err != nil {
+		return errors.New("unknown IANA timezone")
+	}
+	return nil
+}
+
+// Timezone is an IANA timezone at the moment of the request. UTCOffset is formatted as "+03:00",
+// NextTransition is the next change of the offset if there is one
+type Timezone struct {
+	Name           string     `json:"name"`
+	Abbreviation   string     `json:"abbreviation"`
+	UTCOffset      string     `json:"utc_offset"`
+	IsDST          bool       `json:"is_dst"`
+	ObservesDST    bool       `json:"observes_dst"`
+	NextTransition *time.Time `json:"next_transition,omitempty"`
+}
+
+const (
+	taxOnDeparture = "departure"
+	taxOnArrival   = "arrival"
+)
+
+// AirportTax is a tax the airport levies on every passenger with a seat departing from or arriving at it
+type AirportTax struct {
+	TaxCode  string      `json:"tax_code"`
+	Name     string      `json:"name"`
+	Applies  string      `json:"applies"`
+	Amount   money.Money `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+func (t *AirportTax) Validate() error {
+	return validation.ValidateStruct(t,
+		validation.Field(&t.TaxCode, validation.Required, validation.Match(regexp.MustCompile("^[A-Z0-9]{2}$"))),
+		validation.Field(&t.Name, validation.Required, validation.Length(1, 64)),
+		validation.Field(&t.Applies, validation.Required, validation.In(taxOnDeparture, taxOnArrival)),
+		validation.Field(&t.Amount, validation.By(nonNegativeAmount)),
+		validation.Field(&t.Currency, validation.Match(currencyCode), validation.By(storedCurrency)),
+	)
+}
+
+// BookingOffice is a booking office. ServiceFee is charged for every ticket it sells, Currency is the local one
+// the office sells in and its reports are rendered in
 type BookingOffice struct {
-	ID          int    `json:"id"`
-	Address     string `json:"address"`
-	PhoneNumber string `json:"phone_number"`
+	ID          int         `json:"id"`
+	Address     string      `json:"address"`
+	PhoneNumber string      `json:"phone_number"`
+	ServiceFee  money.Money `json:"service_fee"`
+	Currency    string      `json:"currency"`
 }
 
 func (o *BookingOffice) Validate() error {
@@ -61,6 +189,8 @@ func (o *BookingOffice) Validate() error {
 		validation.Field(&o.ID),
 		validation.Field(&o.Address, validation.Required),
 		validation.Field(&o.PhoneNumber, validation.Required, validation.Match(regexp.MustCompile("^[0-9]{11,15}$"))),
+		validation.Field(&o.ServiceFee, validation.By(nonNegativeAmount)),
+		validation.Field(&o.Currency, validation.Match(currencyCode), validation.By(storedCurrency)),
 	)
 }
 
@@ -116,12 +246,19 @@ func validPassword(value interface{}) error {
 	return nil
 }
 
+// Flight is a flight of a line. The status and the estimated and actual times are changed
+// by the status operations only and are ignored on create and update
 type Flight struct {
-	ID        int       `json:"id"`
-	DepDate   time.Time `json:"dep_date"`
-	LineCode  string    `json:"line_code"`
-	IsHot     bool      `json:"is_hot"`
-	LinerCode string    `json:"liner_code"`
+	ID                 int        `json:"id"`
+	DepDate            time.Time  `json:"dep_date"`
+	LineCode           string     `json:"line_code"`
+	IsHot              bool       `json:"is_hot"`
+	LinerCode          string     `json:"liner_code"`
+	Status             string     `json:"status,omitempty"`
+	EstimatedDeparture *time.Time `json:"estimated_departure,omitempty"`
+	EstimatedArrival   *time.Time `json:"estimated_arrival,omitempty"`
+	ActualDeparture    *time.Time `json:"actual_departure,omitempty"`
+	ActualArrival      *time.Time `json:"actual_arrival,omitempty"`
 }
 
 func (f *Flight) Validate() error {
@@ -135,29 +272,44 @@ func (f *Flight) Validate() error {
 	)
 }
 
+// FlightInTicket is a flight of a ticket. Only infants may fly without a seat, in the class of the accompanying adult
 type FlightInTicket struct {
-	ID       int `json:"id"`
-	FlightID int `json:"flight_id"`
-	SeatID   int `json:"seat_id"`
-	TicketID int `json:"ticket_id"`
+	ID           int        `json:"id"`
+	FlightID     int        `json:"flight_id"`
+	SeatID       *int       `json:"seat_id"`
+	TicketID     int        `json:"ticket_id"`
+	Class        string     `json:"class"`
+	BookingClass string     `json:"booking_class,omitempty"`
+	CheckInState string     `json:"checkin_state,omitempty"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	BoardedAt    *time.Time `json:"boarded_at,omitempty"`
 }
 
 func (f *FlightInTicket) Validate() error {
 	return validation.ValidateStruct(f,
 		validation.Field(&f.ID),
 		validation.Field(&f.FlightID, validation.Required),
-		validation.Field(&f.SeatID, validation.Required),
+		validation.Field(&f.SeatID, validation.NilOrNotEmpty),
 		validation.Field(&f.TicketID, validation.Required),
+		validation.Field(&f.Class, validation.In("J", "W", "Y")),
+		validation.Field(&f.BookingClass, validation.Match(bookingClassCode)),
 	)
 }
 
+// Line is a scheduled route. Times are local to the airports, ArrDayOffset is the number of days
+// between the local dates of departure and arrival. The prices of the line and the fares sold on it are in Currency
 type Line struct {
-	LineCode   string  `json:"line_code"`
-	DepTime    string  `json:"dep_time"`
-	ArrTime    string  `json:"arr_time"`
-	BasePrice  float64 `json:"base_price"`
-	DepAirport string  `json:"dep_airport"`
-	ArrAirport string  `json:"arr_airport"`
+	LineCode      string      `json:"line_code"`
+	DepTime       string      `json:"dep_time"`
+	ArrTime       string      `json:"arr_time"`
+	ArrDayOffset  int         `json:"arr_day_offset"`
+	BasePrice     money.Money `json:"base_price"`
+	FuelSurcharge money.Money `json:"fuel_surcharge"`
+	Currency      string      `json:"currency"`
+	DepAirport    string      `json:"dep_airport"`
+	ArrAirport    string      `json:"arr_airport"`
+	DistanceKm    *int        `json:"distance_km,omitempty"`
+	BlockMinutes  *int        `json:"block_minutes,omitempty"`
 }
 
 func (l *Line) Validate() error {
@@ -165,7 +317,10 @@ func (l *Line) Validate() error {
 		validation.Field(&l.LineCode, validation.Required, validation.Length(3, 6), validation.Match(regexp.MustCompile("^[A-Z]{2}[0-9]{1,4}$"))),
 		validation.Field(&l.DepTime, validation.Required),
 		validation.Field(&l.ArrTime, validation.Required),
-		validation.Field(&l.BasePrice, validation.Required, validation.Min(0.0)),
+		validation.Field(&l.ArrDayOffset, validation.Min(0), validation.Max(maxArrDayOffset)),
+		validation.Field(&l.BasePrice, validation.By(positiveAmount)),
+		validation.Field(&l.FuelSurcharge, validation.By(nonNegativeAmount)),
+		validation.Field(&l.Currency, validation.Match(currencyCode), validation.By(storedCurrency)),
 		validation.Field(&l.DepAirport, validation.Required, validation.Length(3, 3), is.Alpha),
 		validation.Field(&l.ArrAirport, validation.Required, validation.Length(3, 3), is.Alpha),
 	)
@@ -195,14 +350,96 @@ func (m *LinerModel) Validate() error {
 	)
 }
 
+// SeatMap is the grid of the cabin of a liner model. SeatID is set for the seats generated from the layout
+type SeatMap struct {
+	ModelCode string       `json:"model_code"`
+	Rows      []SeatMapRow `json:"rows"`
+}
+
+type SeatMapRow struct {
+	Number int           `json:"number"`
+	Class  string        `json:"class"`
+	Exit   bool          `json:"exit"`
+	Cells  []SeatMapCell `json:"cells"`
+}
+
+type SeatMapCell struct {
+	Type            string   `json:"type"`
+	SeatID          *int     `json:"seat_id,omitempty"`
+	Number          string   `json:"number,omitempty"`
+	Class           string   `json:"class,omitempty"`
+	Position        string   `json:"position,omitempty"`
+	Blocked         bool     `json:"blocked,omitempty"`
+	Characteristics []string `json:"characteristics,omitempty"`
+}
+
+// SeatGeneration counts the seats changed to match the layout
+type SeatGeneration struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+type Passenger struct {
+	ID           int        `json:"id"`
+	LastName     string     `json:"last_name"`
+	GivenName    string     `json:"given_name"`
+	BirthDate    time.Time  `json:"birth_date"`
+	Sex          uint8      `json:"sex"`
+	Documents    []Document `json:"documents"`
+	ContactPhone string     `json:"contact_phone"`
+	ContactEmail string     `json:"contact_email"`
+}
+
+func (p *Passenger) Validate() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.ID),
+		validation.Field(&p.LastName, validation.Required, validation.Length(3, 64)),
+		validation.Field(&p.GivenName, validation.Required, validation.Length(3, 128)),
+		validation.Field(&p.BirthDate, validation.Required, validation.Max(time.Now())),
+		validation.Field(&p.Sex, validation.Required, validation.In(uint8(1), uint8(2))),
+		validation.Field(&p.Documents, validation.Required),
+		validation.Field(&p.ContactPhone, validation.Match(regexp.MustCompile("^[0-9]{11,15}$"))),
+		validation.Field(&p.ContactEmail, is.Email),
+	)
+}
+
+// Purchase is a purchase paid in Currency, the local one of the booking office unless given. On sale the total
+// is converted to roubles at the rate effective on the date of the purchase, which took effect on RateDate.
+// The promo code is applied on sale: the total is then the one paid after the Discount. Flights are needed
+// only for codes valid on some lines or classes. RedeemedMiles of the loyalty account pay for MilesAmount
+// of the total, which is then the rest paid
 type Purchase struct {
-	ID              int       `json:"id"`
-	Date            time.Time `json:"date"`
-	BookingOfficeID int       `json:"booking_office_id"`
-	TotalPrice      float64   `json:"total_price"`
-	ContactPhone    string    `json:"contact_phone"`
-	ContactEmail    string    `json:"contact_email"`
-	CashierID       string    `json:"cashier_id"`
+	ID               int              `json:"id"`
+	Date             time.Time        `json:"date"`
+	BookingOfficeID  int              `json:"booking_office_id"`
+	TotalPrice       money.Money      `json:"total_price"`
+	ContactPhone     string           `json:"contact_phone"`
+	ContactEmail     string           `json:"contact_email"`
+	CashierID        string           `json:"cashier_id"`
+	Currency         string           `json:"currency"`
+	ExchangeRate     float64          `json:"exchange_rate"`
+	RateDate         *time.Time       `json:"rate_date,omitempty"`
+	BaseTotalPrice   money.Money      `json:"base_total_price"`
+	PromoCode        string           `json:"promo_code,omitempty"`
+	Discount         money.Money      `json:"discount"`
+	Flights          []PurchaseFlight `json:"flights,omitempty"`
+	LoyaltyAccountID *int             `json:"loyalty_account_id"`
+	RedeemedMiles    int              `json:"redeemed_miles"`
+	MilesAmount      money.Money      `json:"miles_amount"`
+}
+
+// PurchaseFlight is a flight bought in the purchase in the class
+type PurchaseFlight struct {
+	FlightID int    `json:"flight_id"`
+	Class    string `json:"class"`
+}
+
+func (f PurchaseFlight) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.FlightID, validation.Required),
+		validation.Field(&f.Class, validation.Required, validation.In("J", "W", "Y")),
+	)
 }
 
 func (p *Purchase) Validate() error {
@@ -210,10 +447,15 @@ func (p *Purchase) Validate() error {
 		validation.Field(&p.ID),
 		validation.Field(&p.Date, validation.Required),
 		validation.Field(&p.BookingOfficeID, validation.Required),
-		validation.Field(&p.TotalPrice, validation.Required, validation.Min(0.0)),
+		validation.Field(&p.TotalPrice, validation.By(positiveAmount)),
 		validation.Field(&p.ContactPhone, validation.Required, validation.Match(regexp.MustCompile("^[0-9]{11,15}$"))),
 		validation.Field(&p.ContactEmail, validation.Required, is.Email),
 		validation.Field(&p.CashierID, validation.Required),
+		validation.Field(&p.Currency, validation.Match(currencyCode), validation.By(storedCurrency)),
+		validation.Field(&p.PromoCode, validation.Match(promoCode)),
+		validation.Field(&p.Flights),
+		validation.Field(&p.LoyaltyAccountID, validation.When(p.RedeemedMiles > 0, validation.Required)),
+		validation.Field(&p.RedeemedMiles, validation.Min(0)),
 	)
 }
 
@@ -234,13 +476,40 @@ func (s *Seat) Validate() error {
 }
 
 type Ticket struct {
-	ID                      int       `json:"id"`
-	PassengerLastName       string    `json:"passenger_last_name"`
-	PassengerGivenName      string    `json:"passenger_given_name"`
-	PassengerBirthDate      time.Time `json:"passenger_birth_date"`
-	PassengerPassportNumber string    `json:"passenger_passport_number"`
-	PassengerSex            uint8     `json:"passenger_sex"`
-	PurchaseID              int       `json:"purchase_id"`
+	ID                       int        `json:"id"`
+	PassengerLastName        string     `json:"passenger_last_name"`
+	PassengerGivenName       string     `json:"passenger_given_name"`
+	PassengerBirthDate       time.Time  `json:"passenger_birth_date"`
+	PassengerPassportNumber  string     `json:"passenger_passport_number"`
+	PassengerSex             uint8      `json:"passenger_sex"`
+	PurchaseID               int        `json:"purchase_id"`
+	PassengerID              *int       `json:"passenger_id,omitempty"`
+	PassengerDocumentType    string     `json:"passenger_document_type"`
+	PassengerDocumentCountry string     `json:"passenger_document_country"`
+	PassengerDocumentExpiry  *time.Time `json:"passenger_document_expiry,omitempty"`
+	AccompanyingTicketID     *int       `json:"accompanying_ticket_id,omitempty"`
+}
+
+// document returns the travel document of the passenger. Tickets sold before document types were introduced
+// carry the number of a russian internal passport only
+func (t *Ticket) document() Document {
+	if t.PassengerDocumentType == "" {
+		return Document{Type: documentRussianInternal, Number: t.PassengerPassportNumber, IssuingCountry: "RUS"}
+	}
+	return Document{
+		Type:           t.PassengerDocumentType,
+		Number:         t.PassengerPassportNumber,
+		IssuingCountry: t.PassengerDocumentCountry,
+		ExpiryDate:     t.PassengerDocumentExpiry,
+	}
+}
+
+// setDocument stores the travel document in the passenger fields of the ticket
+func (t *Ticket) setDocument(d Document) {
+	t.PassengerDocumentType = d.Type
+	t.PassengerPassportNumber = d.Number
+	t.PassengerDocumentCountry = d.IssuingCountry
+	t.PassengerDocumentExpiry = d.ExpiryDate
 }
 
 func (t *Ticket) Validate() error {
@@ -248,66 +517,551 @@ func (t *Ticket) Validate() error {
 		validation.Field(&t.ID),
 		validation.Field(&t.PassengerLastName, validation.Required, validation.Length(3, 64)),
 		validation.Field(&t.PassengerGivenName, validation.Required, validation.Length(3, 128)),
-		validation.Field(&t.PassengerBirthDate, validation.Required, validation.By(checkAgeOver18)),
-		validation.Field(&t.PassengerPassportNumber, validation.Required, validation.Length(10, 10), is.Digit),
+		validation.Field(&t.PassengerBirthDate, validation.Required, validation.Max(time.Now())),
+		validation.Field(&t.PassengerPassportNumber, validation.Required, validation.By(func(interface{}) error {
+			return t.document().Validate()
+		})),
 		validation.Field(&t.PassengerSex, validation.Required, validation.In(uint8(1), uint8(2))),
 		validation.Field(&t.PurchaseID, validation.Required),
+		validation.Field(&t.AccompanyingTicketID, validation.NilOrNotEmpty),
 	)
 }
 
 type AirportList struct {
 	Items      []Airport `json:"items"`
-	TotalCount int       `json:"total_count"`
+	TotalCount *int      `json:"total_count,omitempty"`
+	Prev       string    `json:"prev,omitempty"`
+	Next       string    `json:"next,omitempty"`
 }
 
 type BookingOfficeList struct {
 	Items      []BookingOffice `json:"items"`
-	TotalCount int             `json:"total_count"`
+	TotalCount *int            `json:"total_count,omitempty"`
+	Prev       string          `json:"prev,omitempty"`
+	Next       string          `json:"next,omitempty"`
 }
 
 type CashierList struct {
 	Items      []Cashier `json:"items"`
-	TotalCount int       `json:"total_count"`
+	TotalCount *int      `json:"total_count,omitempty"`
+	Prev       string    `json:"prev,omitempty"`
+	Next       string    `json:"next,omitempty"`
+}
+
+// FlightLeg is a flight with its departure and arrival instants
+type FlightLeg struct {
+	Flight
+	DepAirport string      `json:"dep_airport"`
+	ArrAirport string      `json:"arr_airport"`
+	Departure  time.Time   `json:"departure"`
+	Arrival    time.Time   `json:"arrival"`
+	BasePrice  money.Money `json:"base_price"`
+	Currency   string      `json:"currency"`
+	DistanceKm *int        `json:"distance_km,omitempty"`
+	Fares      []CabinFare `json:"fares,omitempty"`
+}
+
+// CabinFare is the lowest open booking class of a cabin of a flight with its adult fare
+type CabinFare struct {
+	Class        string      `json:"class"`
+	BookingClass string      `json:"booking_class,omitempty"`
+	Price        money.Money `json:"price"`
+	Available    int         `json:"available"`
+}
+
+// FlightStatusChange is a change of the operational status of a flight. Times not given are kept
+type FlightStatusChange struct {
+	ID                 int        `json:"id"`
+	FlightID           int        `json:"flight_id"`
+	PrevStatus         string     `json:"prev_status"`
+	Status             string     `json:"status"`
+	EstimatedDeparture *time.Time `json:"estimated_departure,omitempty"`
+	EstimatedArrival   *time.Time `json:"estimated_arrival,omitempty"`
+	ActualDeparture    *time.Time `json:"actual_departure,omitempty"`
+	ActualArrival      *time.Time `json:"actual_arrival,omitempty"`
+	Reason             string     `json:"reason"`
+	CashierID          int        `json:"cashier_id"`
+	ChangedAt          time.Time  `json:"changed_at"`
+}
+
+func (c *FlightStatusChange) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Status, validation.Required),
+		validation.Field(&c.Reason, validation.Length(0, 256)),
+	)
+}
+
+// FlightCancellation is a cancelled flight with the tickets to reprotect
+type FlightCancellation struct {
+	Flight          Flight           `json:"flight"`
+	AffectedTickets []AffectedTicket `json:"affected_tickets"`
+}
+
+type AffectedTicket struct {
+	SegmentID          int    `json:"flight_in_ticket_id"`
+	TicketID           int    `json:"ticket_id"`
+	PurchaseID         int    `json:"purchase_id"`
+	PassengerLastName  string `json:"passenger_last_name"`
+	PassengerGivenName string `json:"passenger_given_name"`
+	SeatNumber         string `json:"seat_number,omitempty"`
+	Class              string `json:"class"`
+	ContactPhone       string `json:"contact_phone"`
+	ContactEmail       string `json:"contact_email"`
+}
+
+// ReprotectionRequest asks to move the passengers of a cancelled flight. Without Apply only the proposal is returned
+type ReprotectionRequest struct {
+	Apply bool `json:"apply"`
+}
+
+// Reprotection lists the passengers of a cancelled flight with their new flights and those left without any
+type Reprotection struct {
+	FlightID int                    `json:"flight_id"`
+	Applied  bool                   `json:"applied"`
+	Moved    []ReprotectedPassenger `json:"moved"`
+	Unplaced []ReprotectedPassenger `json:"unplaced"`
+}
+
+type ReprotectedPassenger struct {
+	SegmentID          int              `json:"flight_in_ticket_id"`
+	TicketID           int              `json:"ticket_id"`
+	PassengerLastName  string           `json:"passenger_last_name"`
+	PassengerGivenName string           `json:"passenger_given_name"`
+	Class              string           `json:"class"`
+	Flights            []ReprotectedLeg `json:"flights,omitempty"`
+}
+
+type ReprotectedLeg struct {
+	FlightID   int       `json:"flight_id"`
+	LineCode   string    `json:"line_code"`
+	DepAirport string    `json:"dep_airport"`
+	ArrAirport string    `json:"arr_airport"`
+	Departure  time.Time `json:"departure"`
+	Arrival    time.Time `json:"arrival"`
+	SeatNumber string    `json:"seat_number,omitempty"`
+}
+
+// Manifest lists the passengers of a flight
+type Manifest struct {
+	Flight     FlightLeg           `json:"flight"`
+	Passengers []ManifestPassenger `json:"passengers"`
+}
+
+// ManifestPassenger is a passenger of a flight. An infant without a seat has the ticket of the escort in AccompanyingTicketID
+type ManifestPassenger struct {
+	SegmentID            int       `json:"flight_in_ticket_id"`
+	TicketID             int       `json:"ticket_id"`
+	LastName             string    `json:"last_name"`
+	GivenName            string    `json:"given_name"`
+	BirthDate            time.Time `json:"birth_date"`
+	Sex                  string    `json:"sex"`
+	PassengerType        string    `json:"passenger_type"`
+	DocumentType         string    `json:"document_type"`
+	DocumentNumber       string    `json:"document_number"`
+	DocumentCountry      string    `json:"document_country"`
+	SeatNumber           string    `json:"seat_number,omitempty"`
+	Class                string    `json:"class"`
+	AccompanyingTicketID *int      `json:"infant_of,omitempty"`
+}
+
+// BoardingPass is issued to a passenger checked in for a flight. Barcode is the IATA BCBP data
+// to be printed as PDF417 or QR code
+type BoardingPass struct {
+	SegmentID          int        `json:"flight_in_ticket_id"`
+	TicketID           int        `json:"ticket_id"`
+	BookingReference   string     `json:"booking_reference"`
+	PassengerLastName  string     `json:"passenger_last_name"`
+	PassengerGivenName string     `json:"passenger_given_name"`
+	FlightID           int        `json:"flight_id"`
+	LineCode           string     `json:"line_code"`
+	DepAirport         string     `json:"dep_airport"`
+	ArrAirport         string     `json:"arr_airport"`
+	Departure          time.Time  `json:"departure"`
+	SeatNumber         string     `json:"seat_number,omitempty"`
+	Class              string     `json:"class"`
+	Sequence           int        `json:"sequence"`
+	State              string     `json:"state"`
+	CheckedInAt        *time.Time `json:"checked_in_at"`
+	BoardedAt          *time.Time `json:"boarded_at,omitempty"`
+	Barcode            string     `json:"barcode"`
+}
+
+// BoardingScan is the barcode of a boarding pass read at the gate
+type BoardingScan struct {
+	Barcode string `json:"barcode"`
+}
+
+// FlightCapacityLimit is the overbooking limit of a class of a flight. AuthorizedCapacity, if set,
+// is the number of passengers that may be sold regardless of the percentage
+type FlightCapacityLimit struct {
+	Class              string  `json:"class"`
+	OverbookingPercent float64 `json:"overbooking_percent"`
+	AuthorizedCapacity *int    `json:"authorized_capacity"`
+}
+
+func (c *FlightCapacityLimit) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Class, validation.Required, validation.In("J", "W", "Y")),
+		validation.Field(&c.OverbookingPercent, validation.Min(0.0), validation.Max(float64(overbooking.MaxPercent))),
+		validation.Field(&c.AuthorizedCapacity, validation.Min(0)),
+	)
+}
+
+// FlightCapacity is the sales of a class of a flight against its physical seats and authorized capacity
+type FlightCapacity struct {
+	Class              string  `json:"class"`
+	Physical           int     `json:"physical"`
+	OverbookingPercent float64 `json:"overbooking_percent"`
+	AuthorizedCapacity *int    `json:"authorized_capacity,omitempty"`
+	Authorized         int     `json:"authorized"`
+	Seated             int     `json:"seated"`
+	Seatless           int     `json:"seatless"`
+	Sold               int     `json:"sold"`
+	Held               int     `json:"held"`
+	Available          int     `json:"available"`
+	CheckedIn          int     `json:"checked_in"`
+	Boarded            int     `json:"boarded"`
+	Oversold           int     `json:"oversold"`
+}
+
+// FlightLoad is the sales of every class of a flight. Oversold is the number of passengers sold without a physical seat
+type FlightLoad struct {
+	Flight   FlightLeg        `json:"flight"`
+	Classes  []FlightCapacity `json:"classes"`
+	Oversold int              `json:"oversold"`
+}
+
+var bookingClassCode = regexp.MustCompile("^[A-Z]$")
+
+var currencyCode = regexp.MustCompile("^[A-Z]{3}$")
+
+var promoCode = regexp.MustCompile("^[A-Z0-9]{3,32}$")
+
+// BookingClass is a booking class (RBD) of a cabin. The fare of the class is the base price of the line times
+// FareMultiplier. BookingLimitPercent is the nested booking limit of the class: the share of the authorized capacity
+// of the cabin that may be sold in the class and all the cheaper classes of the cabin together
+type BookingClass struct {
+	Code                string  `json:"code"`
+	Cabin               string  `json:"cabin"`
+	FareMultiplier      float64 `json:"fare_multiplier"`
+	BookingLimitPercent float64 `json:"booking_limit_percent"`
+}
+
+func (c *BookingClass) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Code, validation.Required, validation.Match(bookingClassCode)),
+		validation.Field(&c.Cabin, validation.Required, validation.In("J", "W", "Y")),
+		validation.Field(&c.FareMultiplier, validation.Required, validation.Min(0.0)),
+		validation.Field(&c.BookingLimitPercent, validation.Min(0.0), validation.Max(100.0)),
+	)
+}
+
+// FlightBookingLimit sets the nested booking limit of a booking class of a flight in passengers.
+// Without the limit the class returns to its share of the cabin
+type FlightBookingLimit struct {
+	BookingClass string `json:"booking_class"`
+	BookingLimit *int   `json:"booking_limit"`
+}
+
+func (l *FlightBookingLimit) Validate() error {
+	return validation.ValidateStruct(l,
+		validation.Field(&l.BookingClass, validation.Required, validation.Match(bookingClassCode)),
+		validation.Field(&l.BookingLimit, validation.Min(0)),
+	)
+}
+
+// BookingClassAvailability is the sales of a booking class of a flight against its nested booking limit
+type BookingClassAvailability struct {
+	BookingClass   string      `json:"booking_class"`
+	FareMultiplier float64     `json:"fare_multiplier"`
+	Price          money.Money `json:"price"`
+	BookingLimit   int         `json:"booking_limit"`
+	FlightLimit    bool        `json:"flight_limit"`
+	Sold           int         `json:"sold"`
+	Available      int         `json:"available"`
+}
+
+// CabinInventory is the booking classes of a cabin of a flight, the dearest first
+type CabinInventory struct {
+	Class          string                     `json:"class"`
+	Authorized     int                        `json:"authorized"`
+	Available      int                        `json:"available"`
+	BookingClasses []BookingClassAvailability `json:"booking_classes"`
+}
+
+type FlightInventory struct {
+	Flight FlightLeg        `json:"flight"`
+	Cabins []CabinInventory `json:"cabins"`
+}
+
+// FareRule is the conditions of the fare of a booking class. Stays and advance purchase are in days,
+// the ones not given are not restricted
+type FareRule struct {
+	Refundable          bool        `json:"refundable"`
+	ChangeFee           money.Money `json:"change_fee"`
+	NoShowFee           money.Money `json:"no_show_fee"`
+	MinStayDays         *int        `json:"min_stay_days,omitempty"`
+	MaxStayDays         *int        `json:"max_stay_days,omitempty"`
+	AdvancePurchaseDays *int        `json:"advance_purchase_days,omitempty"`
+}
+
+func (f *FareRule) Validate() error {
+	maxStay := []validation.Rule{validation.Min(0)}
+	if f.MinStayDays != nil {
+		maxStay = append(maxStay, validation.Min(*f.MinStayDays))
+	}
+	return validation.ValidateStruct(f,
+		validation.Field(&f.ChangeFee, validation.By(nonNegativeAmount)),
+		validation.Field(&f.NoShowFee, validation.By(nonNegativeAmount)),
+		validation.Field(&f.MinStayDays, validation.Min(0)),
+		validation.Field(&f.MaxStayDays, maxStay...),
+		validation.Field(&f.AdvancePurchaseDays, validation.Min(0)),
+	)
+}
+
+// SegmentRefundRequest asks to refund a segment. With Quote set the refund is only calculated
+type SegmentRefundRequest struct {
+	Quote bool `json:"quote"`
+}
+
+// SegmentRefund is the refund of a segment under the conditions of its fare. Usage is unused, no_show or flown,
+// an involuntary refund of a cancelled flight is made in full
+type SegmentRefund struct {
+	SegmentID    int                  `json:"flight_in_ticket_id"`
+	TicketID     int                  `json:"ticket_id"`
+	FlightID     int                  `json:"flight_id"`
+	BookingClass string               `json:"booking_class,omitempty"`
+	FareRules    *store.FareRuleModel `json:"fare_rules,omitempty"`
+	Usage        string               `json:"usage"`
+	Involuntary  bool                 `json:"involuntary"`
+	Fare         money.Money          `json:"fare"`
+	Penalty      money.Money          `json:"penalty"`
+	Amount       money.Money          `json:"amount"`
+	Currency     string               `json:"currency"`
+	Quote        bool                 `json:"quote"`
+}
+
+// SegmentExchangeRequest asks to move a segment to another flight, seat or booking class.
+// With Quote set the exchange is only calculated
+type SegmentExchangeRequest struct {
+	FlightID     int    `json:"flight_id"`
+	SeatID       *int   `json:"seat_id"`
+	Class        string `json:"class"`
+	BookingClass string `json:"booking_class"`
+	Quote        bool   `json:"quote"`
+}
+
+func (e *SegmentExchangeRequest) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.FlightID, validation.Required),
+		validation.Field(&e.SeatID, validation.NilOrNotEmpty),
+		validation.Field(&e.Class, validation.In("J", "W", "Y")),
+		validation.Field(&e.BookingClass, validation.Match(bookingClassCode)),
+	)
+}
+
+// SegmentExchange is the exchange of a segment under the conditions of its fare. Amount is collected
+// from the passenger, negative if it is returned to them
+type SegmentExchange struct {
+	SegmentID       int                  `json:"flight_in_ticket_id"`
+	TicketID        int                  `json:"ticket_id"`
+	FlightID        int                  `json:"flight_id"`
+	BookingClass    string               `json:"booking_class,omitempty"`
+	FareRules       *store.FareRuleModel `json:"fare_rules,omitempty"`
+	Fare            money.Money          `json:"fare"`
+	NewFlightID     int                  `json:"new_flight_id"`
+	NewSeatID       *int                 `json:"new_seat_id,omitempty"`
+	NewClass        string               `json:"new_class"`
+	NewBookingClass string               `json:"new_booking_class,omitempty"`
+	NewFareRules    *store.FareRuleModel `json:"new_fare_rules,omitempty"`
+	NewFare         money.Money          `json:"new_fare"`
+	Usage           string               `json:"usage"`
+	Involuntary     bool                 `json:"involuntary"`
+	Fee             money.Money          `json:"fee"`
+	Amount          money.Money          `json:"amount"`
+	Currency        string               `json:"currency"`
+	Quote           bool                 `json:"quote"`
+}
+
+// TicketOperation is a refund or an exchange of a segment of a ticket. Taxes are the airport taxes and the fuel
+// surcharge the segment was sold with, NewTaxes the ones of the segment it is exchanged for
+type TicketOperation struct {
+	ID              int          `json:"id"`
+	Operation       string       `json:"operation"`
+	FlightID        int          `json:"flight_id"`
+	BookingClass    string       `json:"booking_class,omitempty"`
+	Fare            money.Money  `json:"fare"`
+	NewFlightID     *int         `json:"new_flight_id,omitempty"`
+	NewBookingClass string       `json:"new_booking_class,omitempty"`
+	NewFare         *money.Money `json:"new_fare,omitempty"`
+	Taxes           money.Money  `json:"taxes"`
+	NewTaxes        *money.Money `json:"new_taxes,omitempty"`
+	Fee             money.Money  `json:"fee"`
+	Amount          money.Money  `json:"amount"`
+	Involuntary     bool         `json:"involuntary"`
+	CashierID       int          `json:"cashier_id"`
+	CreatedAt       time.Time    `json:"created_at"`
+	Currency        string       `json:"currency"`
+}
+
+// WaitlistRequest puts a ticket in the waitlist of a class of a flight. Higher priority is offered seats first
+type WaitlistRequest struct {
+	TicketID int    `json:"ticket_id"`
+	Class    string `json:"class"`
+	Priority int    `json:"priority"`
+}
+
+func (w *WaitlistRequest) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.TicketID, validation.Required),
+		validation.Field(&w.Class, validation.Required, validation.In("J", "W", "Y")),
+		validation.Field(&w.Priority, validation.Min(0)),
+	)
+}
+
+type WaitlistPriority struct {
+	Priority int `json:"priority"`
+}
+
+func (w *WaitlistPriority) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.Priority, validation.Min(0)),
+	)
+}
+
+// WaitlistEntry is a ticket waiting for a seat. An offered entry holds SeatID until OfferExpiresAt
+type WaitlistEntry struct {
+	ID                 int        `json:"id"`
+	FlightID           int        `json:"flight_id"`
+	Class              string     `json:"class"`
+	TicketID           int        `json:"ticket_id"`
+	PassengerLastName  string     `json:"passenger_last_name"`
+	PassengerGivenName string     `json:"passenger_given_name"`
+	Priority           int        `json:"priority"`
+	Status             string     `json:"status"`
+	SeatID             *int       `json:"seat_id,omitempty"`
+	OfferedAt          *time.Time `json:"offered_at,omitempty"`
+	OfferExpiresAt     *time.Time `json:"offer_expires_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	CashierID          int        `json:"cashier_id"`
+}
+
+type AircraftChangeRequest struct {
+	LinerCode string `json:"liner_code"`
+}
+
+func (a *AircraftChangeRequest) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.LinerCode, validation.Required, validation.Length(3, 7), validation.Match(regexp.MustCompile(("^[A-Z]{2}[0-9]{1,5}$")))),
+	)
+}
+
+// AircraftChange is a stored flight. If its liner was replaced with one of another model, Reseated lists
+// the passengers moved to other seats and Unplaced those left without a seat
+type AircraftChange struct {
+	Flight
+	PrevLinerCode string              `json:"prev_liner_code,omitempty"`
+	Reseated      []ReseatedPassenger `json:"reseated,omitempty"`
+	Unplaced      []ReseatedPassenger `json:"unplaced,omitempty"`
+}
+
+type ReseatedPassenger struct {
+	SegmentID          int    `json:"flight_in_ticket_id"`
+	TicketID           int    `json:"ticket_id"`
+	PassengerLastName  string `json:"passenger_last_name"`
+	PassengerGivenName string `json:"passenger_given_name"`
+	PrevSeat           string `json:"prev_seat"`
+	PrevClass          string `json:"prev_class"`
+	Seat               string `json:"seat,omitempty"`
+	Class              string `json:"class,omitempty"`
+}
+
+// RotationTurn is a flight of an aircraft with the ground time in seconds before its next flight.
+// Conflict is set if the aircraft cannot fly the next flight after this one
+type RotationTurn struct {
+	FlightLeg
+	GroundTime      *int   `json:"ground_time,omitempty"`
+	Conflict        string `json:"conflict,omitempty"`
+	ConflictMessage string `json:"conflict_message,omitempty"`
 }
 
 type FlightList struct {
 	Items      []Flight `json:"items"`
-	TotalCount int      `json:"total_count"`
+	TotalCount *int     `json:"total_count,omitempty"`
+	Prev       string   `json:"prev,omitempty"`
+	Next       string   `json:"next,omitempty"`
 }
 
 type FlightInTicketList struct {
 	Items      []FlightInTicket `json:"items"`
-	TotalCount int              `json:"total_count"`
+	TotalCount *int             `json:"total_count,omitempty"`
+	Prev       string           `json:"prev,omitempty"`
+	Next       string           `json:"next,omitempty"`
 }
 
 type LineList struct {
 	Items      []Line `json:"items"`
-	TotalCount int    `json:"total_count"`
+	TotalCount *int   `json:"total_count,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	Next       string `json:"next,omitempty"`
 }
 
 type LinerList struct {
 	Items      []Liner `json:"items"`
-	TotalCount int     `json:"total_count"`
+	TotalCount *int    `json:"total_count,omitempty"`
+	Prev       string  `json:"prev,omitempty"`
+	Next       string  `json:"next,omitempty"`
 }
 
 type LinerModelList struct {
 	Items      []LinerModel `json:"items"`
-	TotalCount int          `json:"total_count"`
+	TotalCount *int         `json:"total_count,omitempty"`
+	Prev       string       `json:"prev,omitempty"`
+	Next       string       `json:"next,omitempty"`
+}
+
+type PassengerList struct {
+	Items      []Passenger `json:"items"`
+	TotalCount *int        `json:"total_count,omitempty"`
+	Prev       string      `json:"prev,omitempty"`
+	Next       string      `json:"next,omitempty"`
+}
+
+type PassengerDuplicate struct {
+	Passengers [2]Passenger `json:"passengers"`
+	Similarity float64      `json:"similarity"`
+	Reasons    []string     `json:"reasons"`
+}
+
+type PassengerMerge struct {
+	DuplicateIDs []int `json:"duplicate_ids"`
+}
+
+func (m *PassengerMerge) Validate() error {
+	return validation.ValidateStruct(m,
+		validation.Field(&m.DuplicateIDs, validation.Required),
+	)
 }
 
 type PurchaseList struct {
 	Items      []Purchase `json:"items"`
-	TotalCount int        `json:"total_count"`
+	TotalCount *int       `json:"total_count,omitempty"`
+	Prev       string     `json:"prev,omitempty"`
+	Next       string     `json:"next,omitempty"`
 }
 
 type SeatList struct {
 	Items      []Seat `json:"items"`
-	TotalCount int    `json:"total_count"`
+	TotalCount *int   `json:"total_count,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	Next       string `json:"next,omitempty"`
 }
 
 type TicketList struct {
 	Items      []Ticket `json:"items"`
-	TotalCount int      `json:"total_count"`
+	TotalCount *int     `json:"total_count,omitempty"`
+	Prev       string   `json:"prev,omitempty"`
+	Next       string   `json:"next,omitempty"`
 }
 
 type TicketReport struct {
@@ -317,4 +1071,200 @@ type TicketReport struct {
 	BookingOffice BookingOffice                    `json:"booking_office"`
 	Cashier       Cashier                          `json:"cashier"`
 	Purchase      Purchase                         `json:"purchase"`
+	Operations    []TicketOperation                `json:"operations,omitempty"`
+	FareBreakdown FareBreakdown                    `json:"fare_breakdown"`
+	// Reconciliation is of the purchase the ticket is sold in
+	Reconciliation PurchaseReconciliation `json:"reconciliation"`
+}
+
+type TaxItem struct {
+	Code    string      `json:"code"`
+	Name    string      `json:"name"`
+	Airport string      `json:"airport"`
+	Amount  money.Money `json:"amount"`
+}
+
+// FareBreakdown is the price of a ticket itemised in Currency. The items sum to Total
+type FareBreakdown struct {
+	BaseFare      money.Money `json:"base_fare"`
+	Taxes         []TaxItem   `json:"taxes"`
+	TaxTotal      money.Money `json:"tax_total"`
+	FuelSurcharge money.Money `json:"fuel_surcharge"`
+	ServiceFee    money.Money `json:"service_fee"`
+	Total         money.Money `json:"total"`
+	Currency      string      `json:"currency"`
+}
+
+// PurchaseReconciliation checks the total of a purchase against the breakdowns of its tickets, in Currency.
+// Paid is the total before the discount and the miles less Refunded and with Exchanged, collected by the exchanges
+// or returned by them if negative. TicketsTotal includes Retained, the part of the segments refunded or exchanged
+// kept by the penalties, the fees and the fare differences not returned
+type PurchaseReconciliation struct {
+	TicketsTotal money.Money `json:"tickets_total"`
+	Retained     money.Money `json:"retained"`
+	Paid         money.Money `json:"paid"`
+	Refunded     money.Money `json:"refunded"`
+	Exchanged    money.Money `json:"exchanged"`
+	Difference   money.Money `json:"difference"`
+	Reconciled   bool        `json:"reconciled"`
+	Currency     string      `json:"currency"`
+}
+
+type Segment struct {
+	ID           int        `json:"id"`
+	FlightID     int        `json:"flight_id"`
+	DepDate      time.Time  `json:"dep_date"`
+	LineCode     string     `json:"line_code"`
+	DepAirport   string     `json:"dep_airport"`
+	ArrAirport   string     `json:"arr_airport"`
+	SeatNumber   string     `json:"seat_number"`
+	SeatClass    string     `json:"seat_class"`
+	BookingClass string     `json:"booking_class,omitempty"`
+	CheckInState string     `json:"checkin_state"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	BoardedAt    *time.Time `json:"boarded_at,omitempty"`
+}
+
+type PassengerSearchTicket struct {
+	Ticket   Ticket    `json:"ticket"`
+	Segments []Segment `json:"segments"`
+}
+
+type PassengerSearchPurchase struct {
+	Purchase Purchase                `json:"purchase"`
+	Tickets  []PassengerSearchTicket `json:"tickets"`
+}
+
+type PassengerSearchResult struct {
+	Items []PassengerSearchPurchase `json:"items"`
+}
+
+// ExchangeRate is the price of a unit of the currency in roubles from the effective date until the next rate
+type ExchangeRate struct {
+	Currency      string    `json:"currency"`
+	EffectiveDate time.Time `json:"effective_date"`
+	Rate          float64   `json:"rate"`
+}
+
+func (e *ExchangeRate) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.Currency, validation.Required, validation.Match(currencyCode), validation.By(storedCurrency), validation.NotIn(string(money.Default))),
+		validation.Field(&e.EffectiveDate, validation.Required),
+		validation.Field(&e.Rate, validation.Required, validation.Min(0.000001)),
+	)
+}
+
+// PromoCampaign is a discount campaign of a promo code valid from ValidFrom until ValidTo. Percent is the discount
+// of the percent kind, Amount in Currency the one of the fixed kind. Empty Lines and Classes allow any,
+// without limits the code may be used any number of times
+type PromoCampaign struct {
+	Code               string      `json:"code"`
+	Name               string      `json:"name"`
+	Kind               string      `json:"kind"`
+	Percent            float64     `json:"percent"`
+	Amount             money.Money `json:"amount"`
+	Currency           string      `json:"currency"`
+	ValidFrom          time.Time   `json:"valid_from"`
+	ValidTo            time.Time   `json:"valid_to"`
+	Lines              []string    `json:"lines"`
+	Classes            []string    `json:"classes"`
+	MaxUses            *int        `json:"max_uses"`
+	MaxUsesPerCustomer *int        `json:"max_uses_per_customer"`
+}
+
+func (c *PromoCampaign) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Code, validation.Required, validation.Match(promoCode)),
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 128)),
+		validation.Field(&c.Kind, validation.Required, validation.In(string(promo.Percent), string(promo.Fixed))),
+		validation.Field(&c.Percent, validation.When(c.Kind == string(promo.Percent), validation.Required, validation.Max(100.0))),
+		validation.Field(&c.Amount, validation.When(c.Kind == string(promo.Fixed), validation.By(positiveAmount))),
+		validation.Field(&c.Currency, validation.Match(currencyCode), validation.By(storedCurrency)),
+		validation.Field(&c.ValidFrom, validation.Required),
+		validation.Field(&c.ValidTo, validation.Required, validation.Min(c.ValidFrom)),
+		validation.Field(&c.Lines, validation.Each(validation.Match(regexp.MustCompile("^[A-Z]{2}[0-9]{1,4}$")))),
+		validation.Field(&c.Classes, validation.Each(validation.In("J", "W", "Y"))),
+		validation.Field(&c.MaxUses, validation.Min(1)),
+		validation.Field(&c.MaxUsesPerCustomer, validation.Min(1)),
+	)
+}
+
+// PromoUsage is a use of a promo code in a purchase by the customer, the contact email of the purchase.
+// BaseDiscount and BaseTotalPrice, the total paid, are in roubles
+type PromoUsage struct {
+	PurchaseID     int         `json:"purchase_id"`
+	Customer       string      `json:"customer"`
+	Discount       money.Money `json:"discount"`
+	Currency       string      `json:"currency"`
+	BaseDiscount   money.Money `json:"base_discount"`
+	BaseTotalPrice money.Money `json:"base_total_price"`
+	UsedAt         time.Time   `json:"used_at"`
+}
+
+// PromoUsageReport is the uses of a promo code with the discounts given and the revenue of the purchases in roubles
+type PromoUsageReport struct {
+	Code         string       `json:"code"`
+	Uses         int          `json:"uses"`
+	Customers    int          `json:"customers"`
+	BaseDiscount money.Money  `json:"base_discount"`
+	BaseRevenue  money.Money  `json:"base_revenue"`
+	Usages       []PromoUsage `json:"usages"`
+}
+
+// LoyaltyAccount is an account of a passenger in the loyalty programme. The tier is by the miles earned
+// in the last year, ExpiringMiles expire first on NextExpiry
+type LoyaltyAccount struct {
+	ID              int        `json:"id"`
+	PassengerID     int        `json:"passenger_id"`
+	EnrolledAt      time.Time  `json:"enrolled_at"`
+	Tier            string     `json:"tier"`
+	Balance         int        `json:"balance"`
+	QualifyingMiles int        `json:"qualifying_miles"`
+	ExpiredMiles    int        `json:"expired_miles"`
+	NextExpiry      *time.Time `json:"next_expiry,omitempty"`
+	ExpiringMiles   int        `json:"expiring_miles"`
+}
+
+func (a *LoyaltyAccount) Validate() error {
+	return validation.ValidateStruct(a,
+		validation.Field(&a.PassengerID, validation.Required),
+	)
+}
+
+// LoyaltyEntry is an entry of the ledger of a loyalty account: miles earned for the segment flown
+// or redeemed in the purchase
+type LoyaltyEntry struct {
+	ID         int        `json:"id"`
+	Kind       string     `json:"kind"`
+	Miles      int        `json:"miles"`
+	SegmentID  *int       `json:"segment_id,omitempty"`
+	PurchaseID *int       `json:"purchase_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// AirportImport is the result of an import of the OurAirports airports: the airports updated
+// and the ones skipped as unknown
+type AirportImport struct {
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// ImportResult is the result of an import of a file: the rows created and updated and the errors of the rows.
+// Nothing is saved if any row has an error or on a dry run
+type ImportResult struct {
+	Entity  string           `json:"entity"`
+	Mode    string           `json:"mode"`
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+// ImportRowError is an error of a row of an imported file. Row is the line of a CSV file or the position
+// in the array of a JSON file
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
 }
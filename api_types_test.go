@@ -7,35 +7,92 @@ import (
 	"time"
 )
 
-func Test_checkAgeOver18(t *testing.T) {
-	type args struct {
-		value interface{}
+func Test_ageAt(t *testing.T) {
+	today := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		birth time.Time
+		want  int
+	}{
+		{
+			name:  "born after the date",
+			birth: time.Date(2038, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  -1,
+		},
+		{
+			name:  "born the day before",
+			birth: today.Add(-time.Hour * 24),
+			want:  0,
+		},
+		{
+			name:  "birthday tomorrow",
+			birth: time.Date(2012, 3, 11, 0, 0, 0, 0, time.UTC),
+			want:  11,
+		},
+		{
+			name:  "birthday today",
+			birth: time.Date(2012, 3, 10, 0, 0, 0, 0, time.UTC),
+			want:  12,
+		},
+		{
+			name:  "born in 90s",
+			birth: time.Date(1995, 2, 2, 0, 0, 0, 0, time.UTC),
+			want:  29,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ageAt(tt.birth, today); got != tt.want {
+				t.Errorf("ageAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_Validate(t *testing.T) {
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name     string
+		document Document
+		wantErr  bool
 	}{
 		{
-			name:    "born after today",
-			wantErr: true,
-			args:    args{value: time.Date(2038, 1, 1, 0, 0, 0, 0, time.UTC)},
+			name:     "russian internal passport",
+			document: Document{Type: documentRussianInternal, Number: "4510123456", IssuingCountry: "RUS"},
+		},
+		{
+			name:     "russian internal passport with expiry",
+			document: Document{Type: documentRussianInternal, Number: "4510123456", IssuingCountry: "RUS", ExpiryDate: &expiry},
+			wantErr:  true,
+		},
+		{
+			name:     "international passport without expiry",
+			document: Document{Type: documentRussianInternational, Number: "751234567", IssuingCountry: "RUS"},
+			wantErr:  true,
+		},
+		{
+			name:     "foreign passport",
+			document: Document{Type: documentForeignPassport, Number: "C01X00T47", IssuingCountry: "DEU", ExpiryDate: &expiry},
+		},
+		{
+			name:     "birth certificate",
+			document: Document{Type: documentBirthCertificate, Number: "IV-АБ123456", IssuingCountry: "RUS"},
 		},
 		{
-			name:    "born yesterday",
-			wantErr: true,
-			args:    args{value: time.Now().Add(-time.Hour * 24)},
+			name:     "birth certificate issued abroad",
+			document: Document{Type: documentBirthCertificate, Number: "IV-АБ123456", IssuingCountry: "KAZ"},
+			wantErr:  true,
 		},
 		{
-			name:    "born in 90s",
-			wantErr: false,
-			args:    args{value: time.Date(1995, 2, 2, 0, 0, 0, 0, time.UTC)},
+			name:     "unknown type",
+			document: Document{Type: "visa", Number: "123", IssuingCountry: "RUS"},
+			wantErr:  true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := checkAgeOver18(tt.args.value); (err != nil) != tt.wantErr {
-				t.Errorf("checkAgeOver18() error = %v, wantErr %v", err, tt.wantErr)
+			if err := tt.document.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Document.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
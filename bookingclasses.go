@@ -0,0 +1,399 @@
+// Файл bookingclasses.go содержит классы бронирования (RBD) внутри салонов, их вложенные лимиты на рейсе и продажу самого дешёвого открытого класса
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akionka/aviasales/internal/fareclass"
+	"github.com/akionka/aviasales/internal/overbooking"
+	"github.com/akionka/aviasales/internal/pricing"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errBookingClassesClosed = segmentRuleError("все классы бронирования этого класса рейса закрыты для продажи")
+	errBookingClassClosed   = segmentRuleError("класс бронирования закрыт для продажи на этом рейсе")
+	errBookingClassCabin    = segmentRuleError("класс бронирования не относится к классу обслуживания сегмента")
+)
+
+func bookingClassOf(code *string) string {
+	if code == nil {
+		return ""
+	}
+	return *code
+}
+
+func bookingClassPtr(code string) *string {
+	if code == "" {
+		return nil
+	}
+	return &code
+}
+
+// flightInventory returns the booking classes of every cabin of the flight with their availability, the dearest first.
+// Infants without a seat are not counted, nor is the segment exceptID. Segments sold before the booking classes
+// count against the dearest class of their cabin
+func (s *server) flightInventory(st store.Store, flight *store.FlightModel, loads map[string]*overbooking.Load, exceptID int) (map[string][]fareclass.Availability, error) {
+	classes, err := st.BookingClass().FindAll()
+	if err != nil {
+		return nil, err
+	}
+	limits, err := st.BookingClass().FindFlightLimits(flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]*int, len(limits))
+	for i := range limits {
+		overrides[limits[i].BookingClass] = &limits[i].BookingLimit
+	}
+
+	entries, err := st.FlightInTicket().FindManifest(flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	sold := make(map[string]map[string]int, len(cabinClasses))
+	for _, e := range entries {
+		if e.SegmentID == exceptID || e.SeatNumber == "" && e.AccompanyingTicketID != nil {
+			continue
+		}
+		if sold[e.Class] == nil {
+			sold[e.Class] = make(map[string]int)
+		}
+		sold[e.Class][bookingClassOf(e.BookingClass)]++
+	}
+
+	buckets := make(map[string][]fareclass.Bucket, len(cabinClasses))
+	for _, c := range classes {
+		l, ok := loads[c.Cabin]
+		if !ok {
+			continue
+		}
+		buckets[c.Cabin] = append(buckets[c.Cabin], fareclass.Bucket{
+			Code:       c.Code,
+			Cabin:      c.Cabin,
+			Multiplier: c.FareMultiplier,
+			Limit:      fareclass.LimitOf(l.Authorized(), c.BookingLimitPercent, overrides[c.Code]),
+		})
+	}
+	inventory := make(map[string][]fareclass.Availability, len(buckets))
+	for cabin, b := range buckets {
+		inventory[cabin] = fareclass.Inventory(b, sold[cabin], loads[cabin].Available())
+	}
+	return inventory, nil
+}
+
+// sellBookingClass sets the booking class the segment is sold in: the class asked for if it is open, the class
+// the segment was sold in before while it stays on the flight, or else the cheapest open class of the cabin.
+// A cabin without booking classes is sold at the fare of the cabin
+func (s *server) sellBookingClass(st store.Store, f *store.FlightInTicketModel, flight *store.FlightModel, loads map[string]*overbooking.Load) error {
+	inventory, err := s.flightInventory(st, flight, loads, f.ID)
+	if err != nil {
+		return err
+	}
+	cabin := inventory[f.Class]
+
+	if f.ID != 0 {
+		current, err := st.FlightInTicket().Find(f.ID)
+		if err != nil {
+			return err
+		}
+		if current.FlightID == f.FlightID && current.BookingClass != nil && fareclass.Find(cabin, *current.BookingClass) != nil &&
+			(f.BookingClass == nil || *f.BookingClass == *current.BookingClass) {
+			f.BookingClass = current.BookingClass
+			return nil
+		}
+	}
+
+	if f.BookingClass != nil {
+		b := fareclass.Find(cabin, *f.BookingClass)
+		if b == nil {
+			return errBookingClassCabin
+		}
+		if b.Available <= 0 {
+			return errBookingClassClosed
+		}
+		return nil
+	}
+
+	if len(cabin) == 0 {
+		return nil
+	}
+	lowest, err := fareclass.LowestOpen(cabin)
+	if err == fareclass.ErrSoldOut {
+		return errBookingClassesClosed
+	}
+	if err != nil {
+		return err
+	}
+	f.BookingClass = bookingClassPtr(lowest.Code)
+	return nil
+}
+
+// cabinFares returns the lowest open booking class of every cabin of the flight on sale with the adult fare
+func (s *server) cabinFares(leg *store.FlightLegModel) ([]CabinFare, error) {
+	loads, err := s.classLoads(s.store, &leg.FlightModel, 0)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.flightInventory(s.store, &leg.FlightModel, loads, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var fares []CabinFare
+	for _, class := range cabinClasses {
+		l := loads[class]
+		if l.Available() == 0 {
+			continue
+		}
+		if len(inventory[class]) == 0 {
+			fares = append(fares, CabinFare{
+				Class:     class,
+				Price:     pricing.Fare(leg.BasePrice, class, leg.IsHot, pricing.Adult, true),
+				Available: l.Available(),
+			})
+			continue
+		}
+		lowest, err := fareclass.LowestOpen(inventory[class])
+		if err != nil {
+			continue
+		}
+		fares = append(fares, CabinFare{
+			Class:        class,
+			BookingClass: lowest.Code,
+			Price:        pricing.BucketFare(leg.BasePrice, lowest.Multiplier, leg.IsHot, pricing.Adult, true),
+			Available:    lowest.Available,
+		})
+	}
+	return fares, nil
+}
+
+func (s *server) flightInventoryOf(leg *store.FlightLegModel) (*FlightInventory, error) {
+	flight, err := flightLegFromModel(leg)
+	if err != nil {
+		return nil, err
+	}
+	loads, err := s.classLoads(s.store, &leg.FlightModel, 0)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.flightInventory(s.store, &leg.FlightModel, loads, 0)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.store.BookingClass().FindFlightLimits(leg.ID)
+	if err != nil {
+		return nil, err
+	}
+	overridden := make(map[string]bool, len(limits))
+	for _, v := range limits {
+		overridden[v.BookingClass] = true
+	}
+
+	response := &FlightInventory{Flight: *flight, Cabins: []CabinInventory{}}
+	for _, class := range cabinClasses {
+		l := loads[class]
+		if l.Physical == 0 && l.Sold() == 0 {
+			continue
+		}
+		cabin := CabinInventory{
+			Class:          class,
+			Authorized:     l.Authorized(),
+			Available:      l.Available(),
+			BookingClasses: make([]BookingClassAvailability, len(inventory[class])),
+		}
+		for i, b := range inventory[class] {
+			cabin.BookingClasses[i] = BookingClassAvailability{
+				BookingClass:   b.Code,
+				FareMultiplier: b.Multiplier,
+				Price:          pricing.BucketFare(leg.BasePrice, b.Multiplier, leg.IsHot, pricing.Adult, true),
+				BookingLimit:   b.Limit,
+				FlightLimit:    overridden[b.Code],
+				Sold:           b.Sold,
+				Available:      b.Available,
+			}
+		}
+		response.Cabins = append(response.Cabins, cabin)
+	}
+	return response, nil
+}
+
+// handleFlightInventoryGetUpdate returns the booking classes of the cabins of the flight with their availability
+// or sets the booking limits of the booking classes given for the flight
+func (s *server) handleFlightInventoryGetUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		leg, err := s.store.Flight().FindLeg(id)
+		if err != nil {
+			code, err := flightStatusErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var limits []FlightBookingLimit
+			if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			for i := range limits {
+				if err := limits[i].Validate(); err != nil {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				if _, err := s.store.BookingClass().Find(limits[i].BookingClass); err != nil {
+					if err == sql.ErrNoRows {
+						s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+						return
+					}
+					s.error(w, r, http.StatusInternalServerError, err)
+					return
+				}
+			}
+			if err := s.store.Transaction(func(tx store.Store) error {
+				for _, v := range limits {
+					if v.BookingLimit == nil {
+						if err := tx.BookingClass().DeleteFlightLimit(id, v.BookingClass); err != nil {
+							return err
+						}
+						continue
+					}
+					if err := tx.BookingClass().SetFlightLimit(&store.FlightBookingLimitModel{
+						FlightID:     id,
+						BookingClass: v.BookingClass,
+						BookingLimit: *v.BookingLimit,
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		inventory, err := s.flightInventoryOf(leg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, inventory)
+	}
+}
+
+func (s *server) handleBookingClassesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		classes, err := s.store.BookingClass().FindAll()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]BookingClass, len(classes))
+		for i, v := range classes {
+			response[i] = BookingClass{
+				Code:                v.Code,
+				Cabin:               v.Cabin,
+				FareMultiplier:      v.FareMultiplier,
+				BookingLimitPercent: v.BookingLimitPercent,
+			}
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+func (s *server) handleBookingClassesCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := &BookingClass{}
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := c.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.BookingClass().Create(&store.BookingClassModel{
+			Code:                c.Code,
+			Cabin:               c.Cabin,
+			FareMultiplier:      c.FareMultiplier,
+			BookingLimitPercent: c.BookingLimitPercent,
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, c)
+	}
+}
+
+func (s *server) handleBookingClassGetDeleteUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		c, err := s.store.BookingClass().Find(vars["code"])
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			s.respond(w, r, http.StatusOK, &BookingClass{
+				Code:                c.Code,
+				Cabin:               c.Cabin,
+				FareMultiplier:      c.FareMultiplier,
+				BookingLimitPercent: c.BookingLimitPercent,
+			})
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := s.store.BookingClass().Delete(vars["code"]); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			c := &BookingClass{}
+			if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := c.Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+
+			if err := s.store.BookingClass().Update(vars["code"], &store.BookingClassModel{
+				Code:                c.Code,
+				Cabin:               c.Cabin,
+				FareMultiplier:      c.FareMultiplier,
+				BookingLimitPercent: c.BookingLimitPercent,
+			}); err != nil {
+				if err == mysqlstore.ErrNoChanges {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, c)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Файл checkin.go содержит регистрацию пассажиров на рейс, посадочные талоны и посадку по штрихкоду
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/bcbp"
+	"github.com/akionka/aviasales/internal/checkin"
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/overbooking"
+	"github.com/akionka/aviasales/internal/pdf"
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/akionka/aviasales/internal/translit"
+	"github.com/gorilla/mux"
+)
+
+var errBoardingPassOtherFlight = errors.New("посадочный талон выдан на другой рейс")
+
+// checkinErrors are the messages shown for the errors of check-in and boarding
+var checkinErrors = map[error]error{
+	checkin.ErrNotOpen:         fmt.Errorf("регистрация на рейс открывается за %d ч до вылета", int(checkin.Opens.Hours())),
+	checkin.ErrClosed:          fmt.Errorf("регистрация на рейс закрывается за %d мин до вылета", int(checkin.Closes.Minutes())),
+	checkin.ErrFlightNotFlying: errors.New("рейс отменён или уже вылетел"),
+	checkin.ErrNotCheckedIn:    errors.New("пассажир не зарегистрирован на рейс"),
+	checkin.ErrAlreadyBoarded:  errors.New("пассажир уже прошёл на посадку"),
+	checkin.ErrBoardingNotOpen: errors.New("посадка на рейс ещё не началась"),
+	bcbp.ErrFormat:             errors.New("штрихкод не является посадочным талоном IATA BCBP"),
+	bcbp.ErrMultipleLeg:        errors.New("посадочный талон на несколько рейсов не поддерживается"),
+	errBoardingPassOtherFlight: errBoardingPassOtherFlight,
+	overbooking.ErrNoSeat:      errors.New("свободных мест в классе нет, рейс перепродан"),
+}
+
+// checkinErrorStatus returns the status code for an error of check-in or boarding
+func checkinErrorStatus(err error) (int, error) {
+	if e, ok := checkinErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, errRequestedItemDoesNotExist
+	}
+	if err == mysqlstore.ErrNoChanges {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+// departure returns the instant the flight is expected to depart at: the estimate of a delayed flight
+// or the scheduled one
+func departure(leg *store.FlightLegModel) (time.Time, error) {
+	if leg.EstDepTime != nil {
+		return *leg.EstDepTime, nil
+	}
+	dep, _, err := schedule.Times(leg.DepDate, leg.DepTime, leg.ArrTime, leg.ArrDayOffset, leg.DepTimezone, leg.ArrTimezone)
+	return dep, err
+}
+
+// bookingReference returns the reference of the purchase printed on boarding passes
+func bookingReference(purchaseID int) string {
+	return strings.ToUpper(strconv.FormatInt(int64(purchaseID), 36))
+}
+
+// bcbpName returns the name as printed in the barcode: upper case latin
+func bcbpName(name string) string {
+	return strings.ToUpper(translit.Text(name))
+}
+
+// boardingPass returns the boarding pass of the checked in segment
+func (s *server) boardingPass(st store.Store, segment *store.FlightInTicketModel) (*BoardingPass, error) {
+	if segment.CheckedInAt == nil || segment.CheckInSequence == nil {
+		return nil, checkin.ErrNotCheckedIn
+	}
+	leg, err := st.Flight().FindLeg(segment.FlightID)
+	if err != nil {
+		return nil, err
+	}
+	dep, err := departure(leg)
+	if err != nil {
+		return nil, err
+	}
+	t, err := st.Ticket().Find(segment.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	seatNumber := ""
+	if segment.SeatID != nil {
+		seat, err := st.Seat().Find(*segment.SeatID)
+		if err != nil {
+			return nil, err
+		}
+		seatNumber = seat.Number
+	}
+
+	pass := &BoardingPass{
+		SegmentID:          segment.ID,
+		TicketID:           t.ID,
+		BookingReference:   bookingReference(t.PurchaseID),
+		PassengerLastName:  t.PassengerLastName,
+		PassengerGivenName: t.PassengerGivenName,
+		FlightID:           leg.ID,
+		LineCode:           leg.LineCode,
+		DepAirport:         leg.DepAirport,
+		ArrAirport:         leg.ArrAirport,
+		Departure:          dep,
+		SeatNumber:         seatNumber,
+		Class:              segment.Class,
+		Sequence:           *segment.CheckInSequence,
+		State:              string(checkin.StateOf(segment.CheckedInAt, segment.BoardedAt)),
+		CheckedInAt:        segment.CheckedInAt,
+		BoardedAt:          segment.BoardedAt,
+	}
+	pass.Barcode = bcbp.Encode(&bcbp.Pass{
+		LastName:  bcbpName(t.PassengerLastName),
+		GivenName: bcbpName(t.PassengerGivenName),
+		PNR:       pass.BookingReference,
+		From:      leg.DepAirport,
+		To:        leg.ArrAirport,
+		Carrier:   leg.LineCode[:2],
+		FlightNo:  leg.LineCode[2:],
+		Date:      leg.DepDate,
+		Class:     segment.Class,
+		Seat:      seatNumber,
+		Sequence:  pass.Sequence,
+		ETicket:   true,
+		CheckedIn: true,
+	})
+	return pass, nil
+}
+
+func (s *server) writeBoardingPassPDF(w http.ResponseWriter, p *BoardingPass) {
+	seat := p.SeatNumber
+	if seat == "" {
+		seat = "INF"
+	}
+	doc := &pdf.Document{
+		Title: fmt.Sprintf("Boarding pass %s %s", p.LineCode, p.Departure.Format("2006-01-02")),
+		Lines: []string{
+			"BOARDING PASS",
+			"",
+			fmt.Sprintf("Passenger:      %s/%s", bcbpName(p.PassengerLastName), bcbpName(p.PassengerGivenName)),
+			fmt.Sprintf("Booking ref:    %s", p.BookingReference),
+			fmt.Sprintf("Ticket:         %d", p.TicketID),
+			"",
+			fmt.Sprintf("Flight:         %s", p.LineCode),
+			fmt.Sprintf("From:           %s", p.DepAirport),
+			fmt.Sprintf("To:             %s", p.ArrAirport),
+			fmt.Sprintf("Departure:      %s", p.Departure.UTC().Format("2006-01-02 15:04 MST")),
+			"",
+			fmt.Sprintf("Seat:           %s", seat),
+			fmt.Sprintf("Class:          %s", p.Class),
+			fmt.Sprintf("Sequence:       %03d", p.Sequence),
+			"",
+			"BCBP:",
+			p.Barcode,
+		},
+	}
+
+	w.Header().Set("Content-Type", contentTypePDF)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"boarding-pass-%d.pdf\"", p.SegmentID))
+	w.WriteHeader(http.StatusOK)
+	doc.WriteTo(w)
+}
+
+// assignSeat gives the frontmost free seat of the class to a passenger sold without a seat.
+// Having no seat for the passenger means the flight is oversold, which is reported for the staff to deal with.
+// The flight is locked while the seat is chosen, so two passengers checking in at once do not get the same seat
+func (s *server) assignSeat(tx store.Store, flight *store.FlightModel, segment *store.FlightInTicketModel) error {
+	if segment.SeatID != nil {
+		return nil
+	}
+	t, err := tx.Ticket().Find(segment.TicketID)
+	if err != nil {
+		return err
+	}
+	if isLapInfant(t, segment) {
+		return nil
+	}
+	if err := tx.FlightInTicket().LockFlight(flight.ID); err != nil {
+		return err
+	}
+	seat, err := s.freeSeat(tx, flight, segment.Class)
+	if err == overbooking.ErrNoSeat {
+		log.Printf("oversold: flight %d (%s %s) has no free seat in class %s for ticket %d at check-in",
+			flight.ID, flight.LineCode, flight.DepDate.Format("2006-01-02"), segment.Class, segment.TicketID)
+	}
+	if err != nil {
+		return err
+	}
+	segment.SeatID = &seat.ID
+	return tx.FlightInTicket().Update(segment.ID, segment)
+}
+
+// handleSegmentCheckIn checks the passenger in for the flight of the segment and returns the boarding pass.
+// Checking in again returns the pass issued before
+func (s *server) handleSegmentCheckIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		var pass *BoardingPass
+		if err := s.store.Transaction(func(tx store.Store) error {
+			segment, err := tx.FlightInTicket().Find(id)
+			if err != nil {
+				return err
+			}
+			if segment.CheckedInAt == nil {
+				leg, err := tx.Flight().FindLeg(segment.FlightID)
+				if err != nil {
+					return err
+				}
+				dep, err := departure(leg)
+				if err != nil {
+					return err
+				}
+				now := time.Now().UTC().Truncate(time.Second)
+				if err := checkin.CanCheckIn(flightstatus.Status(leg.Status), dep, now); err != nil {
+					return err
+				}
+				if err := s.assignSeat(tx, &leg.FlightModel, segment); err != nil {
+					return err
+				}
+				sequence, err := tx.FlightInTicket().CheckIn(id, now)
+				if err != nil {
+					return err
+				}
+				segment.CheckedInAt, segment.CheckInSequence = &now, &sequence
+			}
+			pass, err = s.boardingPass(tx, segment)
+			return err
+		}); err != nil {
+			code, err := checkinErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, pass)
+	}
+}
+
+// handleSegmentBoardingPassGet returns the boarding pass of the checked in segment as JSON or PDF
+func (s *server) handleSegmentBoardingPassGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		format := negotiate(r.Header.Get("Accept"), contentTypeJSON, contentTypePDF)
+		if format == "" {
+			s.error(w, r, http.StatusNotAcceptable, errors.New("посадочный талон доступен в форматах "+contentTypeJSON+", "+contentTypePDF))
+			return
+		}
+
+		segment, err := s.store.FlightInTicket().Find(id)
+		if err != nil {
+			code, err := checkinErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		pass, err := s.boardingPass(s.store, segment)
+		if err != nil {
+			code, err := checkinErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		if format == contentTypePDF {
+			s.writeBoardingPassPDF(w, pass)
+			return
+		}
+		s.respond(w, r, http.StatusOK, pass)
+	}
+}
+
+// handleFlightBoardingScan boards the passenger whose boarding pass barcode was scanned at the gate of the flight
+func (s *server) handleFlightBoardingScan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		req := &BoardingScan{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		scanned, err := bcbp.Parse(req.Barcode)
+		if err != nil {
+			code, err := checkinErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		var pass *BoardingPass
+		if err := s.store.Transaction(func(tx store.Store) error {
+			leg, err := tx.Flight().FindLeg(id)
+			if err != nil {
+				return err
+			}
+			if !scanned.IsFlight(leg.LineCode[:2], leg.LineCode[2:]) || scanned.From != leg.DepAirport || scanned.DayOfYear != leg.DepDate.YearDay() {
+				return errBoardingPassOtherFlight
+			}
+			segment, err := tx.FlightInTicket().FindByCheckInSequence(id, scanned.Sequence)
+			if err == sql.ErrNoRows {
+				return checkin.ErrNotCheckedIn
+			}
+			if err != nil {
+				return err
+			}
+			if err := checkin.CanBoard(flightstatus.Status(leg.Status), checkin.StateOf(segment.CheckedInAt, segment.BoardedAt)); err != nil {
+				return err
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			if err := tx.FlightInTicket().Board(segment.ID, now); err != nil {
+				return err
+			}
+			segment.BoardedAt = &now
+			if err := s.accrueMiles(tx, segment, leg, now); err != nil {
+				return err
+			}
+			pass, err = s.boardingPass(tx, segment)
+			return err
+		}); err != nil {
+			code, err := checkinErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, pass)
+	}
+}
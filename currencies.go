@@ -0,0 +1,226 @@
+// Файл currencies.go содержит курсы валют, их загрузку из CSV и пересчёт сумм между валютами по курсу на дату
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/exchange"
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+// currencyErrors are the messages shown for the errors of amounts and exchange rates
+var currencyErrors = map[error]error{
+	exchange.ErrNoRate:   errors.New("нет курса валюты на эту дату"),
+	exchange.ErrCurrency: errors.New("код валюты должен состоять из трёх латинских букв по ISO 4217"),
+	exchange.ErrRate:     errors.New("курс валюты должен быть положительным числом"),
+	exchange.ErrMinor:    errors.New("валюты с тремя знаками после запятой не поддерживаются"),
+	money.ErrPrecision:   errors.New("в сумме больше знаков после запятой, чем у валюты"),
+}
+
+// currencyErrorStatus returns the status code for an error of converting amounts
+func currencyErrorStatus(err error) (int, error) {
+	if e, ok := currencyErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	return http.StatusInternalServerError, err
+}
+
+// currencyOf returns the currency of the code, the default one for amounts stored before currencies
+func currencyOf(code string) money.Currency {
+	if code == "" {
+		return money.Default
+	}
+	return money.Currency(code)
+}
+
+// inCurrency puts the amounts decoded from a request in the currency, they are decoded in the default one
+func inCurrency(c money.Currency, amounts ...*money.Money) error {
+	for _, m := range amounts {
+		v, err := m.In(c)
+		if err != nil {
+			return err
+		}
+		*m = v
+	}
+	return nil
+}
+
+// exchangeTable returns the table of the exchange rates against the default currency
+func (s *server) exchangeTable(st store.Store) (*exchange.Table, error) {
+	models, err := st.ExchangeRate().FindAll()
+	if err != nil {
+		return nil, err
+	}
+	rates := make([]exchange.Rate, len(models))
+	for i, m := range models {
+		rates[i] = exchange.Rate{Currency: money.Currency(m.Currency), EffectiveDate: m.EffectiveDate, Rate: m.Rate}
+	}
+	return exchange.NewTable(money.Default, rates), nil
+}
+
+// converter returns a function converting amounts to the currency at the rates effective on the date
+func (s *server) converter(st store.Store, c money.Currency, date time.Time) (func(money.Money) (money.Money, error), error) {
+	table, err := s.exchangeTable(st)
+	if err != nil {
+		return nil, err
+	}
+	return func(m money.Money) (money.Money, error) {
+		conversion, err := table.Convert(m, c, date)
+		return conversion.To, err
+	}, nil
+}
+
+// purchaseModel puts the total of the purchase in its currency, the local one of the booking office unless given,
+// and converts it to the default currency at the rate effective on the date of the purchase
+func (s *server) purchaseModel(p *Purchase) (*store.PurchaseModel, error) {
+	office, err := s.store.BookingOffice().Find(p.BookingOfficeID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Currency == "" {
+		p.Currency = office.Currency
+	}
+	if err := inCurrency(currencyOf(p.Currency), &p.TotalPrice); err != nil {
+		return nil, err
+	}
+	table, err := s.exchangeTable(s.store)
+	if err != nil {
+		return nil, err
+	}
+	conversion, err := table.Convert(p.TotalPrice, money.Default, p.Date)
+	if err != nil {
+		return nil, err
+	}
+	p.ExchangeRate = conversion.Rate
+	p.RateDate = conversion.EffectiveDate
+	p.BaseTotalPrice = conversion.To
+	// The service fee for a ticket is recorded as the office charges it at the time of the purchase
+	fee, feeCurrency := office.ServiceFee, office.Currency
+
+	return &store.PurchaseModel{
+		ID:                 p.ID,
+		Date:               p.Date,
+		BookingOfficeID:    p.BookingOfficeID,
+		TotalPrice:         p.TotalPrice,
+		ContactPhone:       p.ContactPhone,
+		ContactEmail:       p.ContactEmail,
+		CashierID:          p.CashierID,
+		Currency:           p.Currency,
+		ExchangeRate:       p.ExchangeRate,
+		RateDate:           p.RateDate,
+		BaseTotalPrice:     p.BaseTotalPrice,
+		ServiceFee:         &fee,
+		ServiceFeeCurrency: &feeCurrency,
+	}, nil
+}
+
+// handleExchangeRatesGet lists the exchange rates against the default currency. With the date only the rates
+// effective on it are listed, with the currency only the rates of the currency
+func (s *server) handleExchangeRatesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currency := strings.ToUpper(r.URL.Query().Get("currency"))
+		var date *time.Time
+		if v := r.URL.Query().Get("date"); v != "" {
+			d, err := time.Parse(exchange.DateLayout, v)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, errBadDate)
+				return
+			}
+			date = &d
+		}
+
+		models, err := s.store.ExchangeRate().FindAll()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := []ExchangeRate{}
+		for i, m := range models {
+			if currency != "" && m.Currency != currency {
+				continue
+			}
+			// Rates are ordered by the date, so the one effective on the date is the last one of the currency before it
+			if date != nil {
+				last := i+1 == len(models) || models[i+1].Currency != m.Currency || models[i+1].EffectiveDate.After(*date)
+				if m.EffectiveDate.After(*date) || !last {
+					continue
+				}
+			}
+			response = append(response, ExchangeRate{Currency: m.Currency, EffectiveDate: m.EffectiveDate, Rate: m.Rate})
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleExchangeRatesUpdate sets the exchange rates given as a JSON list or, with the text/csv content type,
+// as CSV records of the currency, the effective date and the rate. The rates set for the same dates are replaced
+func (s *server) handleExchangeRatesUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rates []ExchangeRate
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == contentTypeCSV {
+			parsed, err := exchange.ParseCSV(r.Body)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			for _, v := range parsed {
+				rates = append(rates, ExchangeRate{Currency: string(v.Currency), EffectiveDate: v.EffectiveDate, Rate: v.Rate})
+			}
+		} else if err := json.NewDecoder(r.Body).Decode(&rates); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		for i := range rates {
+			if err := rates[i].Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			for _, v := range rates {
+				if err := tx.ExchangeRate().Set(&store.ExchangeRateModel{
+					Currency:      v.Currency,
+					EffectiveDate: v.EffectiveDate,
+					Rate:          v.Rate,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, rates)
+	}
+}
+
+// handleExchangeRateDelete deletes the rate of the currency set for the date
+func (s *server) handleExchangeRateDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		date, err := time.Parse(exchange.DateLayout, vars["date"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errBadDate)
+			return
+		}
+		if err := s.store.ExchangeRate().Delete(strings.ToUpper(vars["currency"]), date); err != nil {
+			if err == mysqlstore.ErrDeletedItemDoesNotExist {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
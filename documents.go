@@ -0,0 +1,37 @@
+// Файл documents.go содержит проверки документов пассажиров при продаже и их маскирование в отчетах
+package main
+
+import (
+	"time"
+
+	"github.com/akionka/aviasales/internal/store"
+)
+
+var (
+	errDocumentExpired      = segmentRuleError("срок действия документа пассажира истекает до вылета")
+	errDocumentHolderTooOld = segmentRuleError("пассажир на дату вылета слишком взрослый для указанного документа")
+)
+
+// maskDocumentNumber hides all but the last four characters of the document number, or all but a half of short numbers
+func maskDocumentNumber(number string) string {
+	runes := []rune(number)
+	visible := 4
+	if len(runes) < 2*visible {
+		visible = len(runes) / 2
+	}
+	for i := 0; i < len(runes)-visible; i++ {
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+// checkTravelDocument checks that the passenger can use the document of the ticket for the flight departing on the date
+func checkTravelDocument(t *store.TicketModel, depDate time.Time) error {
+	if t.PassengerDocumentExpiry != nil && t.PassengerDocumentExpiry.Before(depDate) {
+		return errDocumentExpired
+	}
+	if rule := documentRules[t.PassengerDocumentType]; rule.maxAge > 0 && ageAt(t.PassengerBirthDate, depDate) >= rule.maxAge {
+		return errDocumentHolderTooOld
+	}
+	return nil
+}
@@ -0,0 +1,591 @@
+// Файл farerules.go содержит условия тарифов классов бронирования, возврат и обмен сегментов по этим условиям
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/farerules"
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/pricing"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+const (
+	operationRefund   = "refund"
+	operationExchange = "exchange"
+)
+
+var errEscortHasInfant = segmentRuleError("с пассажиром летит младенец без места, сначала оформите возврат или обмен младенца")
+
+// fareRuleErrors are the messages shown for the errors of the fare conditions
+var fareRuleErrors = map[error]error{
+	farerules.ErrNonRefundable:   errors.New("тариф невозвратный"),
+	farerules.ErrFlown:           errors.New("сегмент уже использован"),
+	farerules.ErrAdvancePurchase: errors.New("по условиям тарифа его нужно приобрести раньше"),
+	farerules.ErrMinStay:         errors.New("срок пребывания меньше минимального по условиям тарифа"),
+	farerules.ErrMaxStay:         errors.New("срок пребывания больше максимального по условиям тарифа"),
+}
+
+// fareRuleErrorStatus returns the status code for an error of a refund or an exchange
+func fareRuleErrorStatus(err error) (int, error) {
+	if e, ok := fareRuleErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	return segmentErrorStatus(err)
+}
+
+func ruleFromModel(m *store.FareRuleModel) farerules.Rule {
+	if m == nil {
+		return farerules.Unrestricted
+	}
+	return farerules.Rule{
+		Refundable:          m.Refundable,
+		ChangeFee:           m.ChangeFee,
+		NoShowFee:           m.NoShowFee,
+		MinStayDays:         m.MinStayDays,
+		MaxStayDays:         m.MaxStayDays,
+		AdvancePurchaseDays: m.AdvancePurchaseDays,
+	}
+}
+
+// ruleIn returns the conditions with the fees, set in the default currency, converted to the currency of the fare
+func ruleIn(rule farerules.Rule, convert func(money.Money) (money.Money, error)) (farerules.Rule, error) {
+	for _, fee := range []*money.Money{&rule.ChangeFee, &rule.NoShowFee} {
+		v, err := convert(*fee)
+		if err != nil {
+			return rule, err
+		}
+		*fee = v
+	}
+	return rule, nil
+}
+
+// fareRule returns the conditions of the fare of the booking class, nil for segments sold without a booking class
+// and classes without conditions, which are not restricted
+func (s *server) fareRule(st store.Store, bookingClass *string) (*store.FareRuleModel, error) {
+	if bookingClass == nil {
+		return nil, nil
+	}
+	rule, err := st.BookingClass().FindRule(*bookingClass)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// segmentPrice returns the price of the segment for the passenger of the ticket at the current fare of its booking class
+func (s *server) segmentPrice(st store.Store, t *store.TicketModel, f *store.FlightInTicketModel, leg *store.FlightLegModel) (money.Money, error) {
+	passenger := pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, leg.DepDate))
+	if f.BookingClass == nil {
+		return pricing.Fare(leg.BasePrice, f.Class, leg.IsHot, passenger, f.SeatID != nil), nil
+	}
+	c, err := st.BookingClass().Find(*f.BookingClass)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return pricing.BucketFare(leg.BasePrice, c.FareMultiplier, leg.IsHot, passenger, f.SeatID != nil), nil
+}
+
+// segmentFare returns the fare the segment was sold at. Segments sold before the fares were recorded
+// are priced at the current fare
+func (s *server) segmentFare(st store.Store, t *store.TicketModel, f *store.FlightInTicketModel, leg *store.FlightLegModel) (money.Money, error) {
+	if f.Fare != nil {
+		return *f.Fare, nil
+	}
+	return s.segmentPrice(st, t, f, leg)
+}
+
+// sellSegment records on the segment the fare and the fuel surcharge it is sold at and returns the airport taxes
+// it is sold with. The class and the booking class of the segment must be set by checkSegment before
+func (s *server) sellSegment(st store.Store, f *store.FlightInTicketModel) ([]store.SegmentTaxModel, error) {
+	t, err := st.Ticket().Find(f.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	leg, err := st.Flight().FindLeg(f.FlightID)
+	if err != nil {
+		return nil, err
+	}
+	fare, err := s.segmentPrice(st, t, f, leg)
+	if err != nil {
+		return nil, err
+	}
+	if !paysTaxes(pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, leg.DepDate)), f.SeatID != nil) {
+		f.SetFare(fare, money.Money{Currency: fare.Currency})
+		return nil, nil
+	}
+	line, err := st.Line().Find(leg.LineCode)
+	if err != nil {
+		return nil, err
+	}
+	f.SetFare(fare, line.FuelSurcharge)
+	return segmentTaxes(st, leg.DepAirport, leg.ArrAirport)
+}
+
+// segmentUsage returns the usage of the segment and whether the flight is cancelled, so the refund or the exchange
+// is involuntary. A flight that left by the schedule counts as departed even if its status is not updated
+func segmentUsage(leg *store.FlightLegModel, f *store.FlightInTicketModel, now time.Time) (farerules.Usage, bool, error) {
+	status := flightstatus.Status(leg.Status)
+	if status == flightstatus.Cancelled {
+		return farerules.Unused, true, nil
+	}
+	departed := status == flightstatus.Departed || status == flightstatus.Arrived
+	if !departed {
+		dep, err := departure(leg)
+		if err != nil {
+			return "", false, err
+		}
+		departed = !now.Before(dep)
+	}
+	return farerules.UsageOf(departed, f.BoardedAt != nil), false, nil
+}
+
+// journey returns the dates of departure there and back of the ticket with the segment moved to the leg.
+// The journey has a way back if its last segment returns to the airport the first one departs from
+func (s *server) journey(ticketID, segmentID int, leg *store.FlightLegModel) (time.Time, *time.Time, error) {
+	segments, err := s.store.FlightInTicket().FindSegments(ticketID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	for i := range segments {
+		if segments[i].ID == segmentID {
+			segments[i].DepDate = leg.DepDate
+			segments[i].DepAirport = leg.DepAirport
+			segments[i].ArrAirport = leg.ArrAirport
+		}
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].DepDate.Before(segments[j].DepDate)
+	})
+	first, last := segments[0], segments[len(segments)-1]
+	if len(segments) > 1 && last.ArrAirport == first.DepAirport {
+		return first.DepDate, &last.DepDate, nil
+	}
+	return first.DepDate, nil, nil
+}
+
+// checkEscortLeaves checks that no infant without a seat is left on the flight without the passenger of the segment
+func (s *server) checkEscortLeaves(f *store.FlightInTicketModel) error {
+	infants, err := s.store.FlightInTicket().CountInfants(f.FlightID, f.TicketID, 0)
+	if err != nil {
+		return err
+	}
+	if infants > 0 {
+		return errEscortHasInfant
+	}
+	return nil
+}
+
+// segmentForOperation returns the segment with its ticket and flight
+func (s *server) segmentForOperation(r *http.Request) (*store.FlightInTicketModel, *store.TicketModel, *store.FlightLegModel, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	f, err := s.store.FlightInTicket().Find(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	t, err := s.store.Ticket().Find(f.TicketID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	leg, err := s.store.Flight().FindLeg(f.FlightID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return f, t, leg, nil
+}
+
+// handleSegmentRefund refunds the segment under the conditions of its fare and frees its seat.
+// A segment of a cancelled flight is refunded in full
+func (s *server) handleSegmentRefund() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &SegmentRefundRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		f, t, leg, err := s.segmentForOperation(r)
+		if err != nil {
+			code, err := fareRuleErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		now := time.Now()
+		fare, err := s.segmentFare(s.store, t, f, leg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		ruleModel, err := s.fareRule(s.store, f.BookingClass)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		usage, involuntary, err := segmentUsage(leg, f, now)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		rule := ruleFromModel(ruleModel)
+		if involuntary {
+			rule = farerules.Unrestricted
+		}
+		convert, err := s.converter(s.store, fare.Currency, now)
+		if err == nil {
+			rule, err = ruleIn(rule, convert)
+		}
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		charges, err := s.segmentCharges(s.store, t, f, leg, fare.Currency, convert)
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		amount, penalty, err := farerules.Refund(rule, fare, usage)
+		if err == nil {
+			err = s.checkEscortLeaves(f)
+		}
+		if err != nil {
+			code, err := fareRuleErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		refund := &SegmentRefund{
+			SegmentID:    f.ID,
+			TicketID:     f.TicketID,
+			FlightID:     f.FlightID,
+			BookingClass: bookingClassOf(f.BookingClass),
+			FareRules:    ruleModel,
+			Usage:        string(usage),
+			Involuntary:  involuntary,
+			Fare:         fare,
+			Penalty:      penalty,
+			Amount:       amount,
+			Currency:     string(fare.Currency),
+			Quote:        req.Quote,
+		}
+		if req.Quote {
+			s.respond(w, r, http.StatusOK, refund)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := tx.FlightInTicket().Delete(f.ID); err != nil {
+				return err
+			}
+			return tx.Ticket().AddOperation(&store.TicketOperationModel{
+				TicketID:     f.TicketID,
+				Operation:    operationRefund,
+				FlightID:     f.FlightID,
+				BookingClass: f.BookingClass,
+				Fare:         fare,
+				Taxes:        charges,
+				Fee:          penalty,
+				Amount:       amount,
+				Currency:     string(fare.Currency),
+				Involuntary:  involuntary,
+				CashierID:    c.ID,
+				CreatedAt:    now,
+			})
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.offerFreedSeats(f.FlightID)
+		s.respond(w, r, http.StatusOK, refund)
+	}
+}
+
+// handleSegmentExchange moves the segment to another flight, seat or booking class, charging the change fee
+// and the fare difference under the conditions of its fare. The new fare must allow the journey and be bought
+// early enough. A segment of a cancelled flight is exchanged free of charge
+func (s *server) handleSegmentExchange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &SegmentExchangeRequest{}
+		// An empty body is an empty request, as for a refund, and fails the validation for the flight
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		f, t, leg, err := s.segmentForOperation(r)
+		if err != nil {
+			code, err := fareRuleErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		now := time.Now()
+		usage, involuntary, err := segmentUsage(leg, f, now)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if usage == farerules.Flown {
+			code, err := fareRuleErrorStatus(farerules.ErrFlown)
+			s.error(w, r, code, err)
+			return
+		}
+		if req.FlightID != f.FlightID {
+			if err := s.checkEscortLeaves(f); err != nil {
+				code, err := fareRuleErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
+		}
+
+		segment := &store.FlightInTicketModel{
+			ID:           f.ID,
+			FlightID:     req.FlightID,
+			SeatID:       req.SeatID,
+			TicketID:     f.TicketID,
+			Class:        req.Class,
+			BookingClass: bookingClassPtr(req.BookingClass),
+		}
+		if err := s.checkSegment(s.store, segment); err != nil {
+			code, err := segmentErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		newLeg, err := s.store.Flight().FindLeg(req.FlightID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		fare, err := s.segmentFare(s.store, t, f, leg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		taxes, err := s.sellSegment(s.store, segment)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		newFare := *segment.Fare
+		ruleModel, err := s.fareRule(s.store, f.BookingClass)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		newRuleModel, err := s.fareRule(s.store, segment.BookingClass)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		dep, err := departure(newLeg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		outbound, inbound, err := s.journey(f.TicketID, f.ID, newLeg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		rule := ruleFromModel(ruleModel)
+		if involuntary {
+			rule = farerules.Unrestricted
+		}
+		// The new fare may be sold in another currency, the difference is charged in the currency of the old one
+		convert, err := s.converter(s.store, fare.Currency, now)
+		if err == nil {
+			rule, err = ruleIn(rule, convert)
+		}
+		if err == nil {
+			newFare, err = convert(newFare)
+		}
+		var charges, newCharges money.Money
+		if err == nil {
+			charges, err = s.segmentCharges(s.store, t, f, leg, fare.Currency, convert)
+		}
+		if err == nil {
+			newCharges, err = chargesTotal(*segment.FuelSurcharge, taxes, convert)
+		}
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		err = farerules.Check(ruleFromModel(newRuleModel), now, dep, outbound, inbound)
+		var amount, fee money.Money
+		if err == nil {
+			amount, fee, err = farerules.Exchange(rule, fare, newFare, usage)
+		}
+		if err != nil {
+			code, err := fareRuleErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		exchange := &SegmentExchange{
+			SegmentID:       f.ID,
+			TicketID:        f.TicketID,
+			FlightID:        f.FlightID,
+			BookingClass:    bookingClassOf(f.BookingClass),
+			FareRules:       ruleModel,
+			Fare:            fare,
+			NewFlightID:     segment.FlightID,
+			NewSeatID:       segment.SeatID,
+			NewClass:        segment.Class,
+			NewBookingClass: bookingClassOf(segment.BookingClass),
+			NewFareRules:    newRuleModel,
+			NewFare:         newFare,
+			Usage:           string(usage),
+			Involuntary:     involuntary,
+			Fee:             fee,
+			Amount:          amount,
+			Currency:        string(fare.Currency),
+			Quote:           req.Quote,
+		}
+		if req.Quote {
+			s.respond(w, r, http.StatusOK, exchange)
+			return
+		}
+
+		// The segment is sold anew with the fare, the fuel surcharge and the taxes of the new flight
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		if err := s.store.Transaction(func(tx store.Store) error {
+			// The flight may have been sold out since the quote above, the class priced must still be open
+			if err := s.checkSegment(tx, segment); err != nil {
+				return err
+			}
+			if err := tx.FlightInTicket().Update(f.ID, segment); err != nil {
+				return err
+			}
+			if err := tx.FlightInTicket().SetTaxes(f.ID, taxes); err != nil {
+				return err
+			}
+			return tx.Ticket().AddOperation(&store.TicketOperationModel{
+				TicketID:        f.TicketID,
+				Operation:       operationExchange,
+				FlightID:        f.FlightID,
+				BookingClass:    f.BookingClass,
+				Fare:            fare,
+				NewFlightID:     &segment.FlightID,
+				NewBookingClass: segment.BookingClass,
+				NewFare:         &newFare,
+				Taxes:           charges,
+				NewTaxes:        &newCharges,
+				Fee:             fee,
+				Amount:          amount,
+				Currency:        string(fare.Currency),
+				Involuntary:     involuntary,
+				CashierID:       c.ID,
+				CreatedAt:       now,
+			})
+		}); err != nil {
+			if err == mysqlstore.ErrNoChanges {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			code, err := segmentErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.offerFreedSeats(f.FlightID)
+		s.respond(w, r, http.StatusOK, exchange)
+	}
+}
+
+// handleFareRuleGetUpdate returns or sets the conditions of the fare of the booking class.
+// A class without conditions is not restricted
+func (s *server) handleFareRuleGetUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+		if _, err := s.store.BookingClass().Find(code); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			f := &FareRule{}
+			if err := json.NewDecoder(r.Body).Decode(f); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := f.Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := s.store.BookingClass().SetRule(&store.FareRuleModel{
+				BookingClass:        code,
+				Refundable:          f.Refundable,
+				ChangeFee:           f.ChangeFee,
+				NoShowFee:           f.NoShowFee,
+				MinStayDays:         f.MinStayDays,
+				MaxStayDays:         f.MaxStayDays,
+				AdvancePurchaseDays: f.AdvancePurchaseDays,
+			}); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, f)
+			return
+		}
+
+		m, err := s.fareRule(s.store, &code)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		rule := ruleFromModel(m)
+		s.respond(w, r, http.StatusOK, &FareRule{
+			Refundable:          rule.Refundable,
+			ChangeFee:           rule.ChangeFee,
+			NoShowFee:           rule.NoShowFee,
+			MinStayDays:         rule.MinStayDays,
+			MaxStayDays:         rule.MaxStayDays,
+			AdvancePurchaseDays: rule.AdvancePurchaseDays,
+		})
+	}
+}
+
+func ticketOperationFromModel(o *store.TicketOperationModel) TicketOperation {
+	return TicketOperation{
+		ID:              o.ID,
+		Operation:       o.Operation,
+		FlightID:        o.FlightID,
+		BookingClass:    bookingClassOf(o.BookingClass),
+		Fare:            o.Fare,
+		NewFlightID:     o.NewFlightID,
+		NewBookingClass: bookingClassOf(o.NewBookingClass),
+		NewFare:         o.NewFare,
+		Taxes:           o.Taxes,
+		NewTaxes:        o.NewTaxes,
+		Fee:             o.Fee,
+		Amount:          o.Amount,
+		Currency:        o.Currency,
+		Involuntary:     o.Involuntary,
+		CashierID:       o.CashierID,
+		CreatedAt:       o.CreatedAt,
+	}
+}
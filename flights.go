@@ -0,0 +1,172 @@
+// Файл flights.go содержит расчет времени вылета и прилёта рейсов, обработчики поиска рейсов и списка часовых поясов
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+)
+
+// Longest flights arrive three local days after the departure date
+const maxArrDayOffset = 3
+
+var (
+	errBadDate            = errors.New("некорректная дата, ожидается ГГГГ-ММ-ДД")
+	errArrivalBeforeDep   = errors.New("прилёт по расписанию раньше вылета, проверьте время и смещение дня прилёта")
+	errAirportNoTimezone  = errors.New("у аэропорта указан неизвестный часовой пояс")
+	errUnknownLineAirport = errors.New("аэропорт линии не существует")
+)
+
+// checkLineSchedule checks that the line's times can be resolved in the timezones of its airports
+// and the arrival follows the departure
+func (s *server) checkLineSchedule(st store.Store, l *Line) error {
+	dep, err := st.Airport().Find(l.DepAirport)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errUnknownLineAirport
+		}
+		return err
+	}
+	arr, err := st.Airport().Find(l.ArrAirport)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errUnknownLineAirport
+		}
+		return err
+	}
+	if _, err := schedule.Location(dep.Timezone); err != nil {
+		return errAirportNoTimezone
+	}
+	if _, err := schedule.Location(arr.Timezone); err != nil {
+		return errAirportNoTimezone
+	}
+	if _, _, err := schedule.Times(time.Now(), l.DepTime, l.ArrTime, l.ArrDayOffset, dep.Timezone, arr.Timezone); err != nil {
+		if err == schedule.ErrArrivalBeforeDeparture {
+			return errArrivalBeforeDep
+		}
+		return err
+	}
+	return nil
+}
+
+func flightFromModel(f *store.FlightModel) Flight {
+	return Flight{
+		ID:                 f.ID,
+		DepDate:            f.DepDate,
+		LineCode:           f.LineCode,
+		IsHot:              f.IsHot,
+		LinerCode:          f.LinerCode,
+		Status:             f.Status,
+		EstimatedDeparture: f.EstDepTime,
+		EstimatedArrival:   f.EstArrTime,
+		ActualDeparture:    f.ActualDepTime,
+		ActualArrival:      f.ActualArrTime,
+	}
+}
+
+func flightLegFromModel(f *store.FlightLegModel) (*FlightLeg, error) {
+	dep, arr, err := schedule.Times(f.DepDate, f.DepTime, f.ArrTime, f.ArrDayOffset, f.DepTimezone, f.ArrTimezone)
+	if err != nil {
+		return nil, err
+	}
+	return &FlightLeg{
+		Flight:     flightFromModel(&f.FlightModel),
+		DepAirport: f.DepAirport,
+		ArrAirport: f.ArrAirport,
+		Departure:  dep,
+		Arrival:    arr,
+		BasePrice:  f.BasePrice,
+		Currency:   f.Currency,
+	}, nil
+}
+
+// handleFlightsSearch finds flights by the departure and arrival airports and the local date of departure
+// with the lowest open booking class and fare of every cabin
+func (s *server) handleFlightsSearch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := &store.FlightQuery{
+			DepAirport: strings.ToUpper(r.URL.Query().Get("from")),
+			ArrAirport: strings.ToUpper(r.URL.Query().Get("to")),
+		}
+		if date := r.URL.Query().Get("date"); date != "" {
+			d, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, errBadDate)
+				return
+			}
+			query.FromDate, query.ToDate = d, d
+		}
+
+		legs, err := s.store.Flight().FindLegs(query)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		findAirport := airportFinder(s.store)
+		response := make([]FlightLeg, len(legs))
+		for i := range legs {
+			leg, err := flightLegFromModel(&legs[i])
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			leg.DistanceKm, err = distanceKm(findAirport, leg.DepAirport, leg.ArrAirport)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			leg.Fares, err = s.cabinFares(&legs[i])
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			response[i] = *leg
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleTimezonesGet lists the IANA timezones with their current offsets
+func (s *server) handleTimezonesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		names := schedule.Zones()
+		timezones := make([]Timezone, 0, len(names))
+		for _, name := range names {
+			z, err := schedule.Zone(name, now)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			tz := Timezone{
+				Name:         z.Name,
+				Abbreviation: z.Abbreviation,
+				UTCOffset:    formatUTCOffset(z.Offset),
+				IsDST:        z.IsDST,
+				ObservesDST:  z.ObservesDST,
+			}
+			if !z.NextTransition.IsZero() {
+				tz.NextTransition = &z.NextTransition
+			}
+			timezones = append(timezones, tz)
+		}
+		s.respond(w, r, http.StatusOK, timezones)
+	}
+}
+
+// formatUTCOffset formats the offset in seconds east of UTC as "+03:00"
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
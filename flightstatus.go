@@ -0,0 +1,257 @@
+// Файл flightstatus.go содержит обработчики оперативного статуса рейса: смену статуса, историю изменений и отмену рейса
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+var errCancellationReasonRequired = errors.New("укажите причину отмены рейса")
+
+// flightStatusErrors are the messages shown for the errors of the status changes
+var flightStatusErrors = map[error]error{
+	flightstatus.ErrUnknownStatus:         errors.New("неизвестный статус рейса"),
+	flightstatus.ErrBadTransition:         errors.New("рейс не может перейти в этот статус из текущего"),
+	flightstatus.ErrEstimateRequired:      errors.New("для задержки укажите расчётное время вылета"),
+	flightstatus.ErrEstimateNotLater:      errors.New("расчётное время вылета должно быть позже времени по расписанию"),
+	flightstatus.ErrArrivalBeforeDepart:   errors.New("время прилёта должно быть позже времени вылета"),
+	flightstatus.ErrActualTimeInTheFuture: errors.New("фактическое время не может быть в будущем"),
+}
+
+// flightStatusErrorStatus returns the status code for an error of changeFlightStatus
+func flightStatusErrorStatus(err error) (int, error) {
+	if e, ok := flightStatusErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, errRequestedItemDoesNotExist
+	}
+	if err == mysqlstore.ErrNoChanges {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+func flightStatusChangeFromModel(c *store.FlightStatusChangeModel) FlightStatusChange {
+	return FlightStatusChange{
+		ID:                 c.ID,
+		FlightID:           c.FlightID,
+		PrevStatus:         c.PrevStatus,
+		Status:             c.Status,
+		EstimatedDeparture: c.EstDepTime,
+		EstimatedArrival:   c.EstArrTime,
+		ActualDeparture:    c.ActualDepTime,
+		ActualArrival:      c.ActualArrTime,
+		Reason:             c.Reason,
+		CashierID:          c.CashierID,
+		ChangedAt:          c.ChangedAt,
+	}
+}
+
+// changeFlightStatus moves the flight to the status of the change and records the change in the history
+func (s *server) changeFlightStatus(tx store.Store, id, cashierID int, change *FlightStatusChange) (*store.FlightModel, error) {
+	leg, err := tx.Flight().FindLeg(id)
+	if err != nil {
+		return nil, err
+	}
+	scheduledDep, _, err := schedule.Times(leg.DepDate, leg.DepTime, leg.ArrTime, leg.ArrDayOffset, leg.DepTimezone, leg.ArrTimezone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	times, err := flightstatus.Change(
+		flightstatus.Status(leg.Status),
+		flightstatus.Status(change.Status),
+		scheduledDep,
+		flightstatus.Times{
+			EstimatedDeparture: leg.EstDepTime,
+			EstimatedArrival:   leg.EstArrTime,
+			ActualDeparture:    leg.ActualDepTime,
+			ActualArrival:      leg.ActualArrTime,
+		},
+		flightstatus.Times{
+			EstimatedDeparture: change.EstimatedDeparture,
+			EstimatedArrival:   change.EstimatedArrival,
+			ActualDeparture:    change.ActualDeparture,
+			ActualArrival:      change.ActualArrival,
+		},
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	flight := leg.FlightModel
+	flight.Status = change.Status
+	flight.EstDepTime = times.EstimatedDeparture
+	flight.EstArrTime = times.EstimatedArrival
+	flight.ActualDepTime = times.ActualDeparture
+	flight.ActualArrTime = times.ActualArrival
+	if err := tx.Flight().UpdateStatus(id, &flight); err != nil {
+		return nil, err
+	}
+	if err := tx.Flight().AddStatusChange(&store.FlightStatusChangeModel{
+		FlightID:      id,
+		PrevStatus:    leg.Status,
+		Status:        change.Status,
+		EstDepTime:    times.EstimatedDeparture,
+		EstArrTime:    times.EstimatedArrival,
+		ActualDepTime: times.ActualDeparture,
+		ActualArrTime: times.ActualArrival,
+		Reason:        change.Reason,
+		CashierID:     cashierID,
+		ChangedAt:     now,
+	}); err != nil {
+		return nil, err
+	}
+	return &flight, nil
+}
+
+// affectedTickets lists every ticket that has the flight
+func (s *server) affectedTickets(tx store.Store, flightID int) ([]AffectedTicket, error) {
+	segments, err := tx.FlightInTicket().FindFlightSegments(flightID)
+	if err != nil {
+		return nil, err
+	}
+	affected := make([]AffectedTicket, len(segments))
+	for i, v := range segments {
+		t, err := tx.Ticket().Find(v.TicketID)
+		if err != nil {
+			return nil, err
+		}
+		p, err := tx.Purchase().Find(t.PurchaseID)
+		if err != nil {
+			return nil, err
+		}
+		affected[i] = AffectedTicket{
+			SegmentID:          v.ID,
+			TicketID:           t.ID,
+			PurchaseID:         p.ID,
+			PassengerLastName:  t.PassengerLastName,
+			PassengerGivenName: t.PassengerGivenName,
+			SeatNumber:         v.SeatNumber,
+			Class:              v.SeatClass,
+			ContactPhone:       p.ContactPhone,
+			ContactEmail:       p.ContactEmail,
+		}
+	}
+	return affected, nil
+}
+
+func (s *server) handleFlightStatusUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		change := &FlightStatusChange{}
+		if err := json.NewDecoder(r.Body).Decode(change); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := change.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if change.Status == string(flightstatus.Cancelled) {
+			// Cancellation goes through its own endpoint that lists the tickets to reprotect
+			s.error(w, r, http.StatusBadRequest, flightStatusErrors[flightstatus.ErrBadTransition])
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		var flight *store.FlightModel
+		if err := s.store.Transaction(func(tx store.Store) error {
+			flight, err = s.changeFlightStatus(tx, id, c.ID, change)
+			return err
+		}); err != nil {
+			code, err := flightStatusErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, flightFromModel(flight))
+	}
+}
+
+func (s *server) handleFlightStatusChangesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := s.store.Flight().Find(id); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		changes, err := s.store.Flight().FindStatusChanges(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]FlightStatusChange, len(changes))
+		for i := range changes {
+			response[i] = flightStatusChangeFromModel(&changes[i])
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleFlightCancel cancels the flight and returns the tickets that have to be reprotected
+func (s *server) handleFlightCancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		change := &FlightStatusChange{}
+		if err := json.NewDecoder(r.Body).Decode(change); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if change.Reason == "" {
+			s.error(w, r, http.StatusBadRequest, errCancellationReasonRequired)
+			return
+		}
+		change.Status = string(flightstatus.Cancelled)
+		if err := change.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		response := &FlightCancellation{}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			flight, err := s.changeFlightStatus(tx, id, c.ID, change)
+			if err != nil {
+				return err
+			}
+			response.Flight = flightFromModel(flight)
+			response.AffectedTickets, err = s.affectedTickets(tx, id)
+			return err
+		}); err != nil {
+			code, err := flightStatusErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,391 @@
+// Файл import.go содержит массовый импорт аэропортов, моделей лайнеров, лайнеров, мест и линий из файлов CSV и JSON
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/akionka/aviasales/internal/importer"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// importInsert creates the rows and fails the ones that exist
+	importInsert = "insert"
+	// importUpsert creates the rows and updates the ones that exist
+	importUpsert = "upsert"
+
+	maxImportFileSize = 32 << 20
+)
+
+var (
+	errImportEntity            = errors.New("импорт этих данных не поддерживается")
+	errImportMode              = errors.New("режим импорта должен быть insert или upsert")
+	errImportFile              = errors.New("загрузите файл в поле file")
+	errImportFormat            = errors.New("формат файла должен быть csv или json")
+	errImportExists            = errors.New("запись уже существует")
+	errImportLinerModelUnknown = errors.New("модель лайнера не существует")
+	// errImportRollback rolls back the transaction of a dry run or of a file with errors
+	errImportRollback = errors.New("import rolled back")
+)
+
+// importRowError is an error of a row of the file. The row is not saved and the import goes on
+// to report the errors of the other rows, other errors stop it
+type importRowError struct {
+	err error
+}
+
+func (e importRowError) Error() string {
+	return e.err.Error()
+}
+
+// importFileError is an error of reading the file, nothing is imported from it
+type importFileError struct {
+	err error
+}
+
+func (e importFileError) Error() string {
+	return e.err.Error()
+}
+
+// importEntity is a kind of data that can be imported: the API type of its rows and saving a row. save
+// returns whether the row existed and was updated
+type importEntity struct {
+	newItem func() interface{}
+	save    func(s *server, st store.Store, item interface{}, upsert bool) (bool, error)
+}
+
+// importEntities are the entities by their names in the API. An import of a file has rows of one entity,
+// the rows are saved in the order of the file, so a row can refer to the rows above it
+var importEntities = map[string]importEntity{
+	"airports":     {newItem: func() interface{} { return &Airport{} }, save: importAirport},
+	"liner_models": {newItem: func() interface{} { return &LinerModel{} }, save: importLinerModel},
+	"liners":       {newItem: func() interface{} { return &Liner{} }, save: importLiner},
+	"seats":        {newItem: func() interface{} { return &Seat{} }, save: importSeat},
+	"lines":        {newItem: func() interface{} { return &Line{} }, save: importLine},
+}
+
+// importErrorStatus returns the status code for an error of an import
+func importErrorStatus(err error) (int, error) {
+	if e, ok := err.(importFileError); ok {
+		err = e.err
+		if err != importer.ErrFormat {
+			return http.StatusBadRequest, err
+		}
+	}
+	if err == importer.ErrFormat {
+		return http.StatusBadRequest, errImportFormat
+	}
+	if err == errImportEntity || err == errImportMode || err == errImportFile {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+// importFile imports the rows of the file in one transaction. Every row is validated and saved, the errors
+// of the rows are in the result. The transaction is committed only if no row has an error and it is not a dry run
+func (s *server) importFile(entity string, format importer.Format, r io.Reader, mode string, dryRun bool) (*ImportResult, error) {
+	e, ok := importEntities[entity]
+	if !ok {
+		return nil, errImportEntity
+	}
+	if mode != importInsert && mode != importUpsert {
+		return nil, errImportMode
+	}
+	rows, err := importer.Decode(r, format, e.newItem)
+	if err != nil {
+		return nil, importFileError{err}
+	}
+
+	result := &ImportResult{Entity: entity, Mode: mode, DryRun: dryRun, Rows: len(rows)}
+	err = s.store.Transaction(func(tx store.Store) error {
+		for _, row := range rows {
+			if err := importRow(s, tx, e, row, mode == importUpsert, result); err != nil {
+				if rowErr, ok := err.(importRowError); ok {
+					result.Errors = append(result.Errors, ImportRowError{Row: row.Number, Error: rowErr.Error()})
+					continue
+				}
+				return err
+			}
+		}
+		if dryRun || len(result.Errors) > 0 {
+			return errImportRollback
+		}
+		return nil
+	})
+	if err != nil && err != errImportRollback {
+		return nil, err
+	}
+	return result, nil
+}
+
+func importRow(s *server, st store.Store, e importEntity, row importer.Row, upsert bool, result *ImportResult) error {
+	if row.Err != nil {
+		return importRowError{row.Err}
+	}
+	if err := row.Item.(validation.Validatable).Validate(); err != nil {
+		return importRowError{err}
+	}
+	updated, err := e.save(s, st, row.Item, upsert)
+	if err != nil {
+		return err
+	}
+	if updated {
+		result.Updated++
+	} else {
+		result.Created++
+	}
+	return nil
+}
+
+// importExisting returns whether the row exists from the error of finding it. An existing row
+// is an error of the row unless it is an upsert
+func importExisting(err error, upsert bool) (bool, error) {
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !upsert {
+		return true, importRowError{errImportExists}
+	}
+	return true, nil
+}
+
+// importUpdated ignores the rows updated with the data they have
+func importUpdated(err error) error {
+	if err == mysqlstore.ErrNoChanges {
+		return nil
+	}
+	return err
+}
+
+func importAirport(s *server, st store.Store, item interface{}, upsert bool) (bool, error) {
+	a := item.(*Airport)
+	_, err := st.Airport().Find(a.IATACode)
+	exists, err := importExisting(err, upsert)
+	if err != nil {
+		return false, err
+	}
+	m := &store.AirportModel{
+		IATACode:  a.IATACode,
+		City:      a.City,
+		Timezone:  a.Timezone,
+		Latitude:  a.Latitude,
+		Longitude: a.Longitude,
+		ICAOCode:  a.ICAOCode,
+		Name:      a.Name,
+		Country:   a.Country,
+	}
+	if exists {
+		return true, importUpdated(st.Airport().Update(a.IATACode, m))
+	}
+	return false, st.Airport().Create(m)
+}
+
+func importLinerModel(s *server, st store.Store, item interface{}, upsert bool) (bool, error) {
+	lm := item.(*LinerModel)
+	_, err := st.LinerModel().Find(lm.IATATypeCode)
+	exists, err := importExisting(err, upsert)
+	if err != nil {
+		return false, err
+	}
+	m := &store.LinerModelModel{
+		IATATypeCode: lm.IATATypeCode,
+		Name:         lm.Name,
+	}
+	if exists {
+		return true, importUpdated(st.LinerModel().Update(lm.IATATypeCode, m))
+	}
+	return false, st.LinerModel().Create(m)
+}
+
+// importLinerModelExists checks that the liner model the row refers to exists
+func importLinerModelExists(st store.Store, code string) error {
+	if _, err := st.LinerModel().Find(code); err != nil {
+		if err == sql.ErrNoRows {
+			return importRowError{errImportLinerModelUnknown}
+		}
+		return err
+	}
+	return nil
+}
+
+func importLiner(s *server, st store.Store, item interface{}, upsert bool) (bool, error) {
+	l := item.(*Liner)
+	if err := importLinerModelExists(st, l.ModelCode); err != nil {
+		return false, err
+	}
+	_, err := st.Liner().Find(l.IATACode)
+	exists, err := importExisting(err, upsert)
+	if err != nil {
+		return false, err
+	}
+	m := &store.LinerModel{
+		IATACode:  l.IATACode,
+		ModelCode: l.ModelCode,
+	}
+	if exists {
+		return true, importUpdated(st.Liner().Update(l.IATACode, m))
+	}
+	return false, st.Liner().Create(m)
+}
+
+// importSeat saves a seat of a liner model. A seat is identified by its number in the model,
+// the IDs of the file are only used for new seats
+func importSeat(s *server, st store.Store, item interface{}, upsert bool) (bool, error) {
+	seat := item.(*Seat)
+	if err := importLinerModelExists(st, seat.LinerModelCode); err != nil {
+		return false, err
+	}
+	seats, err := st.Seat().FindByModel(seat.LinerModelCode)
+	if err != nil {
+		return false, err
+	}
+	m := &store.SeatModel{
+		ID:             seat.ID,
+		Number:         seat.Number,
+		Class:          seat.Class,
+		LinerModelCode: seat.LinerModelCode,
+	}
+	for _, v := range seats {
+		if v.Number != seat.Number {
+			continue
+		}
+		if !upsert {
+			return true, importRowError{errImportExists}
+		}
+		m.ID = v.ID
+		return true, importUpdated(st.Seat().Update(v.ID, m))
+	}
+	return false, st.Seat().Create(m)
+}
+
+func importLine(s *server, st store.Store, item interface{}, upsert bool) (bool, error) {
+	l := item.(*Line)
+	if err := s.checkLineSchedule(st, l); err != nil {
+		return false, importRowError{err}
+	}
+	l.Currency = string(currencyOf(l.Currency))
+	if err := inCurrency(currencyOf(l.Currency), &l.BasePrice, &l.FuelSurcharge); err != nil {
+		code, err := currencyErrorStatus(err)
+		if code == http.StatusBadRequest {
+			return false, importRowError{err}
+		}
+		return false, err
+	}
+	_, err := st.Line().Find(l.LineCode)
+	exists, err := importExisting(err, upsert)
+	if err != nil {
+		return false, err
+	}
+	m := &store.LineModel{
+		LineCode:      l.LineCode,
+		DepTime:       l.DepTime,
+		ArrTime:       l.ArrTime,
+		ArrDayOffset:  l.ArrDayOffset,
+		BasePrice:     l.BasePrice,
+		FuelSurcharge: l.FuelSurcharge,
+		Currency:      l.Currency,
+		DepAirport:    l.DepAirport,
+		ArrAirport:    l.ArrAirport,
+	}
+	if exists {
+		return true, importUpdated(st.Line().Update(l.LineCode, m))
+	}
+	return false, st.Line().Create(m)
+}
+
+// handleImport imports the file uploaded in the file field of a multipart form. The format is the format
+// query parameter or the extension of the file, mode is insert by default and dry_run checks the file
+// without saving it. A file with errors in rows is rejected with the errors
+func (s *server) handleImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errImportFile)
+			return
+		}
+		defer file.Close()
+
+		query := r.URL.Query()
+		var format importer.Format
+		if v := query.Get("format"); v != "" {
+			format, err = importer.ParseFormat(v)
+		} else {
+			format, err = importer.FormatOf(header.Filename)
+		}
+		if err != nil {
+			code, err := importErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		mode := query.Get("mode")
+		if mode == "" {
+			mode = importInsert
+		}
+		dryRun := false
+		if v := query.Get("dry_run"); v != "" {
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		result, err := s.importFile(mux.Vars(r)["entity"], format, file, mode, dryRun)
+		if err != nil {
+			code, err := importErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		if len(result.Errors) > 0 {
+			s.respond(w, r, http.StatusBadRequest, result)
+			return
+		}
+		s.respond(w, r, http.StatusOK, result)
+	}
+}
+
+// runImport imports the file from the command line and logs the result and the errors of the rows
+func runImport(s *server, entity, path, mode string, dryRun bool) error {
+	format, err := importer.FormatOf(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := s.importFile(entity, format, f, mode, dryRun)
+	if err != nil {
+		return err
+	}
+	for _, e := range result.Errors {
+		log.Printf("Row %d: %s", e.Row, e.Error)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d of %d rows have errors, nothing is imported", len(result.Errors), result.Rows)
+	}
+	if dryRun {
+		log.Printf("Dry run of %s: %d rows to create, %d to update", entity, result.Created, result.Updated)
+		return nil
+	}
+	log.Printf("Imported %s: %d rows created, %d updated", entity, result.Created, result.Updated)
+	return nil
+}
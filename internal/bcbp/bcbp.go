@@ -0,0 +1,162 @@
+// Файл internal\bcbp\bcbp.go содержит кодирование и разбор штрихкода посадочного талона в формате IATA BCBP (Resolution 792)
+package bcbp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Length is the length of the mandatory items of a single leg pass without conditional items
+const Length = 60
+
+var (
+	ErrFormat      = errors.New("barcode is not an IATA BCBP boarding pass")
+	ErrMultipleLeg = errors.New("only single leg boarding passes are supported")
+)
+
+// Pass is a single leg boarding pass. Name and the codes must be upper case latin.
+// An infant without a seat has an empty Seat
+type Pass struct {
+	LastName  string
+	GivenName string
+	PNR       string
+	From      string
+	To        string
+	Carrier   string
+	FlightNo  string
+	Date      time.Time
+	DayOfYear int
+	Class     string
+	Seat      string
+	Sequence  int
+	ETicket   bool
+	CheckedIn bool
+}
+
+// field pads or truncates s to the width
+func field(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+// seatField formats the seat number as three digits of the row and the letter, "INF" for infants without a seat
+func seatField(seat string) string {
+	if seat == "" {
+		return "INF "
+	}
+	i := strings.IndexFunc(seat, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return field(seat, 4)
+	}
+	row, _ := strconv.Atoi(seat[:i])
+	return field(fmt.Sprintf("%03d%s", row, seat[i:]), 4)
+}
+
+// flightField formats the flight number as four digits with an optional suffix
+func flightField(number string) string {
+	i := strings.IndexFunc(number, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(number)
+	}
+	n, _ := strconv.Atoi(number[:i])
+	return field(fmt.Sprintf("%04d%s", n, number[i:]), 5)
+}
+
+// IsFlight reports whether the pass is for the flight of the carrier with the number. The numbers are compared
+// by value, the pass does not keep their leading zeros
+func (p *Pass) IsFlight(carrier, flightNo string) bool {
+	return p.Carrier == carrier && flightField(p.FlightNo) == flightField(flightNo)
+}
+
+// Encode returns the barcode data of the pass, to be printed as PDF417 or QR code
+func Encode(p *Pass) string {
+	name := p.LastName
+	if p.GivenName != "" {
+		name += "/" + p.GivenName
+	}
+	eticket := " "
+	if p.ETicket {
+		eticket = "E"
+	}
+	status := "0"
+	if p.CheckedIn {
+		status = "1"
+	}
+
+	var b strings.Builder
+	b.WriteString("M1")
+	b.WriteString(field(name, 20))
+	b.WriteString(eticket)
+	b.WriteString(field(p.PNR, 7))
+	b.WriteString(field(p.From, 3))
+	b.WriteString(field(p.To, 3))
+	b.WriteString(field(p.Carrier, 3))
+	b.WriteString(flightField(p.FlightNo))
+	b.WriteString(fmt.Sprintf("%03d", p.Date.YearDay()))
+	b.WriteString(field(p.Class, 1))
+	b.WriteString(seatField(p.Seat))
+	b.WriteString(field(fmt.Sprintf("%04d", p.Sequence), 5))
+	b.WriteString(status)
+	b.WriteString("00")
+	return b.String()
+}
+
+// Parse reads the mandatory items of a single leg pass. The date of the flight is only known
+// as the day of the year, Date is left zero and DayOfYear is set
+func Parse(data string) (*Pass, error) {
+	if len(data) < Length || data[0] != 'M' {
+		return nil, ErrFormat
+	}
+	if data[1] != '1' {
+		return nil, ErrMultipleLeg
+	}
+	trim := func(from, to int) string {
+		return strings.TrimSpace(data[from:to])
+	}
+
+	p := &Pass{
+		PNR:       trim(23, 30),
+		From:      trim(30, 33),
+		To:        trim(33, 36),
+		Carrier:   trim(36, 39),
+		Class:     trim(47, 48),
+		ETicket:   data[22] == 'E',
+		CheckedIn: data[57] == '1',
+	}
+	name := trim(2, 22)
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		p.LastName, p.GivenName = name[:i], name[i+1:]
+	} else {
+		p.LastName = name
+	}
+
+	flight := trim(39, 44)
+	n, err := strconv.Atoi(strings.TrimRight(flight, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	if err != nil {
+		return nil, ErrFormat
+	}
+	p.FlightNo = strconv.Itoa(n) + strings.TrimLeft(flight, "0123456789")
+
+	if p.DayOfYear, err = strconv.Atoi(data[44:47]); err != nil || p.DayOfYear < 1 || p.DayOfYear > 366 {
+		return nil, ErrFormat
+	}
+
+	seat := trim(48, 52)
+	if seat != "INF" {
+		row := strings.TrimLeft(seat, "0")
+		if row == "" {
+			return nil, ErrFormat
+		}
+		p.Seat = row
+	}
+
+	if p.Sequence, err = strconv.Atoi(trim(52, 57)); err != nil {
+		return nil, ErrFormat
+	}
+	return p, nil
+}
@@ -0,0 +1,89 @@
+package bcbp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	p := &Pass{
+		LastName:  "SHCHUKIN",
+		GivenName: "IURII",
+		PNR:       "00012A",
+		From:      "SVO",
+		To:        "AER",
+		Carrier:   "SU",
+		FlightNo:  "123",
+		Date:      time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC),
+		Class:     "Y",
+		Seat:      "12A",
+		Sequence:  7,
+		ETicket:   true,
+		CheckedIn: true,
+	}
+	want := "M1SHCHUKIN/IURII      E00012A SVOAERSU 0123 034Y012A0007 100"
+	got := Encode(p)
+	if got != want {
+		t.Fatalf("Encode() = %q, want %q", got, want)
+	}
+	if len(got) != Length {
+		t.Errorf("len(Encode()) = %d, want %d", len(got), Length)
+	}
+
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.LastName != p.LastName || parsed.GivenName != p.GivenName || parsed.PNR != p.PNR ||
+		parsed.From != p.From || parsed.To != p.To || parsed.Carrier != p.Carrier || parsed.FlightNo != p.FlightNo ||
+		parsed.DayOfYear != 34 || parsed.Class != p.Class || parsed.Seat != p.Seat || parsed.Sequence != p.Sequence ||
+		!parsed.ETicket || !parsed.CheckedIn {
+		t.Errorf("Parse() = %+v, want %+v", parsed, p)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		seat    string
+		wantErr error
+	}{
+		{name: "infant", data: "M1IVANOVA/MARIA       E00012A SVOAERSU 0123 034YINF 0008 100", seat: ""},
+		{name: "short", data: "M1IVANOVA", wantErr: ErrFormat},
+		{name: "two legs", data: "M2IVANOVA/MARIA       E00012A SVOAERSU 0123 034Y012A0008 100", wantErr: ErrMultipleLeg},
+		{name: "bad date", data: "M1IVANOVA/MARIA       E00012A SVOAERSU 0123 400Y012A0008 100", wantErr: ErrFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.Seat != tt.seat {
+				t.Errorf("Parse() seat = %q, want %q", p.Seat, tt.seat)
+			}
+		})
+	}
+}
+
+func TestIsFlight(t *testing.T) {
+	p, err := Parse("M1IVANOVA/MARIA       E00012A SVOAERSU 0100 034Y012A0008 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		carrier, flightNo string
+		want              bool
+	}{
+		{carrier: "SU", flightNo: "100", want: true},
+		{carrier: "SU", flightNo: "0100", want: true},
+		{carrier: "SU", flightNo: "10", want: false},
+		{carrier: "S7", flightNo: "100", want: false},
+	}
+	for _, tt := range tests {
+		if got := p.IsFlight(tt.carrier, tt.flightNo); got != tt.want {
+			t.Errorf("IsFlight(%s, %s) = %v, want %v", tt.carrier, tt.flightNo, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Файл internal\checkin\checkin.go содержит окно регистрации на рейс и правила регистрации и посадки пассажира
+package checkin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+)
+
+const (
+	// Opens is how long before departure the check-in opens
+	Opens = 24 * time.Hour
+	// Closes is how long before departure the check-in closes
+	Closes = 40 * time.Minute
+)
+
+type State string
+
+const (
+	NotCheckedIn State = "not_checked_in"
+	CheckedIn    State = "checked_in"
+	Boarded      State = "boarded"
+)
+
+var (
+	ErrNotOpen         = errors.New("check-in is not open yet")
+	ErrClosed          = errors.New("check-in is closed")
+	ErrFlightNotFlying = errors.New("flight is cancelled or has departed")
+	ErrNotCheckedIn    = errors.New("passenger is not checked in")
+	ErrAlreadyBoarded  = errors.New("passenger has already boarded")
+	ErrBoardingNotOpen = errors.New("boarding has not started")
+)
+
+// StateOf returns the state of a segment from the times of the check-in and the boarding
+func StateOf(checkedInAt, boardedAt *time.Time) State {
+	switch {
+	case boardedAt != nil:
+		return Boarded
+	case checkedInAt != nil:
+		return CheckedIn
+	}
+	return NotCheckedIn
+}
+
+// Window returns when the check-in for a flight departing at dep opens and closes
+func Window(dep time.Time) (opens, closes time.Time) {
+	return dep.Add(-Opens), dep.Add(-Closes)
+}
+
+// CanCheckIn checks that a passenger may check in now for a flight in the status departing at dep.
+// A delayed flight is checked against its estimated departure
+func CanCheckIn(status flightstatus.Status, dep, now time.Time) error {
+	if status != flightstatus.Scheduled && status != flightstatus.Delayed && status != flightstatus.Boarding {
+		return ErrFlightNotFlying
+	}
+	opens, closes := Window(dep)
+	if now.Before(opens) {
+		return ErrNotOpen
+	}
+	if !now.Before(closes) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// CanBoard checks that a passenger in the state may board a flight in the status
+func CanBoard(status flightstatus.Status, state State) error {
+	if status != flightstatus.Boarding {
+		return ErrBoardingNotOpen
+	}
+	switch state {
+	case NotCheckedIn:
+		return ErrNotCheckedIn
+	case Boarded:
+		return ErrAlreadyBoarded
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package checkin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+)
+
+func TestCanCheckIn(t *testing.T) {
+	dep := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		status  flightstatus.Status
+		now     time.Time
+		wantErr error
+	}{
+		{name: "open", status: flightstatus.Scheduled, now: dep.Add(-3 * time.Hour)},
+		{name: "opens", status: flightstatus.Scheduled, now: dep.Add(-Opens)},
+		{name: "too early", status: flightstatus.Scheduled, now: dep.Add(-Opens - time.Minute), wantErr: ErrNotOpen},
+		{name: "closes", status: flightstatus.Scheduled, now: dep.Add(-Closes), wantErr: ErrClosed},
+		{name: "delayed", status: flightstatus.Delayed, now: dep.Add(-time.Hour)},
+		{name: "boarding", status: flightstatus.Boarding, now: dep.Add(-time.Hour)},
+		{name: "cancelled", status: flightstatus.Cancelled, now: dep.Add(-time.Hour), wantErr: ErrFlightNotFlying},
+		{name: "departed", status: flightstatus.Departed, now: dep.Add(-time.Hour), wantErr: ErrFlightNotFlying},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CanCheckIn(tt.status, dep, tt.now); err != tt.wantErr {
+				t.Errorf("CanCheckIn() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanBoard(t *testing.T) {
+	at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		status    flightstatus.Status
+		checkedIn *time.Time
+		boarded   *time.Time
+		wantErr   error
+	}{
+		{name: "board", status: flightstatus.Boarding, checkedIn: &at},
+		{name: "not checked in", status: flightstatus.Boarding, wantErr: ErrNotCheckedIn},
+		{name: "boarded twice", status: flightstatus.Boarding, checkedIn: &at, boarded: &at, wantErr: ErrAlreadyBoarded},
+		{name: "before boarding", status: flightstatus.Scheduled, checkedIn: &at, wantErr: ErrBoardingNotOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CanBoard(tt.status, StateOf(tt.checkedIn, tt.boarded)); err != tt.wantErr {
+				t.Errorf("CanBoard() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
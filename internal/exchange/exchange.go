@@ -0,0 +1,163 @@
+// Файл internal\exchange\exchange.go содержит таблицу курсов валют с датами начала действия, пересчёт сумм по курсу на дату и чтение курсов из CSV
+package exchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+// DateLayout is the layout of the effective dates of the rates
+const DateLayout = "2006-01-02"
+
+var (
+	ErrNoRate   = errors.New("no exchange rate of the currency on the date")
+	ErrCurrency = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrRate     = errors.New("exchange rate must be a positive number")
+	ErrMinor    = errors.New("currencies with more than two decimal digits are not supported")
+)
+
+// maxExponent is the most decimal digits of the currencies handled, the amounts are stored with two
+const maxExponent = 2
+
+var currencyCode = regexp.MustCompile("^[A-Z]{3}$")
+
+// Rate is the price of a unit of the currency in the base currency from the effective date until the next rate
+type Rate struct {
+	Currency      money.Currency
+	EffectiveDate time.Time
+	Rate          float64
+}
+
+// Table is the exchange rates of currencies against the base currency
+type Table struct {
+	Base  money.Currency
+	rates map[money.Currency][]Rate
+}
+
+func NewTable(base money.Currency, rates []Rate) *Table {
+	t := &Table{Base: base, rates: make(map[money.Currency][]Rate)}
+	for _, r := range rates {
+		t.rates[r.Currency] = append(t.rates[r.Currency], r)
+	}
+	for _, rs := range t.rates {
+		sort.Slice(rs, func(i, j int) bool {
+			return rs[i].EffectiveDate.Before(rs[j].EffectiveDate)
+		})
+	}
+	return t
+}
+
+// RateOn returns the rate of the currency effective on the date, the latest one set on or before it.
+// The base currency is always at 1
+func (t *Table) RateOn(c money.Currency, date time.Time) (Rate, error) {
+	if c == t.Base {
+		return Rate{Currency: c, Rate: 1}, nil
+	}
+	rates := t.rates[c]
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].EffectiveDate.After(date)
+	})
+	if i == 0 {
+		return Rate{}, ErrNoRate
+	}
+	return rates[i-1], nil
+}
+
+// Conversion is an amount converted at the rates effective on a date. Rate is the price of a unit of the currency
+// of From in the currency of To, EffectiveDate is the date the later of the rates used took effect
+type Conversion struct {
+	From          money.Money
+	To            money.Money
+	Rate          float64
+	EffectiveDate *time.Time
+}
+
+// Convert converts the amount to the currency at the rates effective on the date, crossing them through
+// the base currency. The result is rounded halves up
+func (t *Table) Convert(m money.Money, to money.Currency, date time.Time) (Conversion, error) {
+	if m.Currency == to {
+		return Conversion{From: m, To: m, Rate: 1}, nil
+	}
+	from, err := t.RateOn(m.Currency, date)
+	if err != nil {
+		return Conversion{}, err
+	}
+	into, err := t.RateOn(to, date)
+	if err != nil {
+		return Conversion{}, err
+	}
+	effective := from.EffectiveDate
+	if into.EffectiveDate.After(effective) {
+		effective = into.EffectiveDate
+	}
+	rate := from.Rate / into.Rate
+	return Conversion{
+		From:          m,
+		To:            m.Convert(to, rate, money.HalfUp),
+		Rate:          rate,
+		EffectiveDate: &effective,
+	}, nil
+}
+
+// ValidCurrency checks the currency code and that the amounts in the currency can be stored
+func ValidCurrency(c money.Currency) error {
+	if !currencyCode.MatchString(string(c)) {
+		return ErrCurrency
+	}
+	if c.Exponent() > maxExponent {
+		return ErrMinor
+	}
+	return nil
+}
+
+// ParseCSV reads the rates from CSV records of the currency code, the effective date as 2006-01-02 and the rate,
+// such as "USD,2023-06-01,81.5". A first record that is not a rate is taken for the header and skipped
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var rates []Rate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rate, err := parseRecord(record)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
+
+func parseRecord(record []string) (Rate, error) {
+	c := money.Currency(strings.ToUpper(strings.TrimSpace(record[0])))
+	if err := ValidCurrency(c); err != nil {
+		return Rate{}, err
+	}
+	date, err := time.Parse(DateLayout, strings.TrimSpace(record[1]))
+	if err != nil {
+		return Rate{}, err
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+	if err != nil || rate <= 0 {
+		return Rate{}, ErrRate
+	}
+	return Rate{Currency: c, EffectiveDate: date, Rate: rate}, nil
+}
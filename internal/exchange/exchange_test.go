@@ -0,0 +1,95 @@
+package exchange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestConvert(t *testing.T) {
+	table := NewTable(money.RUB, []Rate{
+		{Currency: money.USD, EffectiveDate: day("2023-06-02"), Rate: 82},
+		{Currency: money.USD, EffectiveDate: day("2023-06-01"), Rate: 80},
+		{Currency: money.EUR, EffectiveDate: day("2023-06-01"), Rate: 88},
+	})
+
+	tests := []struct {
+		name     string
+		m        money.Money
+		to       money.Currency
+		date     string
+		want     money.Money
+		wantDate string
+		wantErr  error
+	}{
+		{name: "same currency", m: money.New(1000, money.USD), to: money.USD, date: "2023-01-01", want: money.New(1000, money.USD)},
+		{name: "to base", m: money.New(1000, money.USD), to: money.RUB, date: "2023-06-01", want: money.New(80000, money.RUB), wantDate: "2023-06-01"},
+		{name: "later rate", m: money.New(1000, money.USD), to: money.RUB, date: "2023-06-15", want: money.New(82000, money.RUB), wantDate: "2023-06-02"},
+		{name: "from base", m: money.New(100000, money.RUB), to: money.USD, date: "2023-06-01", want: money.New(1250, money.USD), wantDate: "2023-06-01"},
+		{name: "cross", m: money.New(1100, money.EUR), to: money.USD, date: "2023-06-02", want: money.New(1180, money.USD), wantDate: "2023-06-02"},
+		{name: "before the first rate", m: money.New(1000, money.USD), to: money.RUB, date: "2023-05-31", wantErr: ErrNoRate},
+		{name: "unknown currency", m: money.New(1000, "GBP"), to: money.RUB, date: "2023-06-01", wantErr: ErrNoRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Convert(tt.m, tt.to, day(tt.date))
+			if err != tt.wantErr {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.To != tt.want {
+				t.Errorf("Convert() = %v %s, want %v %s", got.To, got.To.Currency, tt.want, tt.want.Currency)
+			}
+			if tt.wantDate != "" && (got.EffectiveDate == nil || !got.EffectiveDate.Equal(day(tt.wantDate))) {
+				t.Errorf("Convert() effective date = %v, want %s", got.EffectiveDate, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	rates, err := ParseCSV(strings.NewReader("currency,effective_date,rate\nusd, 2023-06-01, 80.5\nEUR,2023-06-01,88\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[0].Currency != money.USD || rates[0].Rate != 80.5 || !rates[0].EffectiveDate.Equal(day("2023-06-01")) {
+		t.Errorf("ParseCSV() = %+v", rates)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("USD,2023-06-01,80\nUSD,2023-06-02,-1\n")); !errors.Is(err, ErrRate) {
+		t.Errorf("ParseCSV() error = %v, want %v", err, ErrRate)
+	}
+	if _, err := ParseCSV(strings.NewReader("USD,2023-06-01\n")); err == nil {
+		t.Error("ParseCSV() of a short record = nil error")
+	}
+}
+
+func TestValidCurrency(t *testing.T) {
+	tests := []struct {
+		c    money.Currency
+		want error
+	}{
+		{c: money.USD},
+		{c: "JPY"},
+		{c: "usd", want: ErrCurrency},
+		{c: "KWD", want: ErrMinor},
+	}
+	for _, tt := range tests {
+		if err := ValidCurrency(tt.c); err != tt.want {
+			t.Errorf("ValidCurrency(%s) error = %v, want %v", tt.c, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Файл internal\fareclass\fareclass.go содержит вложенную инвентаризацию классов бронирования (RBD) внутри салона и выбор самого дешёвого открытого класса
+package fareclass
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var ErrSoldOut = errors.New("no booking class of the cabin is open")
+
+// Bucket is a booking class of a cabin sold at its own fare. Limit is the nested booking limit: the number of
+// passengers that may be sold in the bucket and all the cheaper buckets of the cabin together
+type Bucket struct {
+	Code       string
+	Cabin      string
+	Multiplier float64
+	Limit      int
+}
+
+// Availability is the state of sales of a bucket of a flight. Sold counts the bucket alone
+type Availability struct {
+	Bucket
+	Sold      int
+	Available int
+}
+
+// LimitOf returns the booking limit of a bucket given as a share of the authorized capacity of the cabin,
+// the limit set for the flight overrides it
+func LimitOf(authorized int, percent float64, override *int) int {
+	if override != nil {
+		return *override
+	}
+	return int(math.Floor(float64(authorized) * percent / 100))
+}
+
+// Less orders the buckets from the dearest to the cheapest
+func Less(a, b *Bucket) bool {
+	if a.Multiplier != b.Multiplier {
+		return a.Multiplier > b.Multiplier
+	}
+	return a.Code < b.Code
+}
+
+// Inventory returns the availability of the buckets of a cabin, dearest first. A bucket is available while
+// neither its own limit nor the limit of any dearer bucket is used up by the sales of the bucket and those cheaper,
+// so the cheap buckets close first as the cabin fills. Sales of codes not among the buckets count against the dearest one.
+// cabinAvailable is how many more passengers may be sold in the cabin
+func Inventory(buckets []Bucket, sold map[string]int, cabinAvailable int) []Availability {
+	inv := make([]Availability, len(buckets))
+	for i, b := range buckets {
+		inv[i] = Availability{Bucket: b}
+	}
+	sort.SliceStable(inv, func(i, j int) bool {
+		return Less(&inv[i].Bucket, &inv[j].Bucket)
+	})
+	if len(inv) == 0 {
+		return inv
+	}
+
+	known := make(map[string]bool, len(inv))
+	for i := range inv {
+		inv[i].Sold = sold[inv[i].Code]
+		known[inv[i].Code] = true
+	}
+	for code, n := range sold {
+		if !known[code] {
+			inv[0].Sold += n
+		}
+	}
+
+	// nested[i] is the number sold in the bucket i and all the cheaper ones
+	nested := make([]int, len(inv))
+	for i := len(inv) - 1; i >= 0; i-- {
+		nested[i] = inv[i].Sold
+		if i+1 < len(inv) {
+			nested[i] += nested[i+1]
+		}
+	}
+	available := cabinAvailable
+	for i := range inv {
+		if n := inv[i].Limit - nested[i]; n < available {
+			available = n
+		}
+		if available < 0 {
+			available = 0
+		}
+		inv[i].Available = available
+	}
+	return inv
+}
+
+// LowestOpen returns the cheapest bucket of the inventory that is still available
+func LowestOpen(inv []Availability) (*Availability, error) {
+	for i := len(inv) - 1; i >= 0; i-- {
+		if inv[i].Available > 0 {
+			return &inv[i], nil
+		}
+	}
+	return nil, ErrSoldOut
+}
+
+// Find returns the bucket of the inventory with the code
+func Find(inv []Availability, code string) *Availability {
+	for i := range inv {
+		if inv[i].Code == code {
+			return &inv[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package fareclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInventory(t *testing.T) {
+	buckets := []Bucket{
+		{Code: "M", Cabin: "Y", Multiplier: 1.2, Limit: 4},
+		{Code: "Y", Cabin: "Y", Multiplier: 1.5, Limit: 10},
+		{Code: "B", Cabin: "Y", Multiplier: 1.35, Limit: 7},
+	}
+
+	tests := []struct {
+		name          string
+		sold          map[string]int
+		cabin         int
+		wantAvailable map[string]int
+		wantLowest    string
+		wantErr       error
+	}{
+		{name: "empty flight", sold: nil, cabin: 10, wantAvailable: map[string]int{"Y": 10, "B": 7, "M": 4}, wantLowest: "M"},
+		{name: "cheapest closes first", sold: map[string]int{"M": 4}, cabin: 6, wantAvailable: map[string]int{"Y": 6, "B": 3, "M": 0}, wantLowest: "B"},
+		{name: "dearer sales close nested buckets", sold: map[string]int{"B": 5, "M": 2}, cabin: 3, wantAvailable: map[string]int{"Y": 3, "B": 0, "M": 0}, wantLowest: "Y"},
+		{name: "legacy sales count against the top", sold: map[string]int{"": 8}, cabin: 2, wantAvailable: map[string]int{"Y": 2, "B": 2, "M": 2}, wantLowest: "M"},
+		{name: "cabin limits all", sold: map[string]int{"Y": 1}, cabin: 1, wantAvailable: map[string]int{"Y": 1, "B": 1, "M": 1}, wantLowest: "M"},
+		{name: "sold out", sold: map[string]int{"Y": 3, "B": 3, "M": 4}, cabin: 0, wantAvailable: map[string]int{"Y": 0, "B": 0, "M": 0}, wantErr: ErrSoldOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := Inventory(buckets, tt.sold, tt.cabin)
+			if inv[0].Code != "Y" || inv[2].Code != "M" {
+				t.Fatalf("Inventory() order = %s %s %s, want Y B M", inv[0].Code, inv[1].Code, inv[2].Code)
+			}
+			got := make(map[string]int, len(inv))
+			for _, v := range inv {
+				got[v.Code] = v.Available
+			}
+			if !reflect.DeepEqual(got, tt.wantAvailable) {
+				t.Errorf("Inventory() available = %v, want %v", got, tt.wantAvailable)
+			}
+			lowest, err := LowestOpen(inv)
+			if err != tt.wantErr {
+				t.Fatalf("LowestOpen() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && lowest.Code != tt.wantLowest {
+				t.Errorf("LowestOpen() = %s, want %s", lowest.Code, tt.wantLowest)
+			}
+		})
+	}
+}
+
+func TestLimitOf(t *testing.T) {
+	five := 5
+	if got := LimitOf(31, 40, nil); got != 12 {
+		t.Errorf("LimitOf(31, 40, nil) = %d, want 12", got)
+	}
+	if got := LimitOf(31, 40, &five); got != 5 {
+		t.Errorf("LimitOf(31, 40, 5) = %d, want 5", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Файл internal\farerules\farerules.go содержит условия тарифа: возвратность, сборы за обмен и неявку, срок пребывания и раннее бронирование
+package farerules
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+var (
+	ErrNonRefundable   = errors.New("the fare is non-refundable")
+	ErrFlown           = errors.New("the segment is already flown")
+	ErrAdvancePurchase = errors.New("the fare must be bought earlier before the departure")
+	ErrMinStay         = errors.New("the stay is shorter than the fare allows")
+	ErrMaxStay         = errors.New("the stay is longer than the fare allows")
+)
+
+// Rule is the conditions of a fare. Stays and advance purchase are in days, nil ones are not restricted
+type Rule struct {
+	Refundable          bool
+	ChangeFee           money.Money
+	NoShowFee           money.Money
+	MinStayDays         *int
+	MaxStayDays         *int
+	AdvancePurchaseDays *int
+}
+
+// Unrestricted is the rule of fares sold without conditions. Involuntary refunds and exchanges, as of
+// a cancelled flight, are made under it as well
+var Unrestricted = Rule{Refundable: true}
+
+// Usage is the state of the segment when it is refunded or exchanged
+type Usage string
+
+const (
+	Unused Usage = "unused"
+	NoShow Usage = "no_show"
+	Flown  Usage = "flown"
+)
+
+// UsageOf returns the usage of a segment of a flight that has departed or not. A boarded passenger has flown,
+// one not boarded on a departed flight has not shown up
+func UsageOf(departed, boarded bool) Usage {
+	switch {
+	case boarded:
+		return Flown
+	case departed:
+		return NoShow
+	}
+	return Unused
+}
+
+// Refund returns the amount returned for a segment sold at the fare and the penalty kept.
+// The no-show penalty never exceeds the fare
+func Refund(r Rule, fare money.Money, u Usage) (amount, penalty money.Money, err error) {
+	if u == Flown {
+		return money.Money{}, money.Money{}, ErrFlown
+	}
+	if !r.Refundable {
+		return money.Money{}, money.Money{}, ErrNonRefundable
+	}
+	penalty = money.New(0, fare.Currency)
+	if u == NoShow {
+		if penalty, err = penalty.Add(r.NoShowFee); err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		above, err := penalty.Cmp(fare)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		if above > 0 {
+			penalty = fare
+		}
+	}
+	if amount, err = fare.Sub(penalty); err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	return amount, penalty, nil
+}
+
+// Exchange returns the amount to collect for exchanging a segment sold at the fare for one at newFare, negative
+// if it is returned, and the fees charged. The fare difference is returned only for refundable fares
+func Exchange(r Rule, fare, newFare money.Money, u Usage) (amount, fee money.Money, err error) {
+	if u == Flown {
+		return money.Money{}, money.Money{}, ErrFlown
+	}
+	fee, err = money.New(0, fare.Currency).Add(r.ChangeFee)
+	if err == nil && u == NoShow {
+		fee, err = fee.Add(r.NoShowFee)
+	}
+	var diff money.Money
+	if err == nil {
+		diff, err = newFare.Sub(fare)
+	}
+	if err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	if diff.IsNegative() && !r.Refundable {
+		diff = money.New(0, fare.Currency)
+	}
+	if amount, err = diff.Add(fee); err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	return amount, fee, nil
+}
+
+// Check checks that a fare bought at the time may be used for a departure and the stay.
+// outbound and inbound are the departures of the journey there and back, inbound is nil for one-way journeys
+// and the stay is not checked
+func Check(r Rule, bought, departure, outbound time.Time, inbound *time.Time) error {
+	if r.AdvancePurchaseDays != nil && bought.AddDate(0, 0, *r.AdvancePurchaseDays).After(departure) {
+		return ErrAdvancePurchase
+	}
+	if inbound == nil {
+		return nil
+	}
+	if r.MinStayDays != nil && outbound.AddDate(0, 0, *r.MinStayDays).After(*inbound) {
+		return ErrMinStay
+	}
+	if r.MaxStayDays != nil && outbound.AddDate(0, 0, *r.MaxStayDays).Before(*inbound) {
+		return ErrMaxStay
+	}
+	return nil
+}
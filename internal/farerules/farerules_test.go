@@ -0,0 +1,102 @@
+package farerules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+func rub(rubles int64) money.Money {
+	return money.New(rubles*100, money.RUB)
+}
+
+func TestRefund(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        Rule
+		usage       Usage
+		wantAmount  int64
+		wantPenalty int64
+		wantErr     error
+	}{
+		{name: "unused refundable", rule: Rule{Refundable: true, NoShowFee: rub(300)}, usage: Unused, wantAmount: 1000},
+		{name: "no-show", rule: Rule{Refundable: true, NoShowFee: rub(300)}, usage: NoShow, wantAmount: 700, wantPenalty: 300},
+		{name: "no-show penalty above the fare", rule: Rule{Refundable: true, NoShowFee: rub(1500)}, usage: NoShow, wantPenalty: 1000},
+		{name: "non-refundable", rule: Rule{}, usage: Unused, wantErr: ErrNonRefundable},
+		{name: "flown", rule: Unrestricted, usage: Flown, wantErr: ErrFlown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, penalty, err := Refund(tt.rule, rub(1000), tt.usage)
+			if err != tt.wantErr {
+				t.Fatalf("Refund() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (amount != rub(tt.wantAmount) || penalty != rub(tt.wantPenalty)) {
+				t.Errorf("Refund() = %v, %v, want %v, %v", amount, penalty, tt.wantAmount, tt.wantPenalty)
+			}
+		})
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       Rule
+		newFare    int64
+		usage      Usage
+		wantAmount int64
+		wantFee    int64
+		wantErr    error
+	}{
+		{name: "dearer fare", rule: Rule{ChangeFee: rub(100)}, newFare: 1200, usage: Unused, wantAmount: 300, wantFee: 100},
+		{name: "cheaper refundable", rule: Rule{Refundable: true, ChangeFee: rub(100)}, newFare: 700, usage: Unused, wantAmount: -200, wantFee: 100},
+		{name: "cheaper non-refundable", rule: Rule{ChangeFee: rub(100)}, newFare: 700, usage: Unused, wantAmount: 100, wantFee: 100},
+		{name: "no-show", rule: Rule{ChangeFee: rub(100), NoShowFee: rub(200)}, newFare: 1000, usage: NoShow, wantAmount: 300, wantFee: 300},
+		{name: "flown", rule: Unrestricted, newFare: 1000, usage: Flown, wantErr: ErrFlown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, fee, err := Exchange(tt.rule, rub(1000), rub(tt.newFare), tt.usage)
+			if err != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (amount != rub(tt.wantAmount) || fee != rub(tt.wantFee)) {
+				t.Errorf("Exchange() = %v, %v, want %v, %v", amount, fee, tt.wantAmount, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	seven, three, thirty := 7, 3, 30
+	rule := Rule{AdvancePurchaseDays: &seven, MinStayDays: &three, MaxStayDays: &thirty}
+	dep := time.Date(2023, 6, 10, 9, 0, 0, 0, time.UTC)
+	back := func(days int) *time.Time {
+		t := dep.AddDate(0, 0, days)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		bought  time.Time
+		inbound *time.Time
+		want    error
+	}{
+		{name: "one-way", bought: dep.AddDate(0, 0, -7), want: nil},
+		{name: "bought late", bought: dep.AddDate(0, 0, -6), want: ErrAdvancePurchase},
+		{name: "stay within", bought: dep.AddDate(0, -1, 0), inbound: back(3), want: nil},
+		{name: "stay too short", bought: dep.AddDate(0, -1, 0), inbound: back(2), want: ErrMinStay},
+		{name: "stay too long", bought: dep.AddDate(0, -1, 0), inbound: back(31), want: ErrMaxStay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(rule, tt.bought, dep, dep, tt.inbound); err != tt.want {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if err := Check(Unrestricted, dep, dep, dep, back(400)); err != nil {
+		t.Errorf("Check(Unrestricted) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Файл internal\flightstatus\flightstatus.go содержит статусы рейса и допустимые переходы между ними
+package flightstatus
+
+import (
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	Scheduled Status = "scheduled"
+	Delayed   Status = "delayed"
+	Boarding  Status = "boarding"
+	Departed  Status = "departed"
+	Arrived   Status = "arrived"
+	Cancelled Status = "cancelled"
+)
+
+var (
+	ErrUnknownStatus         = errors.New("unknown flight status")
+	ErrBadTransition         = errors.New("flight cannot change to this status")
+	ErrEstimateRequired      = errors.New("delay needs an estimated departure time")
+	ErrEstimateNotLater      = errors.New("estimated departure must be later than the scheduled one")
+	ErrArrivalBeforeDepart   = errors.New("arrival must be later than departure")
+	ErrActualTimeInTheFuture = errors.New("actual time must not be in the future")
+)
+
+// transitions lists the statuses a flight may change to. A delayed flight may be delayed again with a new estimate
+var transitions = map[Status][]Status{
+	Scheduled: {Delayed, Boarding, Cancelled},
+	Delayed:   {Delayed, Boarding, Cancelled},
+	Boarding:  {Delayed, Departed, Cancelled},
+	Departed:  {Arrived},
+	Arrived:   {},
+	Cancelled: {},
+}
+
+// Valid reports whether the status is known
+func Valid(s Status) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanChange reports whether a flight may change from one status to another
+func CanChange(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OnSale reports whether tickets for a flight in the status may be sold
+func OnSale(s Status) bool {
+	return s == Scheduled || s == Delayed
+}
+
+// Times are the estimated and the actual times of a flight. Nil times are unknown
+type Times struct {
+	EstimatedDeparture *time.Time
+	EstimatedArrival   *time.Time
+	ActualDeparture    *time.Time
+	ActualArrival      *time.Time
+}
+
+// Change checks the change of the status of a flight scheduled to depart at scheduledDep and returns
+// the times of the flight after it. Times not given in the change are kept, a departed flight gets
+// the actual departure time now unless it is given, an arrived one gets the actual arrival time the same way
+func Change(from, to Status, scheduledDep time.Time, current, change Times, now time.Time) (Times, error) {
+	if !Valid(to) {
+		return current, ErrUnknownStatus
+	}
+	if !CanChange(from, to) {
+		return current, ErrBadTransition
+	}
+
+	times := current
+	if change.EstimatedDeparture != nil {
+		times.EstimatedDeparture = change.EstimatedDeparture
+	}
+	if change.EstimatedArrival != nil {
+		times.EstimatedArrival = change.EstimatedArrival
+	}
+
+	switch to {
+	case Delayed:
+		if change.EstimatedDeparture == nil {
+			return current, ErrEstimateRequired
+		}
+		if !change.EstimatedDeparture.After(scheduledDep) {
+			return current, ErrEstimateNotLater
+		}
+	case Departed:
+		times.ActualDeparture = change.ActualDeparture
+		if times.ActualDeparture == nil {
+			times.ActualDeparture = &now
+		}
+		if times.ActualDeparture.After(now) {
+			return current, ErrActualTimeInTheFuture
+		}
+	case Arrived:
+		times.ActualArrival = change.ActualArrival
+		if times.ActualArrival == nil {
+			times.ActualArrival = &now
+		}
+		if times.ActualArrival.After(now) {
+			return current, ErrActualTimeInTheFuture
+		}
+		if times.ActualDeparture != nil && !times.ActualArrival.After(*times.ActualDeparture) {
+			return current, ErrArrivalBeforeDepart
+		}
+	}
+	if times.EstimatedDeparture != nil && times.EstimatedArrival != nil && !times.EstimatedArrival.After(*times.EstimatedDeparture) {
+		return current, ErrArrivalBeforeDepart
+	}
+	return times, nil
+}
@@ -0,0 +1,46 @@
+package flightstatus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChange(t *testing.T) {
+	scheduled := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(h int) *time.Time {
+		t := time.Date(2024, 5, 1, h, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		current Times
+		change  Times
+		wantErr error
+	}{
+		{name: "delay", from: Scheduled, to: Delayed, change: Times{EstimatedDeparture: at(11)}},
+		{name: "delay again", from: Delayed, to: Delayed, change: Times{EstimatedDeparture: at(12)}},
+		{name: "delay without estimate", from: Scheduled, to: Delayed, wantErr: ErrEstimateRequired},
+		{name: "delay to earlier", from: Scheduled, to: Delayed, change: Times{EstimatedDeparture: at(9)}, wantErr: ErrEstimateNotLater},
+		{name: "depart", from: Boarding, to: Departed},
+		{name: "depart in the future", from: Boarding, to: Departed, change: Times{ActualDeparture: at(13)}, wantErr: ErrActualTimeInTheFuture},
+		{name: "depart without boarding", from: Scheduled, to: Departed, wantErr: ErrBadTransition},
+		{name: "cancel departed", from: Departed, to: Cancelled, wantErr: ErrBadTransition},
+		{name: "arrive before departure", from: Departed, to: Arrived, current: Times{ActualDeparture: at(11)}, change: Times{ActualArrival: at(10)}, wantErr: ErrArrivalBeforeDepart},
+		{name: "unknown", from: Scheduled, to: "landed", wantErr: ErrUnknownStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Change(tt.from, tt.to, scheduled, tt.current, tt.change, now)
+			if err != tt.wantErr {
+				t.Fatalf("Change() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && tt.to == Departed && got.ActualDeparture == nil {
+				t.Errorf("Change() did not set the actual departure")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Файл internal\geo\geo.go содержит расчёт расстояния по большому кругу между точками на поверхности Земли
+package geo
+
+import "math"
+
+const (
+	earthRadiusKm = 6371.0088
+	kmPerMile     = 1.609344
+)
+
+// Point is a point on the surface of the Earth, latitude and longitude in degrees
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Distance returns the great-circle distance between the points in kilometres by the haversine formula
+func Distance(a, b Point) float64 {
+	dLat, dLon := radians(b.Lat-a.Lat), radians(b.Lon-a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(radians(a.Lat))*math.Cos(radians(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Miles returns the distance in kilometres in statute miles
+func Miles(km float64) float64 {
+	return km / kmPerMile
+}
@@ -0,0 +1,33 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	svo := Point{Lat: 55.972599, Lon: 37.4146}
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{name: "SVO-LED", a: svo, b: Point{Lat: 59.800301, Lon: 30.262501}, want: 599},
+		{name: "JFK-LHR", a: Point{Lat: 40.639801, Lon: -73.7789}, b: Point{Lat: 51.4706, Lon: -0.461941}, want: 5540},
+		{name: "across the antimeridian", a: Point{Lat: 0, Lon: 179.5}, b: Point{Lat: 0, Lon: -179.5}, want: 111},
+		{name: "same point", a: svo, b: svo, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > 2 {
+				t.Errorf("Distance() = %.1f, want %.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiles(t *testing.T) {
+	if got := Miles(1609.344); math.Abs(got-1000) > 1e-9 {
+		t.Errorf("Miles() = %v, want 1000", got)
+	}
+}
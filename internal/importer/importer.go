@@ -0,0 +1,171 @@
+// Файл internal\importer\importer.go содержит разбор файлов CSV и JSON для массового импорта справочников
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrFormat = errors.New("unknown file format")
+	ErrColumn = errors.New("unknown column")
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// FormatOf returns the format of the file by the extension of its name
+func FormatOf(name string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// ParseFormat returns the format by its name
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, JSON:
+		return f, nil
+	}
+	return "", ErrFormat
+}
+
+// Row is a row of a file decoded to an item. Number is the line of a CSV file or the position
+// in the array of a JSON file, from 1. Err is the error of decoding the row, Item is nil then
+type Row struct {
+	Number int
+	Item   interface{}
+	Err    error
+}
+
+// Decode decodes the rows of the file to the items made by newItem, pointers to structs. A JSON file
+// is an array of objects. A CSV file has a header with the JSON names of the fields, empty cells
+// leave the fields unset. Errors of single rows are in the rows, the others stop the decoding
+func Decode(r io.Reader, format Format, newItem func() interface{}) ([]Row, error) {
+	switch format {
+	case CSV:
+		return decodeCSV(r, newItem)
+	case JSON:
+		return decodeJSON(r, newItem)
+	}
+	return nil, ErrFormat
+}
+
+func decodeJSON(r io.Reader, newItem func() interface{}) ([]Row, error) {
+	var objects []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	rows := make([]Row, len(objects))
+	for i, o := range objects {
+		rows[i] = decodeRow(i+1, o, newItem)
+	}
+	return rows, nil
+}
+
+func decodeCSV(r io.Reader, newItem func() interface{}) ([]Row, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	raw := rawFields(newItem())
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if _, ok := raw[header[i]]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrColumn, header[i])
+		}
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			// A row with a wrong number of cells is an error of the row, the rest of the file is read
+			if e, ok := err.(*csv.ParseError); ok && e.Err == csv.ErrFieldCount {
+				rows = append(rows, Row{Number: line, Err: e.Err})
+				continue
+			}
+			return nil, err
+		}
+
+		b, err := csvObject(header, record, raw)
+		if err != nil {
+			rows = append(rows, Row{Number: line, Err: err})
+			continue
+		}
+		rows = append(rows, decodeRow(line, b, newItem))
+	}
+}
+
+// csvObject returns the row of a CSV file as a JSON object
+func csvObject(header, record []string, raw map[string]bool) ([]byte, error) {
+	object := make(map[string]json.RawMessage, len(record))
+	for i, cell := range record {
+		if cell == "" {
+			continue
+		}
+		if raw[header[i]] {
+			if !json.Valid([]byte(cell)) {
+				return nil, fmt.Errorf("%s: bad value %q", header[i], cell)
+			}
+			object[header[i]] = json.RawMessage(cell)
+			continue
+		}
+		b, err := json.Marshal(cell)
+		if err != nil {
+			return nil, err
+		}
+		object[header[i]] = b
+	}
+	return json.Marshal(object)
+}
+
+func decodeRow(number int, b []byte, newItem func() interface{}) Row {
+	item := newItem()
+	if err := json.Unmarshal(b, item); err != nil {
+		return Row{Number: number, Err: err}
+	}
+	return Row{Number: number, Item: item}
+}
+
+// rawFields returns the JSON names of the fields of the struct the item points to, true for the numbers
+// and booleans, which CSV cells are put as they are. The other cells are strings
+func rawFields(item interface{}) map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(item).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		kind := f.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = f.Type.Elem().Kind()
+		}
+		switch kind {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			fields[name] = true
+		default:
+			fields[name] = false
+		}
+	}
+	return fields
+}
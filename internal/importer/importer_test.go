@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Offset   int      `json:"offset"`
+	Latitude *float64 `json:"latitude"`
+	Internal string   `json:"-"`
+}
+
+func newItem() interface{} { return &item{} }
+
+func TestDecodeCSV(t *testing.T) {
+	const file = "code,name,offset,latitude\n" +
+		"001,\"Moscow, SVO\",1,55.97\n" +
+		"LED,Pulkovo,,\n" +
+		"KZN,Kazan\n" +
+		"AER,Sochi,one,\n"
+	rows, err := Decode(strings.NewReader(file), CSV, newItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Decode() = %d rows, want 4", len(rows))
+	}
+	first := rows[0].Item.(*item)
+	if rows[0].Number != 2 || first.Code != "001" || first.Name != "Moscow, SVO" || first.Offset != 1 ||
+		first.Latitude == nil || *first.Latitude != 55.97 {
+		t.Errorf("Decode() row 1 = %d %+v", rows[0].Number, first)
+	}
+	if second := rows[1].Item.(*item); second.Code != "LED" || second.Offset != 0 || second.Latitude != nil {
+		t.Errorf("Decode() row with empty cells = %+v", second)
+	}
+	for _, i := range []int{2, 3} {
+		if rows[i].Err == nil || rows[i].Item != nil || rows[i].Number != i+2 {
+			t.Errorf("Decode() bad row %d = %+v, want an error", i+2, rows[i])
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	rows, err := Decode(strings.NewReader(`[{"code":"SVO","offset":2},{"code":1}]`), JSON, newItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Number != 1 || rows[0].Item.(*item).Offset != 2 || rows[1].Number != 2 || rows[1].Err == nil {
+		t.Errorf("Decode() = %+v", rows)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(strings.NewReader("code,city\nSVO,Moscow\n"), CSV, newItem); !errors.Is(err, ErrColumn) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrColumn)
+	}
+	if _, err := Decode(strings.NewReader(""), Format("xml"), newItem); err != ErrFormat {
+		t.Errorf("Decode() error = %v, want %v", err, ErrFormat)
+	}
+	tests := map[string]Format{"airports.CSV": CSV, "/tmp/lines.json": JSON, "seats": ""}
+	for name, want := range tests {
+		if got, _ := FormatOf(name); got != want {
+			t.Errorf("FormatOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Файл internal\loyalty\loyalty.go содержит программу лояльности: начисление миль по расстоянию и классу, статусы участников, срок действия миль и их списание
+package loyalty
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+var (
+	ErrInsufficientMiles = errors.New("the balance is less than the miles redeemed")
+	ErrAboveTotal        = errors.New("the miles redeemed are worth more than the total")
+)
+
+const (
+	// QualifyingMonths is the period the miles earned in count for the tier
+	QualifyingMonths = 12
+	// ExpiryMonths is the period the miles earned are valid for
+	ExpiryMonths = 24
+	// mileValue is the value of a redeemed mile in kopecks
+	mileValue = 50
+)
+
+type Tier string
+
+const (
+	Basic    Tier = "basic"
+	Silver   Tier = "silver"
+	Gold     Tier = "gold"
+	Platinum Tier = "platinum"
+)
+
+// TierLevel is the qualifying miles a tier starts from and the bonus share of the miles its members earn
+type TierLevel struct {
+	Tier      Tier
+	Threshold int
+	Bonus     float64
+}
+
+// Tiers are the tiers from the lowest
+var Tiers = []TierLevel{
+	{Tier: Basic, Threshold: 0, Bonus: 0},
+	{Tier: Silver, Threshold: 25000, Bonus: 0.25},
+	{Tier: Gold, Threshold: 50000, Bonus: 0.5},
+	{Tier: Platinum, Threshold: 100000, Bonus: 1},
+}
+
+var classAccrual = map[string]float64{
+	"J": 1.5,
+	"Y": 1.25,
+	"W": 1,
+}
+
+// TierOf returns the tier of a member with the qualifying miles
+func TierOf(qualifying int) TierLevel {
+	level := Tiers[0]
+	for _, t := range Tiers {
+		if qualifying >= t.Threshold {
+			level = t
+		}
+	}
+	return level
+}
+
+func bonusOf(tier Tier) float64 {
+	for _, t := range Tiers {
+		if t.Tier == tier {
+			return t.Bonus
+		}
+	}
+	return 0
+}
+
+// Accrual returns the miles a member of the tier earns for a segment of the distance in miles flown in the class.
+// The tier bonus is a share of the miles of the class
+func Accrual(distance float64, class string, tier Tier) int {
+	miles := math.Round(distance * classAccrual[class])
+	return int(miles + math.Round(miles*bonusOf(tier)))
+}
+
+// Kind is the kind of a ledger entry
+type Kind string
+
+const (
+	Earned   Kind = "accrual"
+	Redeemed Kind = "redemption"
+)
+
+// Entry is an entry of the ledger of an account: miles earned, positive, or redeemed, negative.
+// Miles without the expiry date never expire
+type Entry struct {
+	Kind      Kind
+	Miles     int
+	At        time.Time
+	ExpiresAt *time.Time
+}
+
+// Status is the state of an account at a time. Expired is the miles that expired unused,
+// Expiring the miles that expire first on NextExpiry
+type Status struct {
+	Balance    int
+	Expired    int
+	Qualifying int
+	Tier       Tier
+	NextExpiry *time.Time
+	Expiring   int
+}
+
+type lot struct {
+	miles     int
+	expiresAt *time.Time
+}
+
+// expire drops the lots expired by the time and returns the miles they had left
+func expire(lots []lot, at time.Time) ([]lot, int) {
+	expired := 0
+	kept := lots[:0]
+	for _, l := range lots {
+		if l.expiresAt != nil && !l.expiresAt.After(at) {
+			expired += l.miles
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept, expired
+}
+
+// StatusOf returns the status of the account with the ledger at the time. Redemptions use the miles
+// that expire first. The tier is by the miles earned in the last QualifyingMonths
+func StatusOf(entries []Entry, at time.Time) Status {
+	sorted := append([]Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].At.Before(sorted[j].At)
+	})
+	qualifyingFrom := at.AddDate(0, -QualifyingMonths, 0)
+
+	var s Status
+	var lots []lot
+	for _, e := range sorted {
+		if e.At.After(at) {
+			break
+		}
+		var expired int
+		lots, expired = expire(lots, e.At)
+		s.Expired += expired
+
+		if e.Miles >= 0 {
+			lots = append(lots, lot{miles: e.Miles, expiresAt: e.ExpiresAt})
+			if e.Kind == Earned && !e.At.Before(qualifyingFrom) {
+				s.Qualifying += e.Miles
+			}
+			continue
+		}
+		sort.SliceStable(lots, func(i, j int) bool {
+			a, b := lots[i].expiresAt, lots[j].expiresAt
+			return a != nil && (b == nil || a.Before(*b))
+		})
+		redeemed := -e.Miles
+		for i := range lots {
+			used := lots[i].miles
+			if used > redeemed {
+				used = redeemed
+			}
+			lots[i].miles -= used
+			redeemed -= used
+		}
+	}
+	var expired int
+	lots, expired = expire(lots, at)
+	s.Expired += expired
+
+	for _, l := range lots {
+		s.Balance += l.miles
+		if l.miles == 0 || l.expiresAt == nil {
+			continue
+		}
+		switch {
+		case s.NextExpiry == nil || l.expiresAt.Before(*s.NextExpiry):
+			next := *l.expiresAt
+			s.NextExpiry, s.Expiring = &next, l.miles
+		case l.expiresAt.Equal(*s.NextExpiry):
+			s.Expiring += l.miles
+		}
+	}
+	s.Tier = TierOf(s.Qualifying).Tier
+	return s
+}
+
+// Account is a loyalty account of a passenger profile
+type Account struct {
+	ID          int
+	PassengerID int
+	EnrolledAt  time.Time
+}
+
+// Merge returns the account the passenger keeps when the profiles of the accounts are merged into its own one and
+// the other accounts, whose ledgers move to it. The passenger keeps its own account or else the earliest one
+// of the duplicates, enrolled since the earliest of them. ok is false if none of the profiles has an account
+func Merge(passengerID int, accounts []Account) (kept Account, merged []int, ok bool) {
+	if len(accounts) == 0 {
+		return Account{}, nil, false
+	}
+	sorted := append([]Account{}, accounts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a.PassengerID == passengerID) != (b.PassengerID == passengerID) {
+			return a.PassengerID == passengerID
+		}
+		return a.EnrolledAt.Before(b.EnrolledAt)
+	})
+	kept = sorted[0]
+	for _, a := range sorted[1:] {
+		merged = append(merged, a.ID)
+		if a.EnrolledAt.Before(kept.EnrolledAt) {
+			kept.EnrolledAt = a.EnrolledAt
+		}
+	}
+	kept.PassengerID = passengerID
+	return kept, merged, true
+}
+
+// Value returns the value of the miles redeemed in roubles
+func Value(miles int) money.Money {
+	return money.New(int64(miles)*mileValue, money.RUB)
+}
+
+// Redeem checks that the miles can be redeemed from the balance for a total, value being the value
+// of the miles in the currency of the total
+func Redeem(balance, miles int, value, total money.Money) error {
+	if miles > balance {
+		return ErrInsufficientMiles
+	}
+	above, err := value.Cmp(total)
+	if err != nil {
+		return err
+	}
+	if above > 0 {
+		return ErrAboveTotal
+	}
+	return nil
+}
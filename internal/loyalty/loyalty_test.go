@@ -0,0 +1,88 @@
+package loyalty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+func TestAccrual(t *testing.T) {
+	tests := []struct {
+		class string
+		tier  Tier
+		want  int
+	}{
+		{class: "W", tier: Basic, want: 1000},
+		{class: "Y", tier: Basic, want: 1250},
+		{class: "J", tier: Silver, want: 1875},
+		{class: "W", tier: Platinum, want: 2000},
+	}
+	for _, tt := range tests {
+		if got := Accrual(1000, tt.class, tt.tier); got != tt.want {
+			t.Errorf("Accrual(1000, %s, %s) = %d, want %d", tt.class, tt.tier, got, tt.want)
+		}
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	earned := func(miles int, at time.Time) Entry {
+		expires := at.AddDate(0, ExpiryMonths, 0)
+		return Entry{Kind: Earned, Miles: miles, At: at, ExpiresAt: &expires}
+	}
+	entries := []Entry{
+		earned(10000, day(2021, 1, 10)),
+		earned(20000, day(2022, 6, 1)),
+		{Kind: Redeemed, Miles: -4000, At: day(2022, 7, 1)},
+		earned(30000, day(2023, 3, 1)),
+	}
+
+	s := StatusOf(entries, day(2022, 12, 31))
+	if s.Balance != 26000 || s.Expired != 0 || s.Expiring != 6000 || !s.NextExpiry.Equal(day(2023, 1, 10)) {
+		t.Errorf("StatusOf() before the expiry = %+v", s)
+	}
+	if s.Qualifying != 20000 || s.Tier != Basic {
+		t.Errorf("StatusOf() qualifying = %d %s, want 20000 basic", s.Qualifying, s.Tier)
+	}
+
+	s = StatusOf(entries, day(2023, 6, 1))
+	if s.Balance != 50000 || s.Expired != 6000 {
+		t.Errorf("StatusOf() after the expiry = %+v, want the balance 50000 and 6000 expired", s)
+	}
+	if s.Qualifying != 50000 || s.Tier != Gold {
+		t.Errorf("StatusOf() qualifying = %d %s, want 50000 gold", s.Qualifying, s.Tier)
+	}
+}
+
+func TestRedeem(t *testing.T) {
+	total := money.New(100000, money.RUB)
+	if err := Redeem(1000, 1000, Value(1000), total); err != nil {
+		t.Errorf("Redeem() error = %v", err)
+	}
+	if err := Redeem(1000, 1500, Value(1500), total); err != ErrInsufficientMiles {
+		t.Errorf("Redeem() error = %v, want %v", err, ErrInsufficientMiles)
+	}
+	if err := Redeem(5000, 3000, Value(3000), total); err != ErrAboveTotal {
+		t.Errorf("Redeem() error = %v, want %v", err, ErrAboveTotal)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, time.June, d, 0, 0, 0, 0, time.UTC) }
+	own := Account{ID: 3, PassengerID: 1, EnrolledAt: day(10)}
+	first := Account{ID: 2, PassengerID: 7, EnrolledAt: day(1)}
+	second := Account{ID: 1, PassengerID: 8, EnrolledAt: day(5)}
+
+	kept, merged, ok := Merge(1, []Account{second, own, first})
+	if !ok || kept.ID != own.ID || !kept.EnrolledAt.Equal(day(1)) || len(merged) != 2 || merged[0] != first.ID || merged[1] != second.ID {
+		t.Errorf("Merge() with an own account = %+v, %v, %v", kept, merged, ok)
+	}
+	kept, merged, ok = Merge(1, []Account{second, first})
+	if !ok || kept.ID != first.ID || kept.PassengerID != 1 || len(merged) != 1 || merged[0] != second.ID {
+		t.Errorf("Merge() with duplicate accounts = %+v, %v, %v", kept, merged, ok)
+	}
+	if _, _, ok := Merge(1, nil); ok {
+		t.Error("Merge() without accounts is ok")
+	}
+}
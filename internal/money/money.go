@@ -0,0 +1,305 @@
+// Файл internal\money\money.go содержит денежные суммы в целых минимальных единицах валюты, их округление и запись десятичной строкой в JSON и БД
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+)
+
+// Default is the currency of amounts given without one
+const Default = RUB
+
+// exponents lists the currencies whose minor unit is not a hundredth
+var exponents = map[Currency]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// Exponent returns the number of decimal digits of the minor unit of the currency
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+var (
+	ErrFormat           = errors.New("amount must be a decimal number")
+	ErrPrecision        = errors.New("amount has more decimal digits than the currency has")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// Rounding is how an amount that falls between two minor units is rounded
+type Rounding int
+
+const (
+	// HalfUp rounds halves away from zero, the way prices are rounded
+	HalfUp Rounding = iota
+	// HalfEven rounds halves to the even minor unit, so rounding errors do not pile up in sums
+	HalfEven
+	// Down drops the fraction of the minor unit
+	Down
+)
+
+// factorScale is the precision of the factors amounts are multiplied by
+const factorScale = 1e9
+
+// Money is an amount in the minor units of the currency, kopecks for roubles
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+func New(minor int64, c Currency) Money {
+	return Money{Minor: minor, Currency: c}
+}
+
+// Parse reads a decimal amount such as "1234.5" in the currency. The amount may not have more decimal digits
+// than the minor unit of the currency
+func Parse(s string, c Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" || strings.ContainsAny(whole+frac, "+-") {
+		return Money{}, ErrFormat
+	}
+	exp := c.Exponent()
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return Money{}, ErrPrecision
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, ErrFormat
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: c}, nil
+}
+
+// String returns the amount as a decimal number with all the digits of the minor unit, such as "1234.50"
+func (m Money) String() string {
+	exp := m.Currency.Exponent()
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(minor, 10)
+	}
+	unit := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/unit, exp, minor%unit)
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// Cmp compares the amounts of the same currency, returning -1, 0 or 1
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.same(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Add returns the sum of the amounts of the same currency. A zero amount without a currency takes the currency of the other
+func (m Money) Add(o Money) (Money, error) {
+	c, err := m.same(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: c}, nil
+}
+
+// Sub returns the difference of the amounts of the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	c, err := m.same(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: m.Minor - o.Minor, Currency: c}, nil
+}
+
+// Sum returns the sum of the amounts of the same currency
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Mul multiplies the amount by the factor, rounding the result to the minor unit. The factor is taken
+// to nine decimal digits, the product is exact before the rounding
+func (m Money) Mul(factor float64, r Rounding) Money {
+	f := big.NewInt(int64(math.Round(factor * factorScale)))
+	p := new(big.Int).Mul(big.NewInt(m.Minor), f)
+	return Money{Minor: divRound(p, big.NewInt(factorScale), r), Currency: m.Currency}
+}
+
+// In returns the same decimal amount in the currency, failing if the currency has too few decimal digits for it.
+// Amounts read from JSON or the database are in the default currency until put in their own
+func (m Money) In(c Currency) (Money, error) {
+	from, to := m.Currency.Exponent(), c.Exponent()
+	minor := m.Minor
+	for ; from < to; from++ {
+		minor *= 10
+	}
+	for ; from > to; from-- {
+		if minor%10 != 0 {
+			return Money{}, ErrPrecision
+		}
+		minor /= 10
+	}
+	return Money{Minor: minor, Currency: c}, nil
+}
+
+// Convert returns the amount in the currency at the rate, the price of a unit of the currency of the amount
+// in the other currency
+func (m Money) Convert(c Currency, rate float64, r Rounding) Money {
+	shift := c.Exponent() - m.Currency.Exponent()
+	return Money{Minor: m.Minor, Currency: c}.Mul(rate*math.Pow10(shift), r)
+}
+
+// same returns the currency of the amounts, failing if they are in different ones. Amounts read from the database
+// are in the currencies stored with them, so the callers convert them before adding them up
+func (m Money) same(o Money) (Currency, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Minor == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Minor == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// divRound divides p by the positive q rounding the quotient to an integer
+func divRound(p, q *big.Int, r Rounding) int64 {
+	quo, rem := new(big.Int).QuoRem(p, q, new(big.Int))
+	if rem.Sign() == 0 || r == Down {
+		return quo.Int64()
+	}
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	away := false
+	switch c := twice.Cmp(q); {
+	case c > 0:
+		away = true
+	case c == 0:
+		away = r == HalfUp || quo.Bit(0) == 1
+	}
+	if away {
+		quo.Add(quo, big.NewInt(int64(p.Sign())))
+	}
+	return quo.Int64()
+}
+
+// MarshalJSON writes the amount as a decimal string, the currency is given next to it where it matters
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reads the amount from a decimal string or a number. The currency is kept if set before, otherwise
+// it is the default one
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	c := m.Currency
+	if c == "" {
+		c = Default
+	}
+	v, err := Parse(s, c)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column in the currency set before or the default one
+func (m *Money) Scan(src interface{}) error {
+	c := m.Currency
+	if c == "" {
+		c = Default
+	}
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', c.Exponent(), 64)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	v, err := Parse(s, c)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value writes the amount to a DECIMAL column as a decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
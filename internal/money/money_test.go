@@ -0,0 +1,157 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s       string
+		c       Currency
+		want    int64
+		wantErr error
+	}{
+		{s: "1234.5", c: RUB, want: 123450},
+		{s: "1234.50", c: RUB, want: 123450},
+		{s: "0.01", c: RUB, want: 1},
+		{s: "-0.5", c: RUB, want: -50},
+		{s: "1500", c: RUB, want: 150000},
+		{s: "10.000", c: RUB, want: 1000},
+		{s: "10.005", c: RUB, wantErr: ErrPrecision},
+		{s: "1500", c: "JPY", want: 1500},
+		{s: "1.5", c: "JPY", wantErr: ErrPrecision},
+		{s: "1.005", c: "KWD", want: 1005},
+		{s: "", c: RUB, wantErr: ErrFormat},
+		{s: "1e3", c: RUB, wantErr: ErrFormat},
+		{s: "--1", c: RUB, wantErr: ErrFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := Parse(tt.s, tt.c)
+			if err != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Minor != tt.want || got.Currency != tt.c) {
+				t.Errorf("Parse() = %+v, want %d %s", got, tt.want, tt.c)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: New(123450, RUB), want: "1234.50"},
+		{m: New(5, RUB), want: "0.05"},
+		{m: New(-50, RUB), want: "-0.50"},
+		{m: New(1500, "JPY"), want: "1500"},
+		{m: New(1005, "KWD"), want: "1.005"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name   string
+		m      Money
+		factor float64
+		r      Rounding
+		want   int64
+	}{
+		{name: "exact", m: New(100000, RUB), factor: 1.5, r: HalfUp, want: 150000},
+		{name: "half up", m: New(5, RUB), factor: 0.5, r: HalfUp, want: 3},
+		{name: "half up negative", m: New(-5, RUB), factor: 0.5, r: HalfUp, want: -3},
+		{name: "half even down", m: New(5, RUB), factor: 0.5, r: HalfEven, want: 2},
+		{name: "half even up", m: New(7, RUB), factor: 0.5, r: HalfEven, want: 4},
+		{name: "down", m: New(9, RUB), factor: 0.5, r: Down, want: 4},
+		{name: "above half", m: New(100, RUB), factor: 0.3337, r: Down, want: 33},
+		{name: "float factor", m: New(100000, RUB), factor: 0.1 * 3, r: HalfUp, want: 30000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Mul(tt.factor, tt.r); got.Minor != tt.want {
+				t.Errorf("Mul() = %d, want %d", got.Minor, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	if got := New(1000, USD).Convert(RUB, 92.5, HalfUp); got != New(92500, RUB) {
+		t.Errorf("Convert() = %+v, want 925.00 RUB", got)
+	}
+	if got := New(100000, RUB).Convert("JPY", 1.6, HalfUp); got != New(1600, "JPY") {
+		t.Errorf("Convert() = %+v, want 1600 JPY", got)
+	}
+}
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		m       Money
+		c       Currency
+		want    Money
+		wantErr error
+	}{
+		{m: New(150000, RUB), c: USD, want: New(150000, USD)},
+		{m: New(150000, RUB), c: "JPY", want: New(1500, "JPY")},
+		{m: New(150050, RUB), c: "JPY", wantErr: ErrPrecision},
+		{m: New(1500, RUB), c: "KWD", want: New(15000, "KWD")},
+	}
+	for _, tt := range tests {
+		got, err := tt.m.In(tt.c)
+		if err != tt.wantErr || got != tt.want {
+			t.Errorf("%v.In(%s) = %+v, %v, want %+v, %v", tt.m, tt.c, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Price Money `json:"price"`
+	}
+	for _, in := range []string{`{"price":"1234.5"}`, `{"price":1234.5}`} {
+		v.Price = Money{}
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", in, err)
+		}
+		if v.Price != New(123450, RUB) {
+			t.Errorf("Unmarshal(%s) = %+v", in, v.Price)
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"price":"1234.50"}` {
+		t.Errorf("Marshal() = %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"price":"12.345"}`), &v); err != ErrPrecision {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrPrecision)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	if got, err := (Money{}).Add(New(100, USD)); err != nil || got != New(100, USD) {
+		t.Errorf("Add() = %+v, %v, want 1.00 USD", got, err)
+	}
+	if _, err := New(100, RUB).Add(New(100, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := New(100, RUB).Sub(New(100, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := New(100, RUB).Cmp(New(100, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := Sum(New(100, RUB), Money{}, New(100, USD)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
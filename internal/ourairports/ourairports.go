@@ -0,0 +1,110 @@
+// Файл internal\ourairports\ourairports.go содержит чтение аэропортов из CSV в формате открытой базы OurAirports
+package ourairports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrColumn = errors.New("no column in the header")
+
+var (
+	iataCode = regexp.MustCompile("^[A-Z]{3}$")
+	icaoCode = regexp.MustCompile("^[A-Z0-9]{4}$")
+)
+
+// columns are the columns read, the others are ignored. icao_code is missing in older files
+var columns = []string{"ident", "type", "name", "latitude_deg", "longitude_deg", "iso_country", "municipality", "iata_code", "gps_code"}
+
+// airportTypes are the types of the airports read. Heliports, seaplane bases, balloonports and closed airports
+// are skipped even with an IATA code
+var airportTypes = map[string]bool{"large_airport": true, "medium_airport": true, "small_airport": true}
+
+// Airport is an airport of the file with an IATA code. ICAOCode is empty for airports without it
+type Airport struct {
+	IATACode     string
+	ICAOCode     string
+	Type         string
+	Name         string
+	Country      string
+	Municipality string
+	Latitude     float64
+	Longitude    float64
+}
+
+// Parse reads the open airports with IATA codes from the airports.csv file of OurAirports. The columns are found
+// by the header, so the order of the columns does not matter
+func Parse(r io.Reader) ([]Airport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, c := range columns {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrColumn, c)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var airports []Airport
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return airports, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		iata := strings.ToUpper(field(record, "iata_code"))
+		if !iataCode.MatchString(iata) || !airportTypes[field(record, "type")] {
+			continue
+		}
+		lat, err := strconv.ParseFloat(field(record, "latitude_deg"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad latitude: %w", line, err)
+		}
+		lon, err := strconv.ParseFloat(field(record, "longitude_deg"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad longitude: %w", line, err)
+		}
+		airports = append(airports, Airport{
+			IATACode:     iata,
+			ICAOCode:     icaoOf(field(record, "icao_code"), field(record, "gps_code"), field(record, "ident")),
+			Type:         field(record, "type"),
+			Name:         field(record, "name"),
+			Country:      strings.ToUpper(field(record, "iso_country")),
+			Municipality: field(record, "municipality"),
+			Latitude:     lat,
+			Longitude:    lon,
+		})
+	}
+}
+
+// icaoOf returns the first of the codes that is an ICAO code. Older files have the ICAO code
+// in the GPS code or the identifier only
+func icaoOf(codes ...string) string {
+	for _, c := range codes {
+		if c = strings.ToUpper(c); icaoCode.MatchString(c) {
+			return c
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package ourairports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const airportsCSV = `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","continent","iso_country","iso_region","municipality","scheduled_service","icao_code","iata_code","gps_code","local_code","home_link","wikipedia_link","keywords"
+4365,"UUEE","large_airport","Sheremetyevo International Airport",55.972599,37.4146,622,"EU","RU","RU-MOS","Moscow","yes","UUEE","SVO","UUEE",,,"https://en.wikipedia.org/wiki/Sheremetyevo_International_Airport","Moscow"
+6523,"00A","heliport","Total RF Heliport",40.070985,-74.933689,11,"NA","US","US-PA","Bensalem","no",,,"K00A","00A",,,
+7701,"KJRB","heliport","Downtown Manhattan/Wall St Heliport",40.701222,-74.009028,7,"NA","US","US-NY","New York","no","KJRB","JRB","KJRB","JRB",,,
+3333,"UUMO","closed","Ostafyevo International Airport",55.511667,37.507222,568,"EU","RU","RU-MOS","Moscow","no",,"OSF","UUMO",,,,
+26412,"ULLI","large_airport","Pulkovo Airport",59.800301,30.262501,78,"EU","RU","RU-SPE","St. Petersburg","yes",,"led","ULLI",,,,
+`
+
+func TestParse(t *testing.T) {
+	airports, err := Parse(strings.NewReader(airportsCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(airports) != 2 {
+		t.Fatalf("Parse() = %d airports, want 2 without the heliport and the closed airport", len(airports))
+	}
+	svo := airports[0]
+	if svo.IATACode != "SVO" || svo.ICAOCode != "UUEE" || svo.Country != "RU" || svo.Municipality != "Moscow" ||
+		svo.Name != "Sheremetyevo International Airport" || svo.Latitude != 55.972599 || svo.Longitude != 37.4146 {
+		t.Errorf("Parse() = %+v", svo)
+	}
+	if led := airports[1]; led.IATACode != "LED" || led.ICAOCode != "ULLI" {
+		t.Errorf("Parse() of an airport without the ICAO code column = %+v", led)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("ident,name\nUUEE,Sheremetyevo\n")); !errors.Is(err, ErrColumn) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrColumn)
+	}
+	bad := strings.Replace(airportsCSV, "55.972599", "north", 1)
+	if _, err := Parse(strings.NewReader(bad)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Parse() error = %v, want a bad latitude on line 2", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Файл internal\overbooking\overbooking.go содержит расчёт разрешённой к продаже ёмкости класса рейса с учётом овербукинга
+package overbooking
+
+import (
+	"errors"
+	"math"
+)
+
+// MaxPercent is the largest overbooking allowed beyond the physical capacity of a class
+const MaxPercent = 50
+
+var (
+	ErrCapacityExceeded = errors.New("authorized capacity of the class is sold out")
+	ErrNoSeat           = errors.New("no free seat in the class, the flight is oversold")
+)
+
+// Limit is the overbooking set for a class of a flight. Authorized, if set, overrides the capacity
+// derived from the percentage
+type Limit struct {
+	Percent    float64
+	Authorized *int
+}
+
+// Load is the state of sales of a class of a flight. Infants without a seat are not counted.
+// Held are the seats offered to waitlisted passengers that may still be sold to them
+type Load struct {
+	Class     string
+	Physical  int
+	Limit     Limit
+	Seated    int
+	Seatless  int
+	Held      int
+	CheckedIn int
+	Boarded   int
+}
+
+// Authorized returns the number of passengers that may be sold in the class
+func (l *Load) Authorized() int {
+	if l.Limit.Authorized != nil {
+		return *l.Limit.Authorized
+	}
+	return int(math.Floor(float64(l.Physical) * (100 + l.Limit.Percent) / 100))
+}
+
+// Sold returns the number of passengers sold in the class with or without a seat
+func (l *Load) Sold() int {
+	return l.Seated + l.Seatless
+}
+
+// Available returns how many more passengers may be sold in the class besides those the seats are held for
+func (l *Load) Available() int {
+	if n := l.Authorized() - l.Sold() - l.Held; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Oversold returns how many passengers sold in the class have no physical seat
+func (l *Load) Oversold() int {
+	if n := l.Sold() - l.Physical; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// CanSell checks that one more passenger may be sold in the class
+func (l *Load) CanSell() error {
+	if l.Sold()+l.Held >= l.Authorized() {
+		return ErrCapacityExceeded
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package overbooking
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	ten := 10
+
+	tests := []struct {
+		name         string
+		load         Load
+		wantAuth     int
+		wantOversold int
+		wantErr      error
+	}{
+		{name: "no overbooking", load: Load{Physical: 100, Seated: 99}, wantAuth: 100},
+		{name: "sold out", load: Load{Physical: 100, Seated: 100}, wantAuth: 100, wantErr: ErrCapacityExceeded},
+		{name: "percent", load: Load{Physical: 100, Limit: Limit{Percent: 5}, Seated: 100, Seatless: 4}, wantAuth: 105, wantOversold: 4},
+		{name: "percent rounds down", load: Load{Physical: 30, Limit: Limit{Percent: 5}, Seated: 30, Seatless: 1}, wantAuth: 31, wantOversold: 1, wantErr: ErrCapacityExceeded},
+		{name: "authorized", load: Load{Physical: 8, Limit: Limit{Percent: 50, Authorized: &ten}, Seated: 8, Seatless: 1}, wantAuth: 10, wantOversold: 1},
+		{name: "held", load: Load{Physical: 10, Seated: 9, Held: 1}, wantAuth: 10, wantErr: ErrCapacityExceeded},
+		{name: "authorized below physical", load: Load{Physical: 20, Limit: Limit{Authorized: &ten}, Seated: 10}, wantAuth: 10, wantErr: ErrCapacityExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.load.Authorized(); got != tt.wantAuth {
+				t.Errorf("Authorized() = %d, want %d", got, tt.wantAuth)
+			}
+			if got := tt.load.Oversold(); got != tt.wantOversold {
+				t.Errorf("Oversold() = %d, want %d", got, tt.wantOversold)
+			}
+			if err := tt.load.CanSell(); err != tt.wantErr {
+				t.Errorf("CanSell() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
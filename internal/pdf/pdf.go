@@ -0,0 +1,136 @@
+// Файл internal\pdf\pdf.go содержит формирование простых PDF документов из строк моноширинного текста
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 landscape in points with the text in Courier, so that columns padded with spaces line up
+const (
+	pageWidth  = 842
+	pageHeight = 595
+	margin     = 36
+	fontSize   = 9
+	leading    = 11
+	// LineWidth is the number of characters that fit in a line
+	LineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+)
+
+const linesPerPage = (pageHeight - 2*margin) / leading
+
+// Document is a text document. Header lines are repeated at the top of every page.
+// Only ASCII is supported by the standard fonts, other characters are printed as "?"
+type Document struct {
+	Title  string
+	Header []string
+	Lines  []string
+}
+
+// escape makes the text a PDF string literal
+func escape(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c < 32 || c > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func (d *Document) pages() [][]string {
+	perPage := linesPerPage - len(d.Header)
+	if perPage < 1 {
+		perPage = 1
+	}
+	var pages [][]string
+	for start := 0; start < len(d.Lines) || start == 0; start += perPage {
+		end := start + perPage
+		if end > len(d.Lines) {
+			end = len(d.Lines)
+		}
+		page := append(append([]string(nil), d.Header...), d.Lines[start:end]...)
+		pages = append(pages, page)
+		if end == len(d.Lines) {
+			break
+		}
+	}
+	return pages
+}
+
+func content(lines []string, page, pages int) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin-fontSize)
+	for _, l := range lines {
+		fmt.Fprintf(&b, "%s '\n", escape(l))
+	}
+	b.WriteString("ET\n")
+	footer := fmt.Sprintf("%d / %d", page, pages)
+	fmt.Fprintf(&b, "BT\n/F1 %d Tf\n%d %d Td\n%s Tj\nET\n", fontSize, pageWidth-margin-len(footer)*fontSize*6/10, margin/2, escape(footer))
+	return b.Bytes()
+}
+
+// WriteTo writes the document in the PDF format
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.pages()
+	// 1 is the catalog, 2 the page tree, 3 the font, 4 the info, then a page and its content for every page
+	objects := make([][]byte, 4+2*len(pages))
+	kids := make([]string, len(pages))
+	for i, lines := range pages {
+		pageID, contentID := 5+2*i, 6+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+		objects[pageID-1] = []byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, contentID))
+		stream := content(lines, i+1, len(pages))
+		objects[contentID-1] = []byte(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	objects[0] = []byte("<< /Type /Catalog /Pages 2 0 R >>")
+	objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects[2] = []byte("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	objects[3] = []byte(fmt.Sprintf("<< /Title %s /Producer (aviasales) >>", escape(d.Title)))
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	fmt.Fprint(cw, "%PDF-1.4\n")
+	offsets := make([]int64, len(objects))
+	for i, o := range objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
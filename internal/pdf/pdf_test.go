@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	d := &Document{Title: "Manifest (SU100)", Header: []string{"SEAT NAME"}}
+	for i := 0; i < linesPerPage*2; i++ {
+		d.Lines = append(d.Lines, fmt.Sprintf("%dA IVANOV", i))
+	}
+	var b bytes.Buffer
+	if _, err := d.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := b.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("WriteTo() is not a PDF file")
+	}
+	if got := strings.Count(out, "/Type /Page "); got != 3 {
+		t.Errorf("WriteTo() wrote %d pages, want 3", got)
+	}
+	if !strings.Contains(out, `(Manifest \(SU100\))`) {
+		t.Errorf("WriteTo() did not escape the title")
+	}
+
+	// Every offset in the cross-reference table points at its object
+	xref := out[strings.LastIndex(out, "xref\n"):]
+	for i, line := range strings.Split(xref, "\n")[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		var off int
+		fmt.Sscanf(line, "%d", &off)
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(out[off:], want) {
+			t.Errorf("object %d is not at offset %d", i+1, off)
+		}
+	}
+}
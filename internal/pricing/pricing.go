@@ -0,0 +1,133 @@
+// Файл internal\pricing\pricing.go содержит расчет стоимости полёта в билете
+package pricing
+
+import "github.com/akionka/aviasales/internal/money"
+
+type PassengerType string
+
+// Passenger type codes as used in fares. The type depends on the age of the passenger on the day of the flight
+const (
+	Adult  PassengerType = "ADT"
+	Child  PassengerType = "CHD"
+	Infant PassengerType = "INF"
+)
+
+const (
+	ChildMinAge = 2
+	AdultMinAge = 12
+)
+
+const (
+	hotDiscount               = 0.5
+	infantWithoutSeatDiscount = 0.9
+)
+
+var classMultipliers = map[string]float64{
+	"J": 2,
+	"Y": 1.5,
+	"W": 1,
+}
+
+var passengerDiscounts = map[PassengerType]float64{
+	Adult:  0,
+	Child:  0.25,
+	Infant: 0.25,
+}
+
+// PassengerTypeOf returns the type of the passenger of the given age
+func PassengerTypeOf(age int) PassengerType {
+	switch {
+	case age >= AdultMinAge:
+		return Adult
+	case age >= ChildMinAge:
+		return Child
+	}
+	return Infant
+}
+
+// Fare returns the price of a flight in the class for the passenger. Infants without a seat fly in the class
+// of the accompanying adult
+func Fare(basePrice money.Money, class string, isHot bool, passenger PassengerType, withSeat bool) money.Money {
+	return BucketFare(basePrice, classMultipliers[class], isHot, passenger, withSeat)
+}
+
+// BucketFare returns the price of a flight sold in a booking class with the fare multiplier for the passenger.
+// The multiplier and the discounts are applied at once, so the fare is rounded only once, halves up
+func BucketFare(basePrice money.Money, multiplier float64, isHot bool, passenger PassengerType, withSeat bool) money.Money {
+	factor := multiplier
+	if isHot {
+		factor *= 1 - hotDiscount
+	}
+	if passenger == Infant && !withSeat {
+		factor *= 1 - infantWithoutSeatDiscount
+	} else {
+		factor *= 1 - passengerDiscounts[passenger]
+	}
+	return basePrice.Mul(factor, money.HalfUp)
+}
+
+// Tax is a tax levied by an airport on a flight
+type Tax struct {
+	Code    string
+	Name    string
+	Airport string
+	Amount  money.Money
+}
+
+// Breakdown is the price of a ticket itemised into the fares of its flights, the taxes of the airports,
+// the fuel surcharges of the flights and the service fee of the booking office. Amounts are exact,
+// so the total is exactly the sum of the items
+type Breakdown struct {
+	BaseFare      money.Money
+	Taxes         []Tax
+	FuelSurcharge money.Money
+	ServiceFee    money.Money
+}
+
+// AddFlight adds the fare of a flight with its taxes and fuel surcharge. Taxes of the same code levied
+// by the same airport are summed up. All the amounts must be in the currency of the breakdown
+func (b *Breakdown) AddFlight(fare money.Money, taxes []Tax, fuelSurcharge money.Money) error {
+	baseFare, err := b.BaseFare.Add(fare)
+	if err != nil {
+		return err
+	}
+	fuel, err := b.FuelSurcharge.Add(fuelSurcharge)
+	if err != nil {
+		return err
+	}
+	b.BaseFare, b.FuelSurcharge = baseFare, fuel
+	for _, t := range taxes {
+		found := false
+		for i := range b.Taxes {
+			if b.Taxes[i].Code == t.Code && b.Taxes[i].Airport == t.Airport {
+				if b.Taxes[i].Amount, err = b.Taxes[i].Amount.Add(t.Amount); err != nil {
+					return err
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.Taxes = append(b.Taxes, t)
+		}
+	}
+	return nil
+}
+
+// TaxTotal returns the sum of the taxes
+func (b *Breakdown) TaxTotal() (money.Money, error) {
+	amounts := make([]money.Money, len(b.Taxes))
+	for i, t := range b.Taxes {
+		amounts[i] = t.Amount
+	}
+	return money.Sum(amounts...)
+}
+
+// Total returns the price of the ticket
+func (b *Breakdown) Total() (money.Money, error) {
+	taxes, err := b.TaxTotal()
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.Sum(b.BaseFare, taxes, b.FuelSurcharge, b.ServiceFee)
+}
@@ -0,0 +1,85 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+func rub(s string) money.Money {
+	m, err := money.Parse(s, money.RUB)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestFare(t *testing.T) {
+	tests := []struct {
+		name      string
+		class     string
+		isHot     bool
+		passenger PassengerType
+		withSeat  bool
+		want      string
+	}{
+		{name: "adult business", class: "J", passenger: Adult, withSeat: true, want: "2000"},
+		{name: "adult hot economy", class: "Y", isHot: true, passenger: Adult, withSeat: true, want: "750"},
+		{name: "child", class: "W", passenger: Child, withSeat: true, want: "750"},
+		{name: "infant with seat", class: "W", passenger: Infant, withSeat: true, want: "750"},
+		{name: "infant without seat", class: "J", passenger: Infant, want: "200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fare(rub("1000"), tt.class, tt.isHot, tt.passenger, tt.withSeat); got != rub(tt.want) {
+				t.Errorf("Fare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPassengerTypeOf(t *testing.T) {
+	for age, want := range map[int]PassengerType{0: Infant, 1: Infant, 2: Child, 11: Child, 12: Adult, 40: Adult} {
+		if got := PassengerTypeOf(age); got != want {
+			t.Errorf("PassengerTypeOf(%d) = %v, want %v", age, got, want)
+		}
+	}
+}
+
+func TestBucketFareRounding(t *testing.T) {
+	// 333.33 * 1.5 * 0.75 = 374.99625, rounded once rather than after every discount
+	if got := BucketFare(rub("333.33"), 1.5, false, Child, true); got != rub("375") {
+		t.Errorf("BucketFare() = %v, want 375.00", got)
+	}
+	// 0.01 * 0.5 = 0.005, a half rounded up
+	if got := BucketFare(rub("0.01"), 1, true, Adult, true); got != rub("0.01") {
+		t.Errorf("BucketFare() = %v, want 0.01", got)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	b := Breakdown{ServiceFee: rub("150")}
+	if err := b.AddFlight(rub("1000.01"), []Tax{{Code: "RU", Airport: "SVO", Amount: rub("300.1")}, {Code: "ZZ", Airport: "LED", Amount: rub("0.2")}}, rub("450")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddFlight(rub("999.99"), []Tax{{Code: "RU", Airport: "SVO", Amount: rub("300.1")}}, rub("450")); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.BaseFare != rub("2000") {
+		t.Errorf("BaseFare = %v, want 2000", b.BaseFare)
+	}
+	if len(b.Taxes) != 2 || b.Taxes[0].Amount != rub("600.2") {
+		t.Errorf("Taxes = %v, want RU SVO 600.2 and ZZ LED 0.2", b.Taxes)
+	}
+	if got, err := b.TaxTotal(); err != nil || got != rub("600.4") {
+		t.Errorf("TaxTotal() = %v, %v, want 600.4", got, err)
+	}
+	if got, err := b.Total(); err != nil || got != rub("3650.4") {
+		t.Errorf("Total() = %v, %v, want 3650.4", got, err)
+	}
+	if err := b.AddFlight(money.New(100000, money.USD), nil, rub("450")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("AddFlight() error = %v, want %v", err, money.ErrCurrencyMismatch)
+	}
+}
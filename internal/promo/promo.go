@@ -0,0 +1,115 @@
+// Файл internal\promo\promo.go содержит промокоды акций: процентные и фиксированные скидки, срок действия, допустимые линии и классы и лимиты использований
+package promo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+var (
+	ErrNotStarted     = errors.New("the campaign has not started yet")
+	ErrExpired        = errors.New("the campaign is over")
+	ErrNoFlights      = errors.New("the code is valid on some lines or classes only, the flights are needed to check it")
+	ErrLine           = errors.New("the code is not valid on the line")
+	ErrClass          = errors.New("the code is not valid in the class")
+	ErrUsedUp         = errors.New("the code is used up")
+	ErrCustomerUsedUp = errors.New("the customer has used the code as many times as allowed")
+)
+
+type Kind string
+
+const (
+	Percent Kind = "percent"
+	Fixed   Kind = "fixed"
+)
+
+// Campaign is a discount campaign of a promo code valid from ValidFrom until ValidTo. Percent is the discount
+// of the percent kind, Amount the one of the fixed kind. Empty Lines and Classes allow any, nil limits of uses
+// do not restrict them
+type Campaign struct {
+	Code               string
+	Kind               Kind
+	Percent            float64
+	Amount             money.Money
+	ValidFrom          time.Time
+	ValidTo            time.Time
+	Lines              []string
+	Classes            []string
+	MaxUses            *int
+	MaxUsesPerCustomer *int
+}
+
+// Flight is a flight of the purchase the code is used for
+type Flight struct {
+	LineCode string
+	Class    string
+}
+
+// Usage is the count of the uses of the code before, in total and by the customer
+type Usage struct {
+	Total    int
+	Customer int
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Check checks that the code can be used at the time by a customer with the usage for a purchase of the flights.
+// A code restricted to lines or classes is valid only if every flight is on them
+func Check(c Campaign, at time.Time, flights []Flight, u Usage) error {
+	if at.Before(c.ValidFrom) {
+		return ErrNotStarted
+	}
+	if !at.Before(c.ValidTo) {
+		return ErrExpired
+	}
+	if (len(c.Lines) > 0 || len(c.Classes) > 0) && len(flights) == 0 {
+		return ErrNoFlights
+	}
+	for _, f := range flights {
+		if len(c.Lines) > 0 && !contains(c.Lines, f.LineCode) {
+			return ErrLine
+		}
+		if len(c.Classes) > 0 && !contains(c.Classes, f.Class) {
+			return ErrClass
+		}
+	}
+	if c.MaxUses != nil && u.Total >= *c.MaxUses {
+		return ErrUsedUp
+	}
+	if c.MaxUsesPerCustomer != nil && u.Customer >= *c.MaxUsesPerCustomer {
+		return ErrCustomerUsedUp
+	}
+	return nil
+}
+
+// Discount returns the discount of the campaign off the total, rounded halves up. The fixed amount must be
+// in the currency of the total. The discount never exceeds the total
+func Discount(c Campaign, total money.Money) (money.Money, error) {
+	var d money.Money
+	switch c.Kind {
+	case Percent:
+		d = total.Mul(c.Percent/100, money.HalfUp)
+	case Fixed:
+		d = c.Amount
+	}
+	if d.IsNegative() {
+		return money.New(0, total.Currency), nil
+	}
+	above, err := d.Cmp(total)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if above > 0 {
+		return total, nil
+	}
+	return d, nil
+}
@@ -0,0 +1,81 @@
+package promo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+)
+
+func rub(rubles int64) money.Money {
+	return money.New(rubles*100, money.RUB)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCheck(t *testing.T) {
+	from := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	campaign := Campaign{
+		Code:               "SUMMER",
+		ValidFrom:          from,
+		ValidTo:            to,
+		Lines:              []string{"SU100", "SU101"},
+		Classes:            []string{"Y"},
+		MaxUses:            intPtr(10),
+		MaxUsesPerCustomer: intPtr(1),
+	}
+	eligible := []Flight{{LineCode: "SU100", Class: "Y"}, {LineCode: "SU101", Class: "Y"}}
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		flights []Flight
+		usage   Usage
+		wantErr error
+	}{
+		{name: "valid", at: from, flights: eligible},
+		{name: "not started", at: from.Add(-time.Second), flights: eligible, wantErr: ErrNotStarted},
+		{name: "expired", at: to, flights: eligible, wantErr: ErrExpired},
+		{name: "no flights", at: from, wantErr: ErrNoFlights},
+		{name: "other line", at: from, flights: []Flight{{LineCode: "SU100", Class: "Y"}, {LineCode: "SU200", Class: "Y"}}, wantErr: ErrLine},
+		{name: "other class", at: from, flights: []Flight{{LineCode: "SU100", Class: "J"}}, wantErr: ErrClass},
+		{name: "used up", at: from, flights: eligible, usage: Usage{Total: 10}, wantErr: ErrUsedUp},
+		{name: "used by the customer", at: from, flights: eligible, usage: Usage{Total: 3, Customer: 1}, wantErr: ErrCustomerUsedUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(campaign, tt.at, tt.flights, tt.usage); err != tt.wantErr {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := Check(Campaign{ValidFrom: from, ValidTo: to}, from, nil, Usage{Total: 100, Customer: 100}); err != nil {
+		t.Errorf("Check() of an unrestricted code error = %v", err)
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign Campaign
+		total    money.Money
+		want     money.Money
+	}{
+		{name: "percent", campaign: Campaign{Kind: Percent, Percent: 10}, total: rub(1500), want: rub(150)},
+		{name: "percent rounded", campaign: Campaign{Kind: Percent, Percent: 15}, total: money.New(1003, money.RUB), want: money.New(150, money.RUB)},
+		{name: "fixed", campaign: Campaign{Kind: Fixed, Amount: rub(500)}, total: rub(1500), want: rub(500)},
+		{name: "fixed above the total", campaign: Campaign{Kind: Fixed, Amount: rub(2000)}, total: rub(1500), want: rub(1500)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Discount(tt.campaign, tt.total)
+			if err != nil || got != tt.want {
+				t.Errorf("Discount() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Файл internal\reprotect\reprotect.go содержит подбор альтернативных рейсов для пассажиров отменённого рейса
+package reprotect
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akionka/aviasales/internal/reseat"
+)
+
+// MinConnection is the shortest time between an arrival and the next departure of a passenger
+const MinConnection = 45 * time.Minute
+
+// Class ranks, passengers of higher classes are placed first
+var classRank = map[string]int{"J": 0, "W": 1, "Y": 2}
+
+type Seat struct {
+	ID     int
+	Number string
+	Class  string
+}
+
+// Leg is an alternative flight with its free seats
+type Leg struct {
+	FlightID   int
+	LineCode   string
+	DepAirport string
+	ArrAirport string
+	Departure  time.Time
+	Arrival    time.Time
+	Free       []Seat
+}
+
+// Passenger is a segment of the cancelled flight. NotBefore and NotAfter bound the departure of the first leg
+// and the arrival of the last leg to keep the connections of the ticket, zero times do not bound anything.
+// An infant without a seat has EscortTicketID and flies on the same flights as the escort
+type Passenger struct {
+	SegmentID      int
+	TicketID       int
+	Class          string
+	Seated         bool
+	EscortTicketID int
+	NotBefore      time.Time
+	NotAfter       time.Time
+}
+
+// Assignment is a leg of the new itinerary of a passenger with the seat taken, nil for an infant without a seat
+type Assignment struct {
+	Leg  Leg
+	Seat *Seat
+}
+
+type Placement struct {
+	Passenger Passenger
+	Legs      []Assignment
+}
+
+type Result struct {
+	Placements []Placement
+	Unplaced   []Passenger
+}
+
+// Itineraries returns the direct flights and the connections of two flights from one airport to another,
+// the earliest arrival first and direct flights before connections arriving at the same time
+func Itineraries(from, to string, legs []Leg) [][]int {
+	var itineraries [][]int
+	for i, first := range legs {
+		if first.DepAirport != from {
+			continue
+		}
+		if first.ArrAirport == to {
+			itineraries = append(itineraries, []int{i})
+			continue
+		}
+		for j, second := range legs {
+			if second.DepAirport == first.ArrAirport && second.ArrAirport == to && !second.Departure.Before(first.Arrival.Add(MinConnection)) {
+				itineraries = append(itineraries, []int{i, j})
+			}
+		}
+	}
+	sort.SliceStable(itineraries, func(i, j int) bool {
+		a, b := legs[itineraries[i][len(itineraries[i])-1]], legs[itineraries[j][len(itineraries[j])-1]]
+		if !a.Arrival.Equal(b.Arrival) {
+			return a.Arrival.Before(b.Arrival)
+		}
+		return len(itineraries[i]) < len(itineraries[j])
+	})
+	return itineraries
+}
+
+// Plan places the passengers of a cancelled flight from one airport to another on the earliest itineraries
+// that have a free seat in their class on every leg and keep their connections
+func Plan(from, to string, passengers []Passenger, legs []Leg) Result {
+	free := make([]map[string][]Seat, len(legs))
+	for i, l := range legs {
+		free[i] = make(map[string][]Seat)
+		for _, s := range l.Free {
+			free[i][s.Class] = append(free[i][s.Class], s)
+		}
+		for c := range free[i] {
+			seats := free[i][c]
+			sort.SliceStable(seats, func(a, b int) bool {
+				return reseat.Less(seats[a].Number, seats[b].Number)
+			})
+		}
+	}
+	itineraries := Itineraries(from, to, legs)
+
+	passengers = append([]Passenger(nil), passengers...)
+	sort.SliceStable(passengers, func(i, j int) bool {
+		if passengers[i].Seated != passengers[j].Seated {
+			return passengers[i].Seated
+		}
+		if classRank[passengers[i].Class] != classRank[passengers[j].Class] {
+			return classRank[passengers[i].Class] < classRank[passengers[j].Class]
+		}
+		return passengers[i].SegmentID < passengers[j].SegmentID
+	})
+
+	fits := func(p Passenger, itinerary []int) bool {
+		first, last := legs[itinerary[0]], legs[itinerary[len(itinerary)-1]]
+		if !p.NotBefore.IsZero() && first.Departure.Before(p.NotBefore) {
+			return false
+		}
+		if !p.NotAfter.IsZero() && last.Arrival.After(p.NotAfter) {
+			return false
+		}
+		for _, i := range itinerary {
+			if len(free[i][p.Class]) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var result Result
+	placed := make(map[int][]int)
+	for _, p := range passengers {
+		if !p.Seated {
+			itinerary, ok := placed[p.EscortTicketID]
+			if !ok {
+				result.Unplaced = append(result.Unplaced, p)
+				continue
+			}
+			placement := Placement{Passenger: p}
+			for _, i := range itinerary {
+				placement.Legs = append(placement.Legs, Assignment{Leg: legs[i]})
+			}
+			result.Placements = append(result.Placements, placement)
+			continue
+		}
+
+		found := false
+		for _, itinerary := range itineraries {
+			if !fits(p, itinerary) {
+				continue
+			}
+			placement := Placement{Passenger: p}
+			for _, i := range itinerary {
+				seat := free[i][p.Class][0]
+				free[i][p.Class] = free[i][p.Class][1:]
+				placement.Legs = append(placement.Legs, Assignment{Leg: legs[i], Seat: &seat})
+			}
+			result.Placements = append(result.Placements, placement)
+			placed[p.TicketID] = itinerary
+			found = true
+			break
+		}
+		if !found {
+			result.Unplaced = append(result.Unplaced, p)
+		}
+	}
+	return result
+}
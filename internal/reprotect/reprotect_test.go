@@ -0,0 +1,53 @@
+package reprotect
+
+import (
+	"testing"
+	"time"
+)
+
+func at(h int) time.Time {
+	return time.Date(2024, 5, 1, h, 0, 0, 0, time.UTC)
+}
+
+func TestPlan(t *testing.T) {
+	legs := []Leg{
+		// Direct in the evening with one economy seat
+		{FlightID: 1, DepAirport: "SVO", ArrAirport: "AER", Departure: at(18), Arrival: at(20), Free: []Seat{{ID: 11, Number: "20A", Class: "Y"}}},
+		// Connection through KZN arriving earlier, business only on the first leg
+		{FlightID: 2, DepAirport: "SVO", ArrAirport: "KZN", Departure: at(10), Arrival: at(11), Free: []Seat{{ID: 21, Number: "1A", Class: "J"}, {ID: 22, Number: "15C", Class: "Y"}}},
+		{FlightID: 3, DepAirport: "KZN", ArrAirport: "AER", Departure: at(12), Arrival: at(15), Free: []Seat{{ID: 31, Number: "12A", Class: "Y"}}},
+		// Too short a connection
+		{FlightID: 4, DepAirport: "KZN", ArrAirport: "AER", Departure: at(11), Arrival: at(14), Free: []Seat{{ID: 41, Number: "1A", Class: "J"}}},
+	}
+	passengers := []Passenger{
+		{SegmentID: 1, TicketID: 100, Class: "Y", Seated: true},
+		{SegmentID: 2, TicketID: 101, Class: "Y", Seated: true},
+		{SegmentID: 3, TicketID: 102, Class: "Y", Seated: false, EscortTicketID: 100},
+		{SegmentID: 4, TicketID: 103, Class: "J", Seated: true},
+		{SegmentID: 5, TicketID: 104, Class: "Y", Seated: true, NotAfter: at(17)},
+	}
+	got := Plan("SVO", "AER", passengers, legs)
+
+	flights := make(map[int][]int)
+	for _, p := range got.Placements {
+		for _, l := range p.Legs {
+			flights[p.Passenger.SegmentID] = append(flights[p.Passenger.SegmentID], l.Leg.FlightID)
+		}
+	}
+	if f := flights[1]; len(f) != 2 || f[0] != 2 || f[1] != 3 {
+		t.Errorf("segment 1 flights = %v, want the connection [2 3]", f)
+	}
+	if f := flights[2]; len(f) != 1 || f[0] != 1 {
+		t.Errorf("segment 2 flights = %v, want the direct flight [1]", f)
+	}
+	if f := flights[3]; len(f) != 2 || f[0] != 2 {
+		t.Errorf("infant flights = %v, want the escort's [2 3]", f)
+	}
+	unplaced := make(map[int]bool)
+	for _, p := range got.Unplaced {
+		unplaced[p.SegmentID] = true
+	}
+	if !unplaced[4] || !unplaced[5] || len(unplaced) != 2 {
+		t.Errorf("unplaced = %v, want segments 4 and 5", unplaced)
+	}
+}
@@ -0,0 +1,143 @@
+// Файл internal\reseat\reseat.go содержит пересадку пассажиров на места другого самолёта при замене борта
+package reseat
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Classes from the highest to the lowest
+var classes = []string{"J", "W", "Y"}
+
+// fallbacks lists the classes a passenger is moved to if there is no seat left in their class,
+// the nearest class first and an upgrade before a downgrade
+var fallbacks = map[string][]string{
+	"J": {"W", "Y"},
+	"W": {"J", "Y"},
+	"Y": {"W", "J"},
+}
+
+type Seat struct {
+	ID     int
+	Number string
+	Class  string
+}
+
+// Sold is a seat taken by a segment of a ticket
+type Sold struct {
+	SegmentID int
+	Seat      Seat
+}
+
+// Placement is a segment moved to a seat of the new aircraft, possibly in another class
+type Placement struct {
+	SegmentID int
+	From      Seat
+	To        Seat
+}
+
+// ClassChanged reports whether the passenger was upgraded or downgraded
+func (p Placement) ClassChanged() bool {
+	return p.From.Class != p.To.Class
+}
+
+type Result struct {
+	Placements []Placement
+	Unplaced   []Sold
+}
+
+// ParseNumber splits a seat number like "12A" into the row and the letter. Rows of numbers that do not start
+// with a row are zero
+func ParseNumber(number string) (row int, letter string) {
+	i := strings.IndexFunc(number, func(r rune) bool { return r < '0' || r > '9' })
+	if i == -1 {
+		i = len(number)
+	}
+	row, _ = strconv.Atoi(number[:i])
+	return row, number[i:]
+}
+
+// Less orders seats from the front of the cabin to the back and from left to right
+func Less(a, b string) bool {
+	ra, la := ParseNumber(a)
+	rb, lb := ParseNumber(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return la < lb
+}
+
+// Plan moves the sold seats onto the seats of the new aircraft. A passenger keeps the seat with the same number
+// if it is in the same class, otherwise takes the frontmost free seat of the class keeping the order of the passengers.
+// Passengers who do not fit into their cabin are moved to the nearest class with free seats,
+// those who do not fit into the aircraft at all are returned as unplaced
+func Plan(sold []Sold, seats []Seat) Result {
+	sold = append([]Sold(nil), sold...)
+	sort.SliceStable(sold, func(i, j int) bool {
+		return Less(sold[i].Seat.Number, sold[j].Seat.Number)
+	})
+
+	byNumber := make(map[string]Seat, len(seats))
+	free := make(map[string][]Seat)
+	for _, s := range seats {
+		byNumber[s.Number] = s
+		free[s.Class] = append(free[s.Class], s)
+	}
+	taken := make(map[int]bool)
+	for _, c := range classes {
+		sort.SliceStable(free[c], func(i, j int) bool {
+			return Less(free[c][i].Number, free[c][j].Number)
+		})
+	}
+	take := func(class string) (Seat, bool) {
+		for _, s := range free[class] {
+			if !taken[s.ID] {
+				taken[s.ID] = true
+				return s, true
+			}
+		}
+		return Seat{}, false
+	}
+
+	var result Result
+	placed := make(map[int]bool)
+	place := func(s Sold, to Seat) {
+		placed[s.SegmentID] = true
+		result.Placements = append(result.Placements, Placement{SegmentID: s.SegmentID, From: s.Seat, To: to})
+	}
+
+	for _, s := range sold {
+		if to, ok := byNumber[s.Seat.Number]; ok && to.Class == s.Seat.Class && !taken[to.ID] {
+			taken[to.ID] = true
+			place(s, to)
+		}
+	}
+	for _, s := range sold {
+		if placed[s.SegmentID] {
+			continue
+		}
+		if to, ok := take(s.Seat.Class); ok {
+			place(s, to)
+		}
+	}
+	// Higher classes are moved first so that a business passenger is not left without a seat
+	// because economy passengers took the comfort cabin
+	for _, c := range classes {
+		for _, s := range sold {
+			if placed[s.SegmentID] || s.Seat.Class != c {
+				continue
+			}
+			for _, fallback := range fallbacks[c] {
+				if to, ok := take(fallback); ok {
+					place(s, to)
+					break
+				}
+			}
+			if !placed[s.SegmentID] {
+				result.Unplaced = append(result.Unplaced, s)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package reseat
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		number     string
+		wantRow    int
+		wantLetter string
+	}{
+		{"12A", 12, "A"},
+		{"1C", 1, "C"},
+		{"7", 7, ""},
+		{"A", 0, "A"},
+	}
+	for _, tt := range tests {
+		row, letter := ParseNumber(tt.number)
+		if row != tt.wantRow || letter != tt.wantLetter {
+			t.Errorf("ParseNumber(%q) = %d, %q, want %d, %q", tt.number, row, letter, tt.wantRow, tt.wantLetter)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	sold := []Sold{
+		{SegmentID: 1, Seat: Seat{ID: 1, Number: "1A", Class: "J"}},
+		{SegmentID: 2, Seat: Seat{ID: 2, Number: "1C", Class: "J"}},
+		{SegmentID: 3, Seat: Seat{ID: 3, Number: "10A", Class: "Y"}},
+		{SegmentID: 4, Seat: Seat{ID: 4, Number: "12F", Class: "Y"}},
+		{SegmentID: 5, Seat: Seat{ID: 5, Number: "11B", Class: "Y"}},
+	}
+	seats := []Seat{
+		{ID: 101, Number: "1A", Class: "J"},
+		{ID: 102, Number: "5A", Class: "W"},
+		{ID: 103, Number: "10A", Class: "Y"},
+		{ID: 104, Number: "10B", Class: "Y"},
+	}
+	got := Plan(sold, seats)
+
+	want := map[int]int{1: 101, 2: 102, 3: 103, 5: 104}
+	if len(got.Placements) != len(want) {
+		t.Fatalf("Plan() placements = %+v", got.Placements)
+	}
+	for _, p := range got.Placements {
+		if want[p.SegmentID] != p.To.ID {
+			t.Errorf("Plan() segment %d to seat %d, want %d", p.SegmentID, p.To.ID, want[p.SegmentID])
+		}
+		if p.SegmentID == 2 && !p.ClassChanged() {
+			t.Errorf("Plan() segment 2 class is not changed")
+		}
+	}
+	if len(got.Unplaced) != 1 || got.Unplaced[0].SegmentID != 4 {
+		t.Errorf("Plan() unplaced = %+v, want segment 4", got.Unplaced)
+	}
+}
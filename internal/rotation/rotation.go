@@ -0,0 +1,125 @@
+// Файл internal\rotation\rotation.go содержит построение ротации самолёта (последовательности его рейсов) и поиск конфликтов в ней
+package rotation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Leg is a flight of an aircraft
+type Leg struct {
+	FlightID   int
+	LineCode   string
+	DepAirport string
+	ArrAirport string
+	Departure  time.Time
+	Arrival    time.Time
+}
+
+type ConflictKind string
+
+const (
+	// Overlap means the aircraft departs before it arrives from the previous flight
+	Overlap ConflictKind = "overlap"
+	// Positioning means the aircraft departs from an airport other than the one it arrived at
+	Positioning ConflictKind = "positioning"
+)
+
+// ConflictError describes two consecutive legs that the aircraft cannot fly
+type ConflictError struct {
+	Kind     ConflictKind
+	Previous Leg
+	Next     Leg
+}
+
+func (e *ConflictError) Error() string {
+	if e.Kind == Overlap {
+		return fmt.Sprintf("flight %d departs at %s before flight %d arrives at %s",
+			e.Next.FlightID, e.Next.Departure.UTC().Format(time.RFC3339), e.Previous.FlightID, e.Previous.Arrival.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("flight %d departs from %s while flight %d arrives at %s",
+		e.Next.FlightID, e.Next.DepAirport, e.Previous.FlightID, e.Previous.ArrAirport)
+}
+
+// Turn is a leg of the rotation along with the ground time before the next leg.
+// Conflict is set if the aircraft cannot fly the next leg after this one
+type Turn struct {
+	Leg
+	HasNext    bool
+	GroundTime time.Duration
+	Conflict   *ConflictError
+}
+
+// Sort orders the legs by departure
+func Sort(legs []Leg) {
+	sort.SliceStable(legs, func(i, j int) bool {
+		return legs[i].Departure.Before(legs[j].Departure)
+	})
+}
+
+func check(prev, next Leg) *ConflictError {
+	if next.Departure.Before(prev.Arrival) {
+		return &ConflictError{Kind: Overlap, Previous: prev, Next: next}
+	}
+	if next.DepAirport != prev.ArrAirport {
+		return &ConflictError{Kind: Positioning, Previous: prev, Next: next}
+	}
+	return nil
+}
+
+// Timeline sorts the legs and returns the rotation they make up
+func Timeline(legs []Leg) []Turn {
+	Sort(legs)
+	turns := make([]Turn, len(legs))
+	for i, leg := range legs {
+		turns[i].Leg = leg
+		if i+1 < len(legs) {
+			turns[i].HasNext = true
+			turns[i].GroundTime = legs[i+1].Departure.Sub(leg.Arrival)
+			turns[i].Conflict = check(leg, legs[i+1])
+		}
+	}
+	return turns
+}
+
+// Check puts the leg into the rotation made up of the other legs, replacing the leg of the same flight if there is one,
+// and returns the conflict with the neighbouring legs if any. Conflicts between other legs are ignored.
+// A leg of a flight that is not stored yet has zero FlightID
+func Check(legs []Leg, leg Leg) error {
+	rotation := make([]Leg, 0, len(legs)+1)
+	for _, l := range legs {
+		if l.FlightID != leg.FlightID {
+			rotation = append(rotation, l)
+		}
+	}
+	rotation = append(rotation, leg)
+	Sort(rotation)
+
+	for i, l := range rotation {
+		if l.FlightID != leg.FlightID {
+			continue
+		}
+		// Any leg overlapping the new one is a conflict, not only the neighbouring ones
+		for _, other := range rotation {
+			if other.FlightID != leg.FlightID && other.Departure.Before(leg.Arrival) && leg.Departure.Before(other.Arrival) {
+				if other.Departure.Before(leg.Departure) {
+					return &ConflictError{Kind: Overlap, Previous: other, Next: leg}
+				}
+				return &ConflictError{Kind: Overlap, Previous: leg, Next: other}
+			}
+		}
+		if i > 0 {
+			if c := check(rotation[i-1], leg); c != nil {
+				return c
+			}
+		}
+		if i+1 < len(rotation) {
+			if c := check(leg, rotation[i+1]); c != nil {
+				return c
+			}
+		}
+		break
+	}
+	return nil
+}
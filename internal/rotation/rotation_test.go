@@ -0,0 +1,65 @@
+package rotation
+
+import (
+	"testing"
+	"time"
+)
+
+func leg(id int, from, to string, dep, arr string) Leg {
+	d, _ := time.Parse(time.RFC3339, dep)
+	a, _ := time.Parse(time.RFC3339, arr)
+	return Leg{FlightID: id, DepAirport: from, ArrAirport: to, Departure: d, Arrival: a}
+}
+
+func TestCheck(t *testing.T) {
+	legs := []Leg{
+		leg(1, "SVO", "LED", "2024-05-01T06:00:00Z", "2024-05-01T07:30:00Z"),
+		leg(2, "LED", "SVO", "2024-05-01T09:00:00Z", "2024-05-01T10:30:00Z"),
+		leg(3, "SVO", "KZN", "2024-05-01T15:00:00Z", "2024-05-01T16:30:00Z"),
+	}
+	tests := []struct {
+		name     string
+		leg      Leg
+		wantKind ConflictKind
+	}{
+		{name: "fits between", leg: leg(0, "SVO", "SVO", "2024-05-01T11:00:00Z", "2024-05-01T12:00:00Z")},
+		{name: "overlaps", leg: leg(0, "SVO", "AER", "2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"), wantKind: Overlap},
+		{name: "covers a leg", leg: leg(0, "SVO", "SVO", "2024-05-01T05:00:00Z", "2024-05-01T08:00:00Z"), wantKind: Overlap},
+		{name: "wrong airport", leg: leg(0, "LED", "SVO", "2024-05-01T11:00:00Z", "2024-05-01T12:00:00Z"), wantKind: Positioning},
+		{name: "next leg from another airport", leg: leg(0, "SVO", "AER", "2024-05-01T11:00:00Z", "2024-05-01T13:00:00Z"), wantKind: Positioning},
+		{name: "update moves own leg", leg: leg(2, "LED", "SVO", "2024-05-01T08:00:00Z", "2024-05-01T09:30:00Z")},
+		{name: "last leg", leg: leg(0, "KZN", "SVO", "2024-05-02T06:00:00Z", "2024-05-02T07:30:00Z")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(legs, tt.leg)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			c, ok := err.(*ConflictError)
+			if !ok || c.Kind != tt.wantKind {
+				t.Errorf("Check() error = %v, want %v conflict", err, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	turns := Timeline([]Leg{
+		leg(2, "LED", "SVO", "2024-05-01T09:00:00Z", "2024-05-01T10:30:00Z"),
+		leg(1, "SVO", "LED", "2024-05-01T06:00:00Z", "2024-05-01T07:30:00Z"),
+		leg(3, "KZN", "SVO", "2024-05-01T15:00:00Z", "2024-05-01T16:30:00Z"),
+	})
+	if turns[0].FlightID != 1 || turns[0].GroundTime != 90*time.Minute || turns[0].Conflict != nil {
+		t.Errorf("Timeline()[0] = %+v", turns[0])
+	}
+	if turns[1].Conflict == nil || turns[1].Conflict.Kind != Positioning {
+		t.Errorf("Timeline()[1] conflict = %v, want positioning", turns[1].Conflict)
+	}
+	if turns[2].HasNext {
+		t.Errorf("Timeline()[2] has next leg")
+	}
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+
+// Файл internal\schedule\gen_zones.go генерирует список часовых поясов zones.go из базы IANA, поставляемой с Go.
+// Запуск: go generate ./internal/schedule
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Only the zones of the geographic areas are listed, so the legacy aliases outside them like "US/Eastern"
+// or "Etc/GMT+3" are left out. The zoneinfo of Go does not tell links from zones, so the old names kept
+// for compatibility inside the areas, like "Africa/Asmera", are listed too
+var areas = []string{"Africa", "America", "Antarctica", "Arctic", "Asia", "Atlantic", "Australia", "Europe", "Indian", "Pacific"}
+
+func main() {
+	z, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer z.Close()
+
+	names := []string{"UTC"}
+	for _, f := range z.File {
+		area, _, ok := strings.Cut(f.Name, "/")
+		if !ok {
+			continue
+		}
+		for _, a := range areas {
+			if area == a {
+				names = append(names, f.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_zones.go; DO NOT EDIT.\n\npackage schedule\n\nvar zones = []string{\n")
+	for _, n := range names {
+		b.WriteString("\t" + strconv.Quote(n) + ",\n")
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("zones.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,120 @@
+// Файл internal\schedule\schedule.go содержит расчет моментов вылета и прилёта рейсов с учетом часовых поясов аэропортов
+package schedule
+
+//go:generate go run gen_zones.go
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// The IANA database is embedded so that timezones resolve regardless of the host's zoneinfo
+	_ "time/tzdata"
+)
+
+var (
+	ErrArrivalBeforeDeparture = errors.New("arrival is before departure")
+	ErrUnknownZone            = errors.New("unknown timezone")
+)
+
+var locations sync.Map
+
+// Location returns the IANA timezone by its name. Loaded timezones are cached
+func Location(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	// time.LoadLocation takes "" for UTC and "Local" for the host's zone, neither is a name of a timezone
+	if name == "" || name == "Local" {
+		return nil, ErrUnknownZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrUnknownZone
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// parseClock parses the time of day in the "15:04:05" or "15:04" format
+func parseClock(s string) (hour, min, sec int, err error) {
+	t, err := time.Parse("15:04:05", s)
+	if err != nil {
+		if t, err = time.Parse("15:04", s); err != nil {
+			return 0, 0, 0, fmt.Errorf("bad time of day %q", s)
+		}
+	}
+	return t.Hour(), t.Minute(), t.Second(), nil
+}
+
+// Times returns the departure and the arrival instants of a flight. depDate is the local date of departure,
+// depTime and arrTime are the local times of departure and arrival in the timezones of the airports
+// and arrDayOffset is the number of days between the local dates of departure and arrival
+func Times(depDate time.Time, depTime, arrTime string, arrDayOffset int, depZone, arrZone string) (dep, arr time.Time, err error) {
+	depLoc, err := Location(depZone)
+	if err != nil {
+		return dep, arr, err
+	}
+	arrLoc, err := Location(arrZone)
+	if err != nil {
+		return dep, arr, err
+	}
+	dh, dm, ds, err := parseClock(depTime)
+	if err != nil {
+		return dep, arr, err
+	}
+	ah, am, as, err := parseClock(arrTime)
+	if err != nil {
+		return dep, arr, err
+	}
+
+	y, m, d := depDate.Date()
+	dep = time.Date(y, m, d, dh, dm, ds, 0, depLoc)
+	arr = time.Date(y, m, d+arrDayOffset, ah, am, as, 0, arrLoc)
+	if !arr.After(dep) {
+		return dep, arr, ErrArrivalBeforeDeparture
+	}
+	return dep, arr, nil
+}
+
+// Zones returns the sorted names of the geographic IANA timezones
+func Zones() []string {
+	return append([]string(nil), zones...)
+}
+
+// ZoneInfo describes a timezone at some instant. Offsets are in seconds east of UTC.
+// NextTransition is zero if the zone does not change its offset anymore
+type ZoneInfo struct {
+	Name           string
+	Abbreviation   string
+	Offset         int
+	IsDST          bool
+	ObservesDST    bool
+	NextTransition time.Time
+}
+
+// Zone describes the timezone at the given instant
+func Zone(name string, at time.Time) (ZoneInfo, error) {
+	loc, err := Location(name)
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+	t := at.In(loc)
+	abbr, offset := t.Zone()
+	info := ZoneInfo{
+		Name:         name,
+		Abbreviation: abbr,
+		Offset:       offset,
+		IsDST:        t.IsDST(),
+	}
+	// A zone observes DST this year if its offsets in winter and in summer differ
+	y := t.Year()
+	_, jan := time.Date(y, time.January, 1, 0, 0, 0, 0, loc).Zone()
+	_, jul := time.Date(y, time.July, 1, 0, 0, 0, 0, loc).Zone()
+	info.ObservesDST = jan != jul
+	if _, end := t.ZoneBounds(); !end.IsZero() {
+		info.NextTransition = end.UTC()
+	}
+	return info, nil
+}
@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimes(t *testing.T) {
+	tests := []struct {
+		name         string
+		depDate      time.Time
+		depTime      string
+		arrTime      string
+		arrDayOffset int
+		depZone      string
+		arrZone      string
+		wantDep      string
+		wantArr      string
+		wantErr      bool
+	}{
+		{
+			name:    "same day",
+			depDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			depTime: "10:00:00", arrTime: "11:30:00",
+			depZone: "Europe/Moscow", arrZone: "Europe/Moscow",
+			wantDep: "2024-05-01T07:00:00Z", wantArr: "2024-05-01T08:30:00Z",
+		},
+		{
+			name:    "overnight to the east",
+			depDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			depTime: "22:00:00", arrTime: "08:40", arrDayOffset: 1,
+			depZone: "Europe/Moscow", arrZone: "Asia/Vladivostok",
+			wantDep: "2024-05-01T19:00:00Z", wantArr: "2024-05-01T22:40:00Z",
+		},
+		{
+			name:    "two days later",
+			depDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			depTime: "23:30:00", arrTime: "01:10:00", arrDayOffset: 2,
+			depZone: "America/Los_Angeles", arrZone: "Australia/Sydney",
+			wantDep: "2024-05-02T06:30:00Z", wantArr: "2024-05-02T15:10:00Z",
+		},
+		{
+			name:    "across dst change",
+			depDate: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+			depTime: "01:30:00", arrTime: "04:30:00",
+			depZone: "Europe/Berlin", arrZone: "Europe/Berlin",
+			wantDep: "2024-03-31T00:30:00Z", wantArr: "2024-03-31T02:30:00Z",
+		},
+		{
+			name:    "missing day offset",
+			depDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			depTime: "22:00:00", arrTime: "08:40:00",
+			depZone: "Europe/Moscow", arrZone: "Asia/Vladivostok",
+			wantErr: true,
+		},
+		{
+			name:    "unknown timezone",
+			depDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			depTime: "10:00:00", arrTime: "11:00:00",
+			depZone: "Europe/Atlantis", arrZone: "Europe/Moscow",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dep, arr, err := Times(tt.depDate, tt.depTime, tt.arrTime, tt.arrDayOffset, tt.depZone, tt.arrZone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Times() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := dep.UTC().Format(time.RFC3339); got != tt.wantDep {
+				t.Errorf("Times() dep = %v, want %v", got, tt.wantDep)
+			}
+			if got := arr.UTC().Format(time.RFC3339); got != tt.wantArr {
+				t.Errorf("Times() arr = %v, want %v", got, tt.wantArr)
+			}
+		})
+	}
+}
+
+func TestZone(t *testing.T) {
+	tests := []struct {
+		name            string
+		zone            string
+		at              time.Time
+		wantOffset      int
+		wantIsDST       bool
+		wantObservesDST bool
+		wantTransition  string
+		wantErr         bool
+	}{
+		{
+			name:       "no DST",
+			zone:       "Europe/Moscow",
+			at:         time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			wantOffset: 3 * 3600,
+		},
+		{
+			name:            "summer time",
+			zone:            "Europe/Berlin",
+			at:              time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			wantOffset:      2 * 3600,
+			wantIsDST:       true,
+			wantObservesDST: true,
+			wantTransition:  "2024-10-27T01:00:00Z",
+		},
+		{
+			name:            "winter time",
+			zone:            "Europe/Berlin",
+			at:              time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			wantOffset:      3600,
+			wantObservesDST: true,
+			wantTransition:  "2024-03-31T01:00:00Z",
+		},
+		{name: "unknown", zone: "Europe/Atlantis", wantErr: true},
+		{name: "host zone", zone: "Local", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Zone(tt.zone, tt.at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Zone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Offset != tt.wantOffset || got.IsDST != tt.wantIsDST || got.ObservesDST != tt.wantObservesDST {
+				t.Errorf("Zone() = %+v", got)
+			}
+			transition := ""
+			if !got.NextTransition.IsZero() {
+				transition = got.NextTransition.Format(time.RFC3339)
+			}
+			if transition != tt.wantTransition {
+				t.Errorf("Zone() next transition = %v, want %v", transition, tt.wantTransition)
+			}
+		})
+	}
+}
+
+func TestZones(t *testing.T) {
+	for _, name := range Zones() {
+		if _, err := Location(name); err != nil {
+			t.Errorf("Location(%q) error = %v", name, err)
+		}
+	}
+}
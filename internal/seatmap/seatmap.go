@@ -0,0 +1,253 @@
+// Файл internal\seatmap\seatmap.go содержит описание схемы салона модели самолёта и построение мест и сетки по ней
+package seatmap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Seat positions relative to the windows and the aisles
+const (
+	Window = "window"
+	Middle = "middle"
+	Aisle  = "aisle"
+)
+
+// Kinds of grid cells
+const (
+	CellSeat  = "seat"
+	CellAisle = "aisle"
+	CellEmpty = "empty"
+)
+
+const maxRow = 99
+
+var (
+	ErrNoCabins         = errors.New("layout has no cabins")
+	ErrCabinsOverlap    = errors.New("cabins overlap")
+	ErrBadClass         = errors.New("cabin class must be one of J, W, Y")
+	ErrBadRows          = errors.New("cabin rows must be between 1 and 99")
+	ErrBadColumn        = errors.New("column must be a single letter A-Z")
+	ErrDuplicateColumn  = errors.New("duplicate column")
+	ErrUnknownAisle     = errors.New("aisle is after an unknown column")
+	ErrUnknownSeat      = errors.New("seat is not in the layout")
+	ErrUnknownExitRow   = errors.New("exit row is not in the layout")
+	ErrCharacteristicID = errors.New("characteristic must not be empty")
+)
+
+// Cabin is a block of rows of one class with the same columns. AisleAfter lists the columns followed by an aisle
+type Cabin struct {
+	Class      string   `json:"class"`
+	FirstRow   int      `json:"first_row"`
+	LastRow    int      `json:"last_row"`
+	Columns    []string `json:"columns"`
+	AisleAfter []string `json:"aisle_after"`
+}
+
+// Layout is the cabin layout of a liner model. SkipRows are row numbers that are not used (like 13),
+// ExitRows are rows next to emergency exits, Blocked seats are never sold and Characteristics
+// are codes like "extra_legroom" or "bassinet" by seat number
+type Layout struct {
+	Cabins          []Cabin             `json:"cabins"`
+	SkipRows        []int               `json:"skip_rows,omitempty"`
+	ExitRows        []int               `json:"exit_rows,omitempty"`
+	Blocked         []string            `json:"blocked,omitempty"`
+	Characteristics map[string][]string `json:"characteristics,omitempty"`
+}
+
+type Seat struct {
+	Number          string
+	Row             int
+	Column          string
+	Class           string
+	Position        string
+	Exit            bool
+	Blocked         bool
+	Characteristics []string
+}
+
+// Cell is a place in a row of the grid: a seat, an aisle or no seat in the column of a wider cabin
+type Cell struct {
+	Kind string
+	Seat *Seat
+}
+
+type Row struct {
+	Number int
+	Class  string
+	Exit   bool
+	Cells  []Cell
+}
+
+func contains(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the cabins do not overlap and everything the layout refers to is in it
+func (l *Layout) Validate() error {
+	if len(l.Cabins) == 0 {
+		return ErrNoCabins
+	}
+	rows := make(map[int]bool)
+	for _, c := range l.Cabins {
+		if c.Class != "J" && c.Class != "W" && c.Class != "Y" {
+			return ErrBadClass
+		}
+		if c.FirstRow < 1 || c.LastRow > maxRow || c.FirstRow > c.LastRow {
+			return ErrBadRows
+		}
+		columns := make(map[string]bool)
+		for _, col := range c.Columns {
+			if len(col) != 1 || col[0] < 'A' || col[0] > 'Z' {
+				return ErrBadColumn
+			}
+			if columns[col] {
+				return fmt.Errorf("%w %s", ErrDuplicateColumn, col)
+			}
+			columns[col] = true
+		}
+		if len(columns) == 0 {
+			return ErrBadColumn
+		}
+		for _, a := range c.AisleAfter {
+			if !columns[a] {
+				return fmt.Errorf("%w %s", ErrUnknownAisle, a)
+			}
+		}
+		for r := c.FirstRow; r <= c.LastRow; r++ {
+			if rows[r] {
+				return fmt.Errorf("%w at row %d", ErrCabinsOverlap, r)
+			}
+			rows[r] = true
+		}
+	}
+
+	seats := make(map[string]bool)
+	for _, s := range l.Seats() {
+		seats[s.Number] = true
+	}
+	for _, r := range l.ExitRows {
+		if !rows[r] || contains(l.SkipRows, r) {
+			return fmt.Errorf("%w: %d", ErrUnknownExitRow, r)
+		}
+	}
+	for _, n := range l.Blocked {
+		if !seats[n] {
+			return fmt.Errorf("%w: %s", ErrUnknownSeat, n)
+		}
+	}
+	for n, codes := range l.Characteristics {
+		if !seats[n] {
+			return fmt.Errorf("%w: %s", ErrUnknownSeat, n)
+		}
+		for _, code := range codes {
+			if code == "" {
+				return ErrCharacteristicID
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Cabin) position(i int) string {
+	if i == 0 || i == len(c.Columns)-1 {
+		return Window
+	}
+	if containsString(c.AisleAfter, c.Columns[i]) || containsString(c.AisleAfter, c.Columns[i-1]) {
+		return Aisle
+	}
+	return Middle
+}
+
+func (l *Layout) cabins() []Cabin {
+	cabins := append([]Cabin(nil), l.Cabins...)
+	sort.SliceStable(cabins, func(i, j int) bool {
+		return cabins[i].FirstRow < cabins[j].FirstRow
+	})
+	return cabins
+}
+
+// columns returns the columns of all the cabins in the alphabetical order
+func (l *Layout) columns() []string {
+	var columns []string
+	for _, c := range l.Cabins {
+		for _, col := range c.Columns {
+			if !containsString(columns, col) {
+				columns = append(columns, col)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Grid returns the rows of the cabins from the front to the back. All the rows have a cell for every column
+// of the aircraft so that narrower cabins line up with the wider ones
+func (l *Layout) Grid() []Row {
+	columns := l.columns()
+	var rows []Row
+	for _, c := range l.cabins() {
+		index := make(map[string]int, len(c.Columns))
+		for i, col := range c.Columns {
+			index[col] = i
+		}
+		for r := c.FirstRow; r <= c.LastRow; r++ {
+			if contains(l.SkipRows, r) {
+				continue
+			}
+			row := Row{Number: r, Class: c.Class, Exit: contains(l.ExitRows, r)}
+			for i, col := range columns {
+				j, ok := index[col]
+				if !ok {
+					row.Cells = append(row.Cells, Cell{Kind: CellEmpty})
+				} else {
+					number := strconv.Itoa(r) + col
+					row.Cells = append(row.Cells, Cell{Kind: CellSeat, Seat: &Seat{
+						Number:          number,
+						Row:             r,
+						Column:          col,
+						Class:           c.Class,
+						Position:        c.position(j),
+						Exit:            row.Exit,
+						Blocked:         containsString(l.Blocked, number),
+						Characteristics: l.Characteristics[number],
+					}})
+				}
+				if i < len(columns)-1 && containsString(c.AisleAfter, col) {
+					row.Cells = append(row.Cells, Cell{Kind: CellAisle})
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Seats returns every seat of the layout including the blocked ones
+func (l *Layout) Seats() []Seat {
+	var seats []Seat
+	for _, row := range l.Grid() {
+		for _, cell := range row.Cells {
+			if cell.Kind == CellSeat {
+				seats = append(seats, *cell.Seat)
+			}
+		}
+	}
+	return seats
+}
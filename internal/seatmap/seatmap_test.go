@@ -0,0 +1,87 @@
+package seatmap
+
+import (
+	"errors"
+	"testing"
+)
+
+func testLayout() *Layout {
+	return &Layout{
+		Cabins: []Cabin{
+			{Class: "Y", FirstRow: 10, LastRow: 14, Columns: []string{"A", "B", "C", "D", "E", "F"}, AisleAfter: []string{"C"}},
+			{Class: "J", FirstRow: 1, LastRow: 2, Columns: []string{"A", "C", "D", "F"}, AisleAfter: []string{"C"}},
+		},
+		SkipRows:        []int{13},
+		ExitRows:        []int{12},
+		Blocked:         []string{"14F"},
+		Characteristics: map[string][]string{"10A": {"extra_legroom"}},
+	}
+}
+
+func TestLayout_Validate(t *testing.T) {
+	if err := testLayout().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	overlap := testLayout()
+	overlap.Cabins[1].LastRow = 10
+	if err := overlap.Validate(); !errors.Is(err, ErrCabinsOverlap) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrCabinsOverlap)
+	}
+
+	blocked := testLayout()
+	blocked.Blocked = []string{"2B"}
+	if err := blocked.Validate(); !errors.Is(err, ErrUnknownSeat) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrUnknownSeat)
+	}
+
+	aisle := testLayout()
+	aisle.Cabins[0].AisleAfter = []string{"G"}
+	if err := aisle.Validate(); !errors.Is(err, ErrUnknownAisle) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrUnknownAisle)
+	}
+}
+
+func TestLayout_Grid(t *testing.T) {
+	rows := testLayout().Grid()
+	if len(rows) != 6 {
+		t.Fatalf("Grid() has %d rows, want 6", len(rows))
+	}
+	if rows[0].Number != 1 || rows[0].Class != "J" {
+		t.Errorf("Grid() first row = %d %s, want 1 J", rows[0].Number, rows[0].Class)
+	}
+	// Six columns and an aisle in every row
+	for _, r := range rows {
+		if len(r.Cells) != 7 || r.Cells[3].Kind != CellAisle {
+			t.Errorf("Grid() row %d cells = %+v", r.Number, r.Cells)
+		}
+		if r.Number == 13 {
+			t.Errorf("Grid() has the skipped row")
+		}
+	}
+	if rows[0].Cells[1].Kind != CellEmpty {
+		t.Errorf("Grid() business row has seat B")
+	}
+
+	seats := make(map[string]Seat)
+	for _, s := range testLayout().Seats() {
+		seats[s.Number] = s
+	}
+	tests := []struct {
+		number   string
+		position string
+	}{
+		{"10A", Window}, {"10B", Middle}, {"10C", Aisle}, {"10D", Aisle}, {"10F", Window}, {"1C", Aisle},
+	}
+	for _, tt := range tests {
+		if got := seats[tt.number].Position; got != tt.position {
+			t.Errorf("seat %s position = %s, want %s", tt.number, got, tt.position)
+		}
+	}
+	if !seats["12A"].Exit || !seats["14F"].Blocked || len(seats["10A"].Characteristics) != 1 {
+		t.Errorf("Seats() lost exit, blocked or characteristics")
+	}
+	if len(seats) != 4*2+6*4 {
+		t.Errorf("Seats() = %d seats, want %d", len(seats), 4*2+6*4)
+	}
+}
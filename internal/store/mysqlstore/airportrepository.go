@@ -8,10 +8,15 @@ type AirportRepository struct {
 }
 
 func (r *AirportRepository) Create(a *store.AirportModel) error {
-	_, err := r.store.db.Exec("INSERT INTO airport (iata_code, city, timezone) VALUES (?, ?, ?)",
+	_, err := r.store.db.Exec("INSERT INTO airport (iata_code, city, timezone, latitude, longitude, icao_code, name, country) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
 		a.IATACode,
 		a.City,
 		a.Timezone,
+		a.Latitude,
+		a.Longitude,
+		a.ICAOCode,
+		a.Name,
+		a.Country,
 	)
 	return err
 }
@@ -39,11 +44,24 @@ func (r *AirportRepository) FindAll(row_count, offset int) (*[]store.AirportMode
 	return airports, nil
 }
 
+func (r *AirportRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.AirportModel, error) {
+	airports := &[]store.AirportModel{}
+	if err := r.store.selectPage(airports, "airport", "iata_code", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return airports, nil
+}
+
 func (r *AirportRepository) Update(code string, a *store.AirportModel) error {
-	res, err := r.store.db.Exec("UPDATE airport SET iata_code = ?, city = ?, timezone = ? WHERE iata_code = ?",
+	res, err := r.store.db.Exec("UPDATE airport SET iata_code = ?, city = ?, timezone = ?, latitude = ?, longitude = ?, icao_code = ?, name = ?, country = ? WHERE iata_code = ?",
 		a.IATACode,
 		a.City,
 		a.Timezone,
+		a.Latitude,
+		a.Longitude,
+		a.ICAOCode,
+		a.Name,
+		a.Country,
 		code,
 	)
 	if err != nil {
@@ -82,3 +100,37 @@ func (r *AirportRepository) TotalCount() (int, error) {
 	}
 	return count, nil
 }
+
+// FindTaxes returns the taxes levied by the airport
+func (r *AirportRepository) FindTaxes(code string) ([]store.AirportTaxModel, error) {
+	var taxes []store.AirportTaxModel
+	if err := r.store.db.Select(&taxes, "SELECT * FROM airport_tax WHERE iata_code = ? ORDER BY applies, tax_code", code); err != nil {
+		return nil, err
+	}
+	for i := range taxes {
+		if err := taxes[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return taxes, nil
+}
+
+// SetTaxes replaces the taxes levied by the airport
+func (r *AirportRepository) SetTaxes(code string, taxes []store.AirportTaxModel) error {
+	if _, err := r.store.db.Exec("DELETE FROM airport_tax WHERE iata_code = ?", code); err != nil {
+		return err
+	}
+	for _, t := range taxes {
+		if _, err := r.store.db.Exec("INSERT INTO airport_tax (iata_code, tax_code, name, applies, amount, currency) VALUES (?, ?, ?, ?, ?, ?)",
+			code,
+			t.TaxCode,
+			t.Name,
+			t.Applies,
+			t.Amount,
+			t.Currency,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Файл internal\store\mysqlstore\bookingclassrepository.go содержит код для работы с таблицами Классы бронирования и Лимиты классов бронирования рейса
+package mysqlstore
+
+import "github.com/akionka/aviasales/internal/store"
+
+type BookingClassRepository struct {
+	store *Store
+}
+
+func (r *BookingClassRepository) Create(c *store.BookingClassModel) error {
+	_, err := r.store.db.Exec("INSERT INTO booking_class (code, cabin, fare_multiplier, booking_limit_percent) VALUES (?, ?, ?, ?)",
+		c.Code,
+		c.Cabin,
+		c.FareMultiplier,
+		c.BookingLimitPercent,
+	)
+	return err
+}
+
+func (r *BookingClassRepository) Find(code string) (*store.BookingClassModel, error) {
+	bookingClass := &store.BookingClassModel{}
+	if err := r.store.db.Get(bookingClass, "SELECT * FROM booking_class WHERE code = ?", code); err != nil {
+		return nil, err
+	}
+	return bookingClass, nil
+}
+
+// FindAll returns the booking classes of all the cabins, the dearest first
+func (r *BookingClassRepository) FindAll() ([]store.BookingClassModel, error) {
+	var bookingClasses []store.BookingClassModel
+	if err := r.store.db.Select(&bookingClasses, "SELECT * FROM booking_class ORDER BY cabin, fare_multiplier DESC, code"); err != nil {
+		return nil, err
+	}
+	return bookingClasses, nil
+}
+
+func (r *BookingClassRepository) Update(code string, c *store.BookingClassModel) error {
+	res, err := r.store.db.Exec("UPDATE booking_class SET code = ?, cabin = ?, fare_multiplier = ?, booking_limit_percent = ? WHERE code = ?",
+		c.Code,
+		c.Cabin,
+		c.FareMultiplier,
+		c.BookingLimitPercent,
+		code,
+	)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return err
+}
+
+func (r *BookingClassRepository) Delete(code string) error {
+	res, err := r.store.db.Exec("DELETE FROM booking_class WHERE code = ?", code)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeletedItemDoesNotExist
+	}
+	return nil
+}
+
+// FindFlightLimits returns the booking limits set for the booking classes of the flight
+func (r *BookingClassRepository) FindFlightLimits(flightID int) ([]store.FlightBookingLimitModel, error) {
+	var limits []store.FlightBookingLimitModel
+	if err := r.store.db.Select(&limits, "SELECT * FROM flight_booking_limit WHERE flight_id = ? ORDER BY booking_class", flightID); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SetFlightLimit sets the booking limit of the booking class of the flight
+func (r *BookingClassRepository) SetFlightLimit(l *store.FlightBookingLimitModel) error {
+	_, err := r.store.db.Exec(`INSERT INTO flight_booking_limit (flight_id, booking_class, booking_limit) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE booking_limit = VALUES(booking_limit)`,
+		l.FlightID,
+		l.BookingClass,
+		l.BookingLimit,
+	)
+	return err
+}
+
+// DeleteFlightLimit returns the booking class of the flight to the limit of the booking class
+func (r *BookingClassRepository) DeleteFlightLimit(flightID int, code string) error {
+	_, err := r.store.db.Exec("DELETE FROM flight_booking_limit WHERE flight_id = ? AND booking_class = ?", flightID, code)
+	return err
+}
+
+// FindRule returns the conditions of the fare of the booking class
+func (r *BookingClassRepository) FindRule(code string) (*store.FareRuleModel, error) {
+	rule := &store.FareRuleModel{}
+	if err := r.store.db.Get(rule, "SELECT * FROM fare_rule WHERE booking_class = ?", code); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// SetRule sets the conditions of the fare of the booking class
+func (r *BookingClassRepository) SetRule(f *store.FareRuleModel) error {
+	_, err := r.store.db.Exec(`INSERT INTO fare_rule
+	(booking_class, refundable, change_fee, no_show_fee, min_stay_days, max_stay_days, advance_purchase_days)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE refundable = VALUES(refundable), change_fee = VALUES(change_fee), no_show_fee = VALUES(no_show_fee),
+	min_stay_days = VALUES(min_stay_days), max_stay_days = VALUES(max_stay_days), advance_purchase_days = VALUES(advance_purchase_days)`,
+		f.BookingClass,
+		f.Refundable,
+		f.ChangeFee,
+		f.NoShowFee,
+		f.MinStayDays,
+		f.MaxStayDays,
+		f.AdvancePurchaseDays,
+	)
+	return err
+}
@@ -10,10 +10,12 @@ type BookingOfficeRepository struct {
 }
 
 func (r *BookingOfficeRepository) Create(o *store.BookingOfficeModel) error {
-	_, err := r.store.db.Exec("INSERT INTO booking_office (id, address, phone_number) VALUES (?, ?, ?)",
+	_, err := r.store.db.Exec("INSERT INTO booking_office (id, address, phone_number, service_fee, currency) VALUES (?, ?, ?, ?, ?)",
 		o.ID,
 		o.Address,
 		o.PhoneNumber,
+		o.ServiceFee,
+		o.Currency,
 	)
 	return err
 }
@@ -23,6 +25,9 @@ func (r *BookingOfficeRepository) Find(id int) (*store.BookingOfficeModel, error
 	if err := r.store.db.Get(office, "SELECT * FROM booking_office WHERE id = ?", id); err != nil {
 		return nil, err
 	}
+	if err := office.ApplyCurrency(); err != nil {
+		return nil, err
+	}
 	return office, nil
 }
 
@@ -38,14 +43,34 @@ func (r *BookingOfficeRepository) FindAll(row_count, offset int) (*[]store.Booki
 	if err := r.store.db.Select(offices, "SELECT * FROM booking_office ORDER BY id LIMIT ?, ?", offset, row_count); err != nil {
 		return nil, err
 	}
+	for i := range *offices {
+		if err := (*offices)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return offices, nil
+}
+
+func (r *BookingOfficeRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.BookingOfficeModel, error) {
+	offices := &[]store.BookingOfficeModel{}
+	if err := r.store.selectPage(offices, "booking_office", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	for i := range *offices {
+		if err := (*offices)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
 	return offices, nil
 }
 
 func (r *BookingOfficeRepository) Update(id int, o *store.BookingOfficeModel) error {
-	res, err := r.store.db.Exec("UPDATE booking_office SET id = ?, address = ?, phone_number = ? WHERE id = ?",
+	res, err := r.store.db.Exec("UPDATE booking_office SET id = ?, address = ?, phone_number = ?, service_fee = ?, currency = ? WHERE id = ?",
 		o.ID,
 		o.Address,
 		o.PhoneNumber,
+		o.ServiceFee,
+		o.Currency,
 		id,
 	)
 	if err != nil {
@@ -51,6 +51,14 @@ func (r *CashierRepository) FindAll(row_count, offset int) (*[]store.CashierMode
 	return cashiers, nil
 }
 
+func (r *CashierRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.CashierModel, error) {
+	cashiers := &[]store.CashierModel{}
+	if err := r.store.selectPage(cashiers, "cashier", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return cashiers, nil
+}
+
 func (r *CashierRepository) Update(id int, c *store.CashierModel) error {
 	res, err := r.store.db.Exec("UPDATE cashier SET login = ?, last_name = ?, first_name = ?, middle_name = ? WHERE id = ?",
 		c.Login,
@@ -0,0 +1,47 @@
+// Файл internal\store\mysqlstore\exchangeraterepository.go содержит код для работы с таблицей Курсы валют
+package mysqlstore
+
+import (
+	"time"
+
+	"github.com/akionka/aviasales/internal/store"
+)
+
+type ExchangeRateRepository struct {
+	store *Store
+}
+
+// FindAll returns the rates of all the currencies, the earliest first
+func (r *ExchangeRateRepository) FindAll() ([]store.ExchangeRateModel, error) {
+	var rates []store.ExchangeRateModel
+	if err := r.store.db.Select(&rates, "SELECT * FROM exchange_rate ORDER BY currency, effective_date"); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Set sets the rate of the currency from the effective date, replacing the one set for the same date
+func (r *ExchangeRateRepository) Set(e *store.ExchangeRateModel) error {
+	_, err := r.store.db.Exec(`INSERT INTO exchange_rate (currency, effective_date, rate) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE rate = VALUES(rate)`,
+		e.Currency,
+		e.EffectiveDate,
+		e.Rate,
+	)
+	return err
+}
+
+func (r *ExchangeRateRepository) Delete(currency string, effectiveDate time.Time) error {
+	res, err := r.store.db.Exec("DELETE FROM exchange_rate WHERE currency = ? AND effective_date = ?", currency, effectiveDate)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeletedItemDoesNotExist
+	}
+	return nil
+}
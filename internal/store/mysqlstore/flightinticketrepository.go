@@ -2,20 +2,115 @@
 package mysqlstore
 
 import (
+	"time"
+
 	"github.com/akionka/aviasales/internal/store"
+	"github.com/jmoiron/sqlx"
 )
 
+const segmentQuery = `SELECT
+	fit.id,
+	fit.ticket_id,
+	f.id flight_id,
+	f.dep_date,
+	l.line_code,
+	l.dep_airport,
+	l.arr_airport,
+	fit.seat_id,
+	COALESCE(s.number, '') number,
+	fit.class,
+	fit.booking_class,
+	fit.checked_in_at,
+	fit.checkin_sequence,
+	fit.boarded_at
+FROM
+	flight_in_ticket fit
+			INNER JOIN
+	flight f ON fit.flight_id = f.id
+			INNER JOIN
+	line l ON f.line_code = l.line_code
+			LEFT JOIN
+	seat s ON s.id = fit.seat_id`
+
 type FlightInTicketRepository struct {
 	store *Store
 }
 
 func (r *FlightInTicketRepository) Create(f *store.FlightInTicketModel) error {
-	_, err := r.store.db.Exec("INSERT INTO flight_in_ticket (flight_id, seat_id, ticket_id) VALUES (?, ?, ?)",
+	res, err := r.store.db.Exec("INSERT INTO flight_in_ticket (flight_id, seat_id, ticket_id, class, booking_class, fare, fare_currency, fuel_surcharge) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
 		f.FlightID,
 		f.SeatID,
 		f.TicketID,
+		f.Class,
+		f.BookingClass,
+		f.Fare,
+		f.FareCurrency,
+		f.FuelSurcharge,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	f.ID = int(id)
+	return nil
+}
+
+// FindTaxes returns the airport taxes levied on the segment when it was sold
+func (r *FlightInTicketRepository) FindTaxes(id int) ([]store.SegmentTaxModel, error) {
+	var taxes []store.SegmentTaxModel
+	if err := r.store.db.Select(&taxes, "SELECT * FROM segment_tax WHERE flight_in_ticket_id = ? ORDER BY airport, tax_code", id); err != nil {
+		return nil, err
+	}
+	for i := range taxes {
+		if err := taxes[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return taxes, nil
+}
+
+// FindSegmentsTaxes returns the airport taxes levied on the segments when they were sold ordered by the segment
+func (r *FlightInTicketRepository) FindSegmentsTaxes(ids []int) ([]store.SegmentTaxModel, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In("SELECT * FROM segment_tax WHERE flight_in_ticket_id IN (?) ORDER BY flight_in_ticket_id, airport, tax_code", ids)
+	if err != nil {
+		return nil, err
+	}
+	var taxes []store.SegmentTaxModel
+	if err := r.store.db.Select(&taxes, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range taxes {
+		if err := taxes[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return taxes, nil
+}
+
+// SetTaxes replaces the airport taxes of the segment with the ones it is sold with
+func (r *FlightInTicketRepository) SetTaxes(id int, taxes []store.SegmentTaxModel) error {
+	if _, err := r.store.db.Exec("DELETE FROM segment_tax WHERE flight_in_ticket_id = ?", id); err != nil {
+		return err
+	}
+	for _, t := range taxes {
+		if _, err := r.store.db.Exec("INSERT INTO segment_tax (flight_in_ticket_id, airport, tax_code, name, amount, currency) VALUES (?, ?, ?, ?, ?, ?)",
+			id,
+			t.Airport,
+			t.TaxCode,
+			t.Name,
+			t.Amount,
+			t.Currency,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *FlightInTicketRepository) Find(id int) (*store.FlightInTicketModel, error) {
@@ -23,6 +118,20 @@ func (r *FlightInTicketRepository) Find(id int) (*store.FlightInTicketModel, err
 	if err := r.store.db.Get(flightInTicket, "SELECT * FROM flight_in_ticket WHERE id = ?", id); err != nil {
 		return nil, err
 	}
+	if err := flightInTicket.ApplyCurrency(); err != nil {
+		return nil, err
+	}
+	return flightInTicket, nil
+}
+
+func (r *FlightInTicketRepository) FindOnFlight(ticketID, flightID int) (*store.FlightInTicketModel, error) {
+	flightInTicket := &store.FlightInTicketModel{}
+	if err := r.store.db.Get(flightInTicket, "SELECT * FROM flight_in_ticket WHERE ticket_id = ? AND flight_id = ?", ticketID, flightID); err != nil {
+		return nil, err
+	}
+	if err := flightInTicket.ApplyCurrency(); err != nil {
+		return nil, err
+	}
 	return flightInTicket, nil
 }
 
@@ -38,14 +147,168 @@ func (r *FlightInTicketRepository) FindAll(row_count, offset int) (*[]store.Flig
 	if err := r.store.db.Select(flightInTickets, "SELECT * FROM flight_in_ticket ORDER BY id LIMIT ?, ?", offset, row_count); err != nil {
 		return nil, err
 	}
+	for i := range *flightInTickets {
+		if err := (*flightInTickets)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return flightInTickets, nil
+}
+
+func (r *FlightInTicketRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.FlightInTicketModel, error) {
+	flightInTickets := &[]store.FlightInTicketModel{}
+	if err := r.store.selectPage(flightInTickets, "flight_in_ticket", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	for i := range *flightInTickets {
+		if err := (*flightInTickets)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
 	return flightInTickets, nil
 }
 
+func (r *FlightInTicketRepository) FindSegments(ticketID int) ([]store.SegmentModel, error) {
+	var segments []store.SegmentModel
+	if err := r.store.db.Select(&segments, segmentQuery+`
+WHERE
+	fit.ticket_id = ?
+ORDER BY f.dep_date, l.dep_time`, ticketID); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// FindTicketsSegments returns the segments of the tickets ordered by the ticket and the departure
+func (r *FlightInTicketRepository) FindTicketsSegments(ticketIDs []int) ([]store.SegmentModel, error) {
+	if len(ticketIDs) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(segmentQuery+`
+WHERE
+	fit.ticket_id IN (?)
+ORDER BY fit.ticket_id, f.dep_date, l.dep_time`, ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	var segments []store.SegmentModel
+	if err := r.store.db.Select(&segments, query, args...); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// LockFlight locks the flight and its segments until the end of the transaction, so no seat
+// of the flight is sold or taken meanwhile, even while the flight has no segments yet
+func (r *FlightInTicketRepository) LockFlight(flightID int) error {
+	var ids []int
+	if err := r.store.db.Select(&ids, "SELECT id FROM flight WHERE id = ? FOR UPDATE", flightID); err != nil {
+		return err
+	}
+	return r.store.db.Select(&ids, "SELECT id FROM flight_in_ticket WHERE flight_id = ? FOR UPDATE", flightID)
+}
+
+// FindFlightSegments returns the segments of all the tickets for the flight
+func (r *FlightInTicketRepository) FindFlightSegments(flightID int) ([]store.SegmentModel, error) {
+	var segments []store.SegmentModel
+	if err := r.store.db.Select(&segments, segmentQuery+`
+WHERE
+	fit.flight_id = ?
+ORDER BY fit.id`, flightID); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// FindManifest returns the passengers of the flight
+func (r *FlightInTicketRepository) FindManifest(flightID int) ([]store.ManifestEntryModel, error) {
+	var entries []store.ManifestEntryModel
+	if err := r.store.db.Select(&entries, `SELECT
+	fit.id,
+	t.id ticket_id,
+	t.pass_last_name,
+	t.pass_given_name,
+	t.pass_birth_date,
+	t.pass_sex,
+	t.pass_document_type,
+	t.pass_passport_number,
+	t.pass_document_country,
+	t.accompanying_ticket_id,
+	COALESCE(s.number, '') number,
+	fit.class,
+	fit.booking_class,
+	fit.checked_in_at,
+	fit.boarded_at
+FROM
+	flight_in_ticket fit
+			INNER JOIN
+	ticket t ON t.id = fit.ticket_id
+			LEFT JOIN
+	seat s ON s.id = fit.seat_id
+WHERE
+	fit.flight_id = ?`, flightID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindCompanions returns the other tickets of the purchase of the ticket that have the flight
+func (r *FlightInTicketRepository) FindCompanions(flightID, ticketID int) ([]store.TicketModel, error) {
+	var tickets []store.TicketModel
+	if err := r.store.db.Select(&tickets, `SELECT
+	c.*
+FROM
+	ticket t
+			INNER JOIN
+	ticket c ON c.purchase_id = t.purchase_id AND c.id <> t.id
+			INNER JOIN
+	flight_in_ticket fit ON fit.ticket_id = c.id
+WHERE
+	t.id = ? AND fit.flight_id = ?`, ticketID, flightID); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// CountInfants returns the number of infants without a seat flying on the flight with the escort,
+// not counting the flight in ticket exceptID
+func (r *FlightInTicketRepository) CountInfants(flightID, escortTicketID, exceptID int) (int, error) {
+	var count int
+	row := r.store.db.QueryRow(`SELECT
+	COUNT(*)
+FROM
+	flight_in_ticket fit
+			INNER JOIN
+	ticket t ON t.id = fit.ticket_id
+WHERE
+	fit.flight_id = ? AND fit.seat_id IS NULL AND t.accompanying_ticket_id = ? AND fit.id <> ?`, flightID, escortTicketID, exceptID)
+	if err := row.Scan(&count); err != nil {
+		return -1, err
+	}
+	return count, nil
+}
+
+// Update changes the segment. Moving it to another flight cancels the check-in, so the check-in columns
+// are set before flight_id while it still holds the old flight. The booking class and the fare sold are kept unless given
 func (r *FlightInTicketRepository) Update(id int, f *store.FlightInTicketModel) error {
-	res, err := r.store.db.Exec("UPDATE flight_in_ticket SET flight_id = ?, seat_id = ?, ticket_id = ? WHERE id = ?",
+	res, err := r.store.db.Exec(`UPDATE flight_in_ticket SET
+	checked_in_at = IF(flight_id = ?, checked_in_at, NULL),
+	checkin_sequence = IF(flight_id = ?, checkin_sequence, NULL),
+	boarded_at = IF(flight_id = ?, boarded_at, NULL),
+	flight_id = ?, seat_id = ?, ticket_id = ?, class = ?, booking_class = COALESCE(?, booking_class),
+	fare = COALESCE(?, fare), fare_currency = COALESCE(?, fare_currency), fuel_surcharge = COALESCE(?, fuel_surcharge)
+WHERE id = ?`,
+		f.FlightID,
+		f.FlightID,
+		f.FlightID,
 		f.FlightID,
 		f.SeatID,
 		f.TicketID,
+		f.Class,
+		f.BookingClass,
+		f.Fare,
+		f.FareCurrency,
+		f.FuelSurcharge,
 		id,
 	)
 	if err != nil {
@@ -61,6 +324,63 @@ func (r *FlightInTicketRepository) Update(id int, f *store.FlightInTicketModel)
 	return err
 }
 
+// FindByCheckInSequence returns the segment checked in for the flight with the sequence number
+func (r *FlightInTicketRepository) FindByCheckInSequence(flightID, sequence int) (*store.FlightInTicketModel, error) {
+	flightInTicket := &store.FlightInTicketModel{}
+	if err := r.store.db.Get(flightInTicket, "SELECT * FROM flight_in_ticket WHERE flight_id = ? AND checkin_sequence = ?", flightID, sequence); err != nil {
+		return nil, err
+	}
+	if err := flightInTicket.ApplyCurrency(); err != nil {
+		return nil, err
+	}
+	return flightInTicket, nil
+}
+
+// CheckIn marks the segment checked in and gives it the next sequence number of its flight
+func (r *FlightInTicketRepository) CheckIn(id int, at time.Time) (int, error) {
+	var sequence int
+	row := r.store.db.QueryRow(`SELECT
+	COALESCE(MAX(o.checkin_sequence), 0) + 1
+FROM
+	flight_in_ticket fit
+			INNER JOIN
+	flight_in_ticket o ON o.flight_id = fit.flight_id
+WHERE
+	fit.id = ?
+FOR UPDATE`, id)
+	if err := row.Scan(&sequence); err != nil {
+		return -1, err
+	}
+	res, err := r.store.db.Exec("UPDATE flight_in_ticket SET checked_in_at = ?, checkin_sequence = ? WHERE id = ? AND checked_in_at IS NULL", at, sequence, id)
+	if err != nil {
+		return -1, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+	if count == 0 {
+		return -1, ErrNoChanges
+	}
+	return sequence, nil
+}
+
+// Board marks the checked in segment boarded
+func (r *FlightInTicketRepository) Board(id int, at time.Time) error {
+	res, err := r.store.db.Exec("UPDATE flight_in_ticket SET boarded_at = ? WHERE id = ? AND checked_in_at IS NOT NULL AND boarded_at IS NULL", at, id)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return nil
+}
+
 func (r *FlightInTicketRepository) Delete(id int) error {
 	res, err := r.store.db.Exec("DELETE FROM flight_in_ticket WHERE id = ?", id)
 	if err != nil {
@@ -2,9 +2,33 @@
 package mysqlstore
 
 import (
+	"database/sql"
+	"strings"
+	"time"
+
 	"github.com/akionka/aviasales/internal/store"
 )
 
+const flightLegQuery = `SELECT
+	f.*,
+	l.dep_time,
+	l.arr_time,
+	l.arr_day_offset,
+	l.base_price,
+	l.currency,
+	l.dep_airport,
+	l.arr_airport,
+	a1.timezone dep_timezone,
+	a2.timezone arr_timezone
+FROM
+	flight f
+			INNER JOIN
+	line l ON f.line_code = l.line_code
+			INNER JOIN
+	airport a1 ON l.dep_airport = a1.iata_code
+			INNER JOIN
+	airport a2 ON l.arr_airport = a2.iata_code`
+
 type FlightRepository struct {
 	store *Store
 }
@@ -27,6 +51,85 @@ func (r *FlightRepository) Find(id int) (*store.FlightModel, error) {
 	return flight, nil
 }
 
+func (r *FlightRepository) FindLeg(id int) (*store.FlightLegModel, error) {
+	leg := &store.FlightLegModel{}
+	if err := r.store.db.Get(leg, flightLegQuery+" WHERE f.id = ?", id); err != nil {
+		return nil, err
+	}
+	if err := leg.ApplyCurrency(); err != nil {
+		return nil, err
+	}
+	return leg, nil
+}
+
+func (r *FlightRepository) FindLegs(q *store.FlightQuery) ([]store.FlightLegModel, error) {
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	if q.DepAirport != "" {
+		conditions = append(conditions, "l.dep_airport = ?")
+		args = append(args, q.DepAirport)
+	}
+	if q.ArrAirport != "" {
+		conditions = append(conditions, "l.arr_airport = ?")
+		args = append(args, q.ArrAirport)
+	}
+	if q.LinerCode != "" {
+		conditions = append(conditions, "f.liner_code = ?")
+		args = append(args, q.LinerCode)
+	}
+	if !q.FromDate.IsZero() {
+		conditions = append(conditions, "f.dep_date >= ?")
+		args = append(args, q.FromDate.Format("2006-01-02"))
+	}
+	if !q.ToDate.IsZero() {
+		conditions = append(conditions, "f.dep_date <= ?")
+		args = append(args, q.ToDate.Format("2006-01-02"))
+	}
+
+	var legs []store.FlightLegModel
+	if err := r.store.db.Select(&legs, flightLegQuery+" WHERE "+strings.Join(conditions, " AND ")+" ORDER BY f.dep_date, l.dep_time, f.id", args...); err != nil {
+		return nil, err
+	}
+	for i := range legs {
+		if err := legs[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return legs, nil
+}
+
+// FindAdjacentLegs returns the last flight of the liner departing before the date from and the first one departing
+// after the date to, nil if there is none. Cancelled flights and the flight exceptID are skipped
+func (r *FlightRepository) FindAdjacentLegs(linerCode string, from, to time.Time, exceptID int) (prev, next *store.FlightLegModel, err error) {
+	const where = " WHERE f.liner_code = ? AND f.id <> ? AND f.status <> 'cancelled'"
+	prev, err = r.findLeg(flightLegQuery+where+" AND f.dep_date < ? ORDER BY f.dep_date DESC, l.dep_time DESC, f.id DESC LIMIT 1",
+		linerCode, exceptID, from.Format("2006-01-02"))
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err = r.findLeg(flightLegQuery+where+" AND f.dep_date > ? ORDER BY f.dep_date, l.dep_time, f.id LIMIT 1",
+		linerCode, exceptID, to.Format("2006-01-02"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return prev, next, nil
+}
+
+// findLeg returns the leg the query selects, nil if it selects none
+func (r *FlightRepository) findLeg(query string, args ...interface{}) (*store.FlightLegModel, error) {
+	leg := &store.FlightLegModel{}
+	if err := r.store.db.Get(leg, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := leg.ApplyCurrency(); err != nil {
+		return nil, err
+	}
+	return leg, nil
+}
+
 func (r *FlightRepository) FindAll(row_count, offset int) (*[]store.FlightModel, error) {
 	if row_count < 0 {
 		row_count = 0
@@ -42,6 +145,14 @@ func (r *FlightRepository) FindAll(row_count, offset int) (*[]store.FlightModel,
 	return flights, nil
 }
 
+func (r *FlightRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.FlightModel, error) {
+	flights := &[]store.FlightModel{}
+	if err := r.store.selectPage(flights, "flight", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return flights, nil
+}
+
 func (r *FlightRepository) Update(id int, f *store.FlightModel) error {
 	res, err := r.store.db.Exec("UPDATE flight SET dep_date = ?, line_code = ?, is_hot = ?, liner_code = ? WHERE id = ?",
 		f.DepDate,
@@ -63,6 +174,84 @@ func (r *FlightRepository) Update(id int, f *store.FlightModel) error {
 	return err
 }
 
+// UpdateStatus stores the status and the estimated and actual times of the flight
+func (r *FlightRepository) UpdateStatus(id int, f *store.FlightModel) error {
+	res, err := r.store.db.Exec("UPDATE flight SET status = ?, est_dep_time = ?, est_arr_time = ?, actual_dep_time = ?, actual_arr_time = ? WHERE id = ?",
+		f.Status,
+		f.EstDepTime,
+		f.EstArrTime,
+		f.ActualDepTime,
+		f.ActualArrTime,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return nil
+}
+
+func (r *FlightRepository) AddStatusChange(c *store.FlightStatusChangeModel) error {
+	res, err := r.store.db.Exec(`INSERT INTO flight_status_change
+	(flight_id, prev_status, status, est_dep_time, est_arr_time, actual_dep_time, actual_arr_time, reason, cashier_id, changed_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.FlightID,
+		c.PrevStatus,
+		c.Status,
+		c.EstDepTime,
+		c.EstArrTime,
+		c.ActualDepTime,
+		c.ActualArrTime,
+		c.Reason,
+		c.CashierID,
+		c.ChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	c.ID = int(id)
+	return nil
+}
+
+func (r *FlightRepository) FindStatusChanges(flightID int) ([]store.FlightStatusChangeModel, error) {
+	var changes []store.FlightStatusChangeModel
+	if err := r.store.db.Select(&changes, "SELECT * FROM flight_status_change WHERE flight_id = ? ORDER BY changed_at, id", flightID); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// FindCapacities returns the overbooking limits set for the classes of the flight
+func (r *FlightRepository) FindCapacities(flightID int) ([]store.FlightCapacityModel, error) {
+	var capacities []store.FlightCapacityModel
+	if err := r.store.db.Select(&capacities, "SELECT * FROM flight_capacity WHERE flight_id = ? ORDER BY class", flightID); err != nil {
+		return nil, err
+	}
+	return capacities, nil
+}
+
+// SetCapacity sets the overbooking limit of the class of the flight
+func (r *FlightRepository) SetCapacity(c *store.FlightCapacityModel) error {
+	_, err := r.store.db.Exec(`INSERT INTO flight_capacity (flight_id, class, overbooking_percent, authorized_capacity) VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE overbooking_percent = VALUES(overbooking_percent), authorized_capacity = VALUES(authorized_capacity)`,
+		c.FlightID,
+		c.Class,
+		c.OverbookingPercent,
+		c.AuthorizedCapacity,
+	)
+	return err
+}
+
 func (r *FlightRepository) Delete(id int) error {
 	res, err := r.store.db.Exec("DELETE FROM flight WHERE id = ?", id)
 	if err != nil {
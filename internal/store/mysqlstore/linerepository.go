@@ -8,11 +8,14 @@ type LineRepository struct {
 }
 
 func (r *LineRepository) Create(l *store.LineModel) error {
-	_, err := r.store.db.Exec("INSERT INTO line (line_code, dep_time, arr_time, base_price, dep_airport, arr_airport) VALUES (?, ?, ?, ?, ?, ?)",
+	_, err := r.store.db.Exec("INSERT INTO line (line_code, dep_time, arr_time, arr_day_offset, base_price, fuel_surcharge, currency, dep_airport, arr_airport) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		l.LineCode,
 		l.DepTime,
 		l.ArrTime,
+		l.ArrDayOffset,
 		l.BasePrice,
+		l.FuelSurcharge,
+		l.Currency,
 		l.DepAirport,
 		l.ArrAirport,
 	)
@@ -24,6 +27,9 @@ func (r *LineRepository) Find(code string) (*store.LineModel, error) {
 	if err := r.store.db.Get(line, "SELECT * FROM line WHERE line_code = ?", code); err != nil {
 		return nil, err
 	}
+	if err := line.ApplyCurrency(); err != nil {
+		return nil, err
+	}
 	return line, nil
 }
 
@@ -39,15 +45,36 @@ func (r *LineRepository) FindAll(row_count, offset int) (*[]store.LineModel, err
 	if err := r.store.db.Select(lines, "SELECT * FROM line ORDER BY line_code LIMIT ?, ?", offset, row_count); err != nil {
 		return nil, err
 	}
+	for i := range *lines {
+		if err := (*lines)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func (r *LineRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.LineModel, error) {
+	lines := &[]store.LineModel{}
+	if err := r.store.selectPage(lines, "line", "line_code", cursor, row_count); err != nil {
+		return nil, err
+	}
+	for i := range *lines {
+		if err := (*lines)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
 	return lines, nil
 }
 
 func (r *LineRepository) Update(code string, l *store.LineModel) error {
-	res, err := r.store.db.Exec("UPDATE line SET line_code = ?, dep_time = ?, arr_time = ?, base_price = ?, dep_airport = ?, arr_airport = ? WHERE line_code = ?",
+	res, err := r.store.db.Exec("UPDATE line SET line_code = ?, dep_time = ?, arr_time = ?, arr_day_offset = ?, base_price = ?, fuel_surcharge = ?, currency = ?, dep_airport = ?, arr_airport = ? WHERE line_code = ?",
 		l.LineCode,
 		l.DepTime,
 		l.ArrTime,
+		l.ArrDayOffset,
 		l.BasePrice,
+		l.FuelSurcharge,
+		l.Currency,
 		l.DepAirport,
 		l.ArrAirport,
 		code,
@@ -38,6 +38,14 @@ func (r *LinerModelRepository) FindAll(row_count, offset int) (*[]store.LinerMod
 	return linerModels, nil
 }
 
+func (r *LinerModelRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.LinerModelModel, error) {
+	linerModels := &[]store.LinerModelModel{}
+	if err := r.store.selectPage(linerModels, "liner_model", "iata_type_code", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return linerModels, nil
+}
+
 func (r *LinerModelRepository) Update(code string, m *store.LinerModelModel) error {
 	res, err := r.store.db.Exec("UPDATE liner_model SET iata_type_code = ?, name = ? WHERE iata_type_code = ?",
 		m.IATATypeCode,
@@ -57,6 +65,21 @@ func (r *LinerModelRepository) Update(code string, m *store.LinerModelModel) err
 	return err
 }
 
+func (r *LinerModelRepository) SetLayout(code string, layout *string) error {
+	res, err := r.store.db.Exec("UPDATE liner_model SET layout = ? WHERE iata_type_code = ?", layout, code)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return nil
+}
+
 func (r *LinerModelRepository) Delete(code string) error {
 	res, err := r.store.db.Exec("DELETE FROM liner_model WHERE iata_type_code = ?", code)
 	if err != nil {
@@ -37,6 +37,14 @@ func (r *LinerRepository) FindAll(row_count, offset int) (*[]store.LinerModel, e
 	return liners, nil
 }
 
+func (r *LinerRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.LinerModel, error) {
+	liners := &[]store.LinerModel{}
+	if err := r.store.selectPage(liners, "liner", "iata_code", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return liners, nil
+}
+
 func (r *LinerRepository) Update(code string, l *store.LinerModel) error {
 	res, err := r.store.db.Exec("UPDATE liner SET iata_code = ?, model_code = ? WHERE iata_code = ?",
 		l.IATACode,
@@ -0,0 +1,91 @@
+// Файл internal\store\mysqlstore\loyaltyrepository.go содержит код для работы с таблицами Счета программы лояльности и Журнал миль
+package mysqlstore
+
+import "github.com/akionka/aviasales/internal/store"
+
+type LoyaltyRepository struct {
+	store *Store
+}
+
+func (r *LoyaltyRepository) Create(a *store.LoyaltyAccountModel) error {
+	res, err := r.store.db.Exec("INSERT INTO loyalty_account (passenger_id, enrolled_at) VALUES (?, ?)",
+		a.PassengerID,
+		a.EnrolledAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = int(id)
+	return nil
+}
+
+func (r *LoyaltyRepository) Find(id int) (*store.LoyaltyAccountModel, error) {
+	account := &store.LoyaltyAccountModel{}
+	if err := r.store.db.Get(account, "SELECT * FROM loyalty_account WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (r *LoyaltyRepository) FindByPassenger(passengerID int) (*store.LoyaltyAccountModel, error) {
+	account := &store.LoyaltyAccountModel{}
+	if err := r.store.db.Get(account, "SELECT * FROM loyalty_account WHERE passenger_id = ?", passengerID); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Lock locks the account until the end of the transaction, so the balance read stays valid until the miles are redeemed
+func (r *LoyaltyRepository) Lock(id int) error {
+	var locked int
+	return r.store.db.Get(&locked, "SELECT id FROM loyalty_account WHERE id = ? FOR UPDATE", id)
+}
+
+func (r *LoyaltyRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM loyalty_account WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeletedItemDoesNotExist
+	}
+	return nil
+}
+
+// FindEntries returns the ledger of the account, the earliest entries first
+func (r *LoyaltyRepository) FindEntries(accountID int) ([]store.LoyaltyEntryModel, error) {
+	var entries []store.LoyaltyEntryModel
+	if err := r.store.db.Select(&entries, "SELECT * FROM loyalty_entry WHERE account_id = ? ORDER BY created_at, id", accountID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *LoyaltyRepository) AddEntry(e *store.LoyaltyEntryModel) error {
+	res, err := r.store.db.Exec("INSERT INTO loyalty_entry (account_id, kind, miles, segment_id, purchase_id, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.AccountID,
+		e.Kind,
+		e.Miles,
+		e.SegmentID,
+		e.PurchaseID,
+		e.CreatedAt,
+		e.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(id)
+	return nil
+}
@@ -0,0 +1,310 @@
+// Файл internal\store\mysqlstore\passengerrepository.go содержит код для работы с таблицей Пассажиры
+package mysqlstore
+
+import (
+	"time"
+
+	"github.com/akionka/aviasales/internal/loyalty"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/translit"
+	"github.com/jmoiron/sqlx"
+)
+
+type PassengerRepository struct {
+	store *Store
+}
+
+func (r *PassengerRepository) Create(p *store.PassengerModel) error {
+	res, err := r.store.db.Exec("INSERT INTO passenger (last_name, given_name, birth_date, sex, contact_phone, contact_email, last_name_key, given_name_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		p.LastName,
+		p.GivenName,
+		p.BirthDate,
+		p.Sex,
+		p.ContactPhone,
+		p.ContactEmail,
+		translit.Key(p.LastName),
+		translit.Key(p.GivenName),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(id)
+	return nil
+}
+
+func (r *PassengerRepository) Find(id int) (*store.PassengerModel, error) {
+	passenger := &store.PassengerModel{}
+	if err := r.store.db.Get(passenger, "SELECT * FROM passenger WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return passenger, nil
+}
+
+// LockByDocument returns the passenger with the document and the birth date and locks the documents with the number
+// until the end of the transaction, so no other transaction adds a passenger with the document meanwhile
+func (r *PassengerRepository) LockByDocument(docType, number string, birthDate time.Time) (*store.PassengerModel, error) {
+	passenger := &store.PassengerModel{}
+	if err := r.store.db.Get(passenger, "SELECT p.* FROM passenger p INNER JOIN passenger_document d ON d.passenger_id = p.id WHERE d.type = ? AND d.number = ? AND p.birth_date = ? ORDER BY p.id LIMIT 1 FOR UPDATE",
+		docType,
+		number,
+		birthDate.Format("2006-01-02"),
+	); err != nil {
+		return nil, err
+	}
+	return passenger, nil
+}
+
+func (r *PassengerRepository) FindDocuments(id int) ([]store.DocumentModel, error) {
+	documents := []store.DocumentModel{}
+	if err := r.store.db.Select(&documents, "SELECT * FROM passenger_document WHERE passenger_id = ? ORDER BY id", id); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// SetDocuments replaces the documents of the passenger
+func (r *PassengerRepository) SetDocuments(id int, documents []store.DocumentModel) error {
+	return r.store.Transaction(func(tx store.Store) error {
+		s := tx.(*Store)
+		if _, err := s.db.Exec("DELETE FROM passenger_document WHERE passenger_id = ?", id); err != nil {
+			return err
+		}
+		for _, d := range documents {
+			if _, err := s.db.Exec("INSERT INTO passenger_document (passenger_id, type, number, issuing_country, expiry_date) VALUES (?, ?, ?, ?, ?)",
+				id,
+				d.Type,
+				d.Number,
+				d.IssuingCountry,
+				d.ExpiryDate,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PassengerRepository) FindAll(row_count, offset int) (*[]store.PassengerModel, error) {
+	if row_count < 0 {
+		row_count = 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	passengers := &[]store.PassengerModel{}
+	if err := r.store.db.Select(passengers, "SELECT * FROM passenger ORDER BY id LIMIT ?, ?", offset, row_count); err != nil {
+		return nil, err
+	}
+	return passengers, nil
+}
+
+func (r *PassengerRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.PassengerModel, error) {
+	passengers := &[]store.PassengerModel{}
+	if err := r.store.selectPage(passengers, "passenger", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return passengers, nil
+}
+
+// FindDuplicateCandidates returns the passengers sharing a document number or the birth date with another passenger
+func (r *PassengerRepository) FindDuplicateCandidates() ([]store.PassengerModel, error) {
+	var passengers []store.PassengerModel
+	if err := r.store.db.Select(&passengers, `SELECT
+	p.*
+FROM
+	passenger p
+WHERE
+	EXISTS( SELECT
+			1
+		FROM
+			passenger d
+		WHERE
+			d.id <> p.id
+				AND d.birth_date = p.birth_date)
+		OR EXISTS( SELECT
+			1
+		FROM
+			passenger_document pd
+				INNER JOIN
+			passenger_document dd ON dd.type = pd.type
+				AND dd.number = pd.number
+				AND dd.passenger_id <> pd.passenger_id
+		WHERE
+			pd.passenger_id = p.id)
+ORDER BY p.birth_date, p.id`); err != nil {
+		return nil, err
+	}
+	return passengers, nil
+}
+
+func (r *PassengerRepository) Update(id int, p *store.PassengerModel) error {
+	res, err := r.store.db.Exec("UPDATE passenger SET last_name = ?, given_name = ?, birth_date = ?, sex = ?, contact_phone = ?, contact_email = ?, last_name_key = ?, given_name_key = ? WHERE id = ?",
+		p.LastName,
+		p.GivenName,
+		p.BirthDate,
+		p.Sex,
+		p.ContactPhone,
+		p.ContactEmail,
+		translit.Key(p.LastName),
+		translit.Key(p.GivenName),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return err
+}
+
+// Merge moves the tickets, the documents and a loyalty account of the duplicates to the passenger, fills in the contacts
+// the passenger lacks from the duplicates and deletes the duplicates
+func (r *PassengerRepository) Merge(id int, duplicateIDs []int) error {
+	return r.store.Transaction(func(tx store.Store) error {
+		s := tx.(*Store)
+		passenger := &store.PassengerModel{}
+		if err := s.db.Get(passenger, "SELECT * FROM passenger WHERE id = ? FOR UPDATE", id); err != nil {
+			return err
+		}
+
+		query, args, err := sqlx.In("SELECT * FROM passenger WHERE id IN (?) AND id <> ? ORDER BY id FOR UPDATE", duplicateIDs, id)
+		if err != nil {
+			return err
+		}
+		var duplicates []store.PassengerModel
+		if err := s.db.Select(&duplicates, query, args...); err != nil {
+			return err
+		}
+		if len(duplicates) != len(duplicateIDs) {
+			return ErrMergedItemDoesNotExist
+		}
+
+		for _, d := range duplicates {
+			if passenger.ContactPhone == "" {
+				passenger.ContactPhone = d.ContactPhone
+			}
+			if passenger.ContactEmail == "" {
+				passenger.ContactEmail = d.ContactEmail
+			}
+		}
+		if _, err := s.db.Exec("UPDATE passenger SET contact_phone = ?, contact_email = ? WHERE id = ?", passenger.ContactPhone, passenger.ContactEmail, id); err != nil {
+			return err
+		}
+
+		query, args, err = sqlx.In("UPDATE ticket SET passenger_id = ? WHERE passenger_id IN (?)", id, duplicateIDs)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(query, args...); err != nil {
+			return err
+		}
+
+		query, args, err = sqlx.In("UPDATE passenger_document SET passenger_id = ? WHERE passenger_id IN (?)", id, duplicateIDs)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(query, args...); err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(`DELETE d1 FROM passenger_document d1
+			INNER JOIN
+	passenger_document d2 ON d1.passenger_id = d2.passenger_id
+		AND d1.type = d2.type
+		AND d1.number = d2.number
+		AND d1.id > d2.id
+WHERE
+	d1.passenger_id = ?`, id); err != nil {
+			return err
+		}
+
+		if err := s.mergeLoyaltyAccounts(id, duplicateIDs); err != nil {
+			return err
+		}
+
+		query, args, err = sqlx.In("DELETE FROM passenger WHERE id IN (?)", duplicateIDs)
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Exec(query, args...)
+		return err
+	})
+}
+
+// mergeLoyaltyAccounts moves the ledgers and the purchases of the loyalty accounts of the duplicates to the account
+// the passenger keeps, so the miles are not lost when the duplicates are deleted with their accounts
+func (s *Store) mergeLoyaltyAccounts(id int, duplicateIDs []int) error {
+	query, args, err := sqlx.In("SELECT * FROM loyalty_account WHERE passenger_id IN (?) ORDER BY id FOR UPDATE", append([]int{id}, duplicateIDs...))
+	if err != nil {
+		return err
+	}
+	var models []store.LoyaltyAccountModel
+	if err := s.db.Select(&models, query, args...); err != nil {
+		return err
+	}
+	accounts := make([]loyalty.Account, len(models))
+	for i, v := range models {
+		accounts[i] = loyalty.Account{ID: v.ID, PassengerID: v.PassengerID, EnrolledAt: v.EnrolledAt}
+	}
+	kept, merged, ok := loyalty.Merge(id, accounts)
+	if !ok {
+		return nil
+	}
+
+	if len(merged) > 0 {
+		for _, update := range []string{"UPDATE loyalty_entry SET account_id = ? WHERE account_id IN (?)", "UPDATE purchase SET loyalty_account_id = ? WHERE loyalty_account_id IN (?)"} {
+			query, args, err := sqlx.In(update, kept.ID, merged)
+			if err != nil {
+				return err
+			}
+			if _, err := s.db.Exec(query, args...); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = s.db.Exec("UPDATE loyalty_account SET passenger_id = ?, enrolled_at = ? WHERE id = ?", kept.PassengerID, kept.EnrolledAt, kept.ID)
+	return err
+}
+
+func (r *PassengerRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM passenger WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeletedItemDoesNotExist
+	}
+	return nil
+}
+
+func (r *PassengerRepository) TotalCount() (int, error) {
+	var count int
+	row := r.store.db.QueryRow("SELECT COUNT(*) from passenger")
+	if err := row.Scan(&count); err != nil {
+		return -1, err
+	}
+	return count, nil
+}
+
+// FillNameKeys computes search keys of the passengers created by the migration from the existing tickets
+func (r *PassengerRepository) FillNameKeys() error {
+	return r.store.fillNameKeys(nameKeyColumns{
+		table:        "passenger",
+		lastName:     "last_name",
+		givenName:    "given_name",
+		lastNameKey:  "last_name_key",
+		givenNameKey: "given_name_key",
+	})
+}
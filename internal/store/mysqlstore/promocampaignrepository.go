@@ -0,0 +1,191 @@
+// Файл internal\store\mysqlstore\promocampaignrepository.go содержит код для работы с таблицами Промокоды, их линии и классы и Использования промокодов
+package mysqlstore
+
+import "github.com/akionka/aviasales/internal/store"
+
+type PromoCampaignRepository struct {
+	store *Store
+}
+
+func (r *PromoCampaignRepository) Create(c *store.PromoCampaignModel) error {
+	return r.store.Transaction(func(tx store.Store) error {
+		s := tx.(*Store)
+		if _, err := s.db.Exec(`INSERT INTO promo_campaign
+	(code, name, kind, percent, amount, currency, valid_from, valid_to, max_uses, max_uses_per_customer)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.Code,
+			c.Name,
+			c.Kind,
+			c.Percent,
+			c.Amount,
+			c.Currency,
+			c.ValidFrom,
+			c.ValidTo,
+			c.MaxUses,
+			c.MaxUsesPerCustomer,
+		); err != nil {
+			return err
+		}
+		return s.PromoCampaign().(*PromoCampaignRepository).setEligibility(c)
+	})
+}
+
+// setEligibility replaces the lines and the classes the code is valid on
+func (r *PromoCampaignRepository) setEligibility(c *store.PromoCampaignModel) error {
+	if _, err := r.store.db.Exec("DELETE FROM promo_campaign_line WHERE code = ?", c.Code); err != nil {
+		return err
+	}
+	if _, err := r.store.db.Exec("DELETE FROM promo_campaign_class WHERE code = ?", c.Code); err != nil {
+		return err
+	}
+	for _, l := range c.Lines {
+		if _, err := r.store.db.Exec("INSERT INTO promo_campaign_line (code, line_code) VALUES (?, ?)", c.Code, l); err != nil {
+			return err
+		}
+	}
+	for _, class := range c.Classes {
+		if _, err := r.store.db.Exec("INSERT INTO promo_campaign_class (code, class) VALUES (?, ?)", c.Code, class); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEligibility reads the lines and the classes the code is valid on
+func (r *PromoCampaignRepository) findEligibility(c *store.PromoCampaignModel) error {
+	if err := r.store.db.Select(&c.Lines, "SELECT line_code FROM promo_campaign_line WHERE code = ? ORDER BY line_code", c.Code); err != nil {
+		return err
+	}
+	return r.store.db.Select(&c.Classes, "SELECT class FROM promo_campaign_class WHERE code = ? ORDER BY class", c.Code)
+}
+
+func (r *PromoCampaignRepository) Find(code string) (*store.PromoCampaignModel, error) {
+	campaign := &store.PromoCampaignModel{}
+	if err := r.store.db.Get(campaign, "SELECT * FROM promo_campaign WHERE code = ?", code); err != nil {
+		return nil, err
+	}
+	if err := campaign.ApplyCurrency(); err != nil {
+		return nil, err
+	}
+	if err := r.findEligibility(campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// FindAll returns all the campaigns, the latest first
+func (r *PromoCampaignRepository) FindAll() ([]store.PromoCampaignModel, error) {
+	var campaigns []store.PromoCampaignModel
+	if err := r.store.db.Select(&campaigns, "SELECT * FROM promo_campaign ORDER BY valid_from DESC, code"); err != nil {
+		return nil, err
+	}
+	for i := range campaigns {
+		if err := campaigns[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+		if err := r.findEligibility(&campaigns[i]); err != nil {
+			return nil, err
+		}
+	}
+	return campaigns, nil
+}
+
+func (r *PromoCampaignRepository) Update(code string, c *store.PromoCampaignModel) error {
+	return r.store.Transaction(func(tx store.Store) error {
+		s := tx.(*Store)
+		res, err := s.db.Exec(`UPDATE promo_campaign SET code = ?, name = ?, kind = ?, percent = ?, amount = ?, currency = ?,
+	valid_from = ?, valid_to = ?, max_uses = ?, max_uses_per_customer = ? WHERE code = ?`,
+			c.Code,
+			c.Name,
+			c.Kind,
+			c.Percent,
+			c.Amount,
+			c.Currency,
+			c.ValidFrom,
+			c.ValidTo,
+			c.MaxUses,
+			c.MaxUsesPerCustomer,
+			code,
+		)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if err := s.PromoCampaign().(*PromoCampaignRepository).setEligibility(c); err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNoChanges
+		}
+		return nil
+	})
+}
+
+func (r *PromoCampaignRepository) Delete(code string) error {
+	res, err := r.store.db.Exec("DELETE FROM promo_campaign WHERE code = ?", code)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDeletedItemDoesNotExist
+	}
+	return nil
+}
+
+// CountUses returns the count of the uses of the code in total and by the customer. The campaign is locked
+// until the end of the transaction, so the uses counted stay valid until the next one is added
+func (r *PromoCampaignRepository) CountUses(code, customer string) (total, byCustomer int, err error) {
+	var locked string
+	if err := r.store.db.Get(&locked, "SELECT code FROM promo_campaign WHERE code = ? FOR UPDATE", code); err != nil {
+		return 0, 0, err
+	}
+	row := r.store.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(customer = ?), 0) FROM promo_usage WHERE code = ?", customer, code)
+	if err := row.Scan(&total, &byCustomer); err != nil {
+		return 0, 0, err
+	}
+	return total, byCustomer, nil
+}
+
+func (r *PromoCampaignRepository) AddUsage(u *store.PromoUsageModel) error {
+	res, err := r.store.db.Exec("INSERT INTO promo_usage (code, purchase_id, customer, discount, currency, base_discount, used_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		u.Code,
+		u.PurchaseID,
+		u.Customer,
+		u.Discount,
+		u.Currency,
+		u.BaseDiscount,
+		u.UsedAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = int(id)
+	return nil
+}
+
+// FindUsages returns the uses of the code with the totals of the purchases, the earliest first
+func (r *PromoCampaignRepository) FindUsages(code string) ([]store.PromoUsageModel, error) {
+	var usages []store.PromoUsageModel
+	if err := r.store.db.Select(&usages, `SELECT u.*, p.base_total_price FROM promo_usage u
+	JOIN purchase p ON p.id = u.purchase_id
+	WHERE u.code = ? ORDER BY u.used_at, u.id`, code); err != nil {
+		return nil, err
+	}
+	for i := range usages {
+		if err := usages[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return usages, nil
+}
@@ -1,22 +1,44 @@
 // Файл internal\store\mysqlstore\linermodelrepository.go содержит код для работы с таблицей Покупки
 package mysqlstore
 
-import "github.com/akionka/aviasales/internal/store"
+import (
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/jmoiron/sqlx"
+)
 
 type PurchaseRepository struct {
 	store *Store
 }
 
 func (r *PurchaseRepository) Create(p *store.PurchaseModel) error {
-	_, err := r.store.db.Exec("INSERT INTO purchase (date, booking_office_id, total_price, contact_phone, contact_email, cashier_login) VALUES (?, ?, ?, ?, ?, ?)",
+	res, err := r.store.db.Exec("INSERT INTO purchase (date, booking_office_id, total_price, contact_phone, contact_email, cashier_login, currency, exchange_rate, rate_date, base_total_price, promo_code, discount, loyalty_account_id, redeemed_miles, miles_amount, service_fee, service_fee_currency) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		p.Date,
 		p.BookingOfficeID,
 		p.TotalPrice,
 		p.ContactPhone,
 		p.ContactEmail,
 		p.CashierID,
+		p.Currency,
+		p.ExchangeRate,
+		p.RateDate,
+		p.BaseTotalPrice,
+		p.PromoCode,
+		p.Discount,
+		p.LoyaltyAccountID,
+		p.RedeemedMiles,
+		p.MilesAmount,
+		p.ServiceFee,
+		p.ServiceFeeCurrency,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(id)
+	return nil
 }
 
 func (r *PurchaseRepository) Find(id int) (*store.PurchaseModel, error) {
@@ -24,9 +46,33 @@ func (r *PurchaseRepository) Find(id int) (*store.PurchaseModel, error) {
 	if err := r.store.db.Get(purchase, "SELECT * FROM purchase WHERE id = ?", id); err != nil {
 		return nil, err
 	}
+	if err := purchase.ApplyCurrency(); err != nil {
+		return nil, err
+	}
 	return purchase, nil
 }
 
+// FindByIDs returns the purchases with the IDs
+func (r *PurchaseRepository) FindByIDs(ids []int) ([]store.PurchaseModel, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In("SELECT * FROM purchase WHERE id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return nil, err
+	}
+	var purchases []store.PurchaseModel
+	if err := r.store.db.Select(&purchases, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range purchases {
+		if err := purchases[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return purchases, nil
+}
+
 func (r *PurchaseRepository) FindAll(row_count, offset int) (*[]store.PurchaseModel, error) {
 	if row_count < 0 {
 		row_count = 0
@@ -38,11 +84,29 @@ func (r *PurchaseRepository) FindAll(row_count, offset int) (*[]store.PurchaseMo
 	if err := r.store.db.Select(purchases, "SELECT * FROM purchase ORDER BY id LIMIT ?, ?", offset, row_count); err != nil {
 		return nil, err
 	}
+	for i := range *purchases {
+		if err := (*purchases)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return purchases, nil
+}
+
+func (r *PurchaseRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.PurchaseModel, error) {
+	purchases := &[]store.PurchaseModel{}
+	if err := r.store.selectPage(purchases, "purchase", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	for i := range *purchases {
+		if err := (*purchases)[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
 	return purchases, nil
 }
 
 func (r *PurchaseRepository) Update(id int, p *store.PurchaseModel) error {
-	res, err := r.store.db.Exec("UPDATE purchase SET id = ?, date = ?, booking_office_id = ?, total_price = ?, contact_phone = ?, contact_email = ?, cashier_id = ? WHERE id = ?",
+	res, err := r.store.db.Exec("UPDATE purchase SET id = ?, date = ?, booking_office_id = ?, total_price = ?, contact_phone = ?, contact_email = ?, cashier_id = ?, currency = ?, exchange_rate = ?, rate_date = ?, base_total_price = ?, promo_code = ?, discount = ?, loyalty_account_id = ?, redeemed_miles = ?, miles_amount = ? WHERE id = ?",
 		p.ID,
 		p.Date,
 		p.BookingOfficeID,
@@ -50,6 +114,15 @@ func (r *PurchaseRepository) Update(id int, p *store.PurchaseModel) error {
 		p.ContactPhone,
 		p.ContactEmail,
 		p.CashierID,
+		p.Currency,
+		p.ExchangeRate,
+		p.RateDate,
+		p.BaseTotalPrice,
+		p.PromoCode,
+		p.Discount,
+		p.LoyaltyAccountID,
+		p.RedeemedMiles,
+		p.MilesAmount,
 		id,
 	)
 	if err != nil {
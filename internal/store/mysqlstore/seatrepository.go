@@ -25,6 +25,23 @@ func (r *SeatRepository) Find(id int) (*store.SeatModel, error) {
 	return seat, nil
 }
 
+func (r *SeatRepository) FindByModel(modelCode string) ([]store.SeatModel, error) {
+	var seats []store.SeatModel
+	if err := r.store.db.Select(&seats, "SELECT * FROM seat WHERE model_code = ? ORDER BY id", modelCode); err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+// FindSold returns the ids of the seats of the model that are taken by tickets
+func (r *SeatRepository) FindSold(modelCode string) ([]int, error) {
+	var ids []int
+	if err := r.store.db.Select(&ids, "SELECT DISTINCT s.id FROM seat s INNER JOIN flight_in_ticket fit ON fit.seat_id = s.id WHERE s.model_code = ?", modelCode); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *SeatRepository) FindAll(row_count, offset int) (*[]store.SeatModel, error) {
 	if row_count < 0 {
 		row_count = 0
@@ -39,6 +56,14 @@ func (r *SeatRepository) FindAll(row_count, offset int) (*[]store.SeatModel, err
 	return seats, nil
 }
 
+func (r *SeatRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.SeatModel, error) {
+	seats := &[]store.SeatModel{}
+	if err := r.store.selectPage(seats, "seat", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
 func (r *SeatRepository) Update(id int, s *store.SeatModel) error {
 	res, err := r.store.db.Exec("UPDATE seat SET id = ?, number = ?, class = ?, model_code = ? WHERE id = ?",
 		s.ID,
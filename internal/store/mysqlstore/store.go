@@ -2,17 +2,32 @@
 package mysqlstore
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/translit"
 	"github.com/jmoiron/sqlx"
 )
 
 var ErrDeletedItemDoesNotExist = errors.New("the item you delete does not exist")
 var ErrNoChanges = errors.New("the item you update did not change")
+var ErrMergedItemDoesNotExist = errors.New("the item you merge does not exist")
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx so the repositories work the same way inside transactions
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
 type Store struct {
-	db                       *sqlx.DB
+	conn                     *sqlx.DB
+	db                       queryer
 	airportRepository        *AirportRepository
 	bookingOfficeRepository  *BookingOfficeRepository
 	cashierRepository        *CashierRepository
@@ -21,18 +36,41 @@ type Store struct {
 	lineRepository           *LineRepository
 	linerRepository          *LinerRepository
 	linerModelRepository     *LinerModelRepository
+	passengerRepository      *PassengerRepository
 	purchaseRepository       *PurchaseRepository
 	seatRepository           *SeatRepository
 	ticketRepository         *TicketRepository
-	timezoneRepository       *TimezoneRepository
+	waitlistRepository       *WaitlistRepository
+	bookingClassRepository   *BookingClassRepository
+	exchangeRateRepository   *ExchangeRateRepository
+	promoCampaignRepository  *PromoCampaignRepository
+	loyaltyRepository        *LoyaltyRepository
 }
 
 func New(db *sqlx.DB) *Store {
 	return &Store{
-		db: db,
+		conn: db,
+		db:   db,
 	}
 }
 
+// Transaction runs fn with a store whose repositories share one transaction. The transaction is committed
+// if fn returns nil and rolled back otherwise. Nested calls run in the outer transaction
+func (s *Store) Transaction(fn func(store.Store) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+	tx, err := s.conn.Beginx()
+	if err != nil {
+		return err
+	}
+	if err := fn(&Store{db: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func (s *Store) Airport() store.AirportRepository {
 	if s.airportRepository != nil {
 		return s.airportRepository
@@ -113,6 +151,16 @@ func (s *Store) LinerModel() store.LinerModelRepository {
 	return s.linerModelRepository
 }
 
+func (s *Store) Passenger() store.PassengerRepository {
+	if s.passengerRepository != nil {
+		return s.passengerRepository
+	}
+	s.passengerRepository = &PassengerRepository{
+		store: s,
+	}
+	return s.passengerRepository
+}
+
 func (s *Store) Purchase() store.PurchaseRepository {
 	if s.purchaseRepository != nil {
 		return s.purchaseRepository
@@ -143,12 +191,112 @@ func (s *Store) Ticket() store.TicketRepository {
 	return s.ticketRepository
 }
 
-func (s *Store) Timezone() store.TimezoneRepository {
-	if s.timezoneRepository != nil {
-		return s.timezoneRepository
+func (s *Store) Waitlist() store.WaitlistRepository {
+	if s.waitlistRepository != nil {
+		return s.waitlistRepository
+	}
+	s.waitlistRepository = &WaitlistRepository{
+		store: s,
+	}
+	return s.waitlistRepository
+}
+
+func (s *Store) BookingClass() store.BookingClassRepository {
+	if s.bookingClassRepository != nil {
+		return s.bookingClassRepository
+	}
+	s.bookingClassRepository = &BookingClassRepository{
+		store: s,
+	}
+	return s.bookingClassRepository
+}
+
+func (s *Store) ExchangeRate() store.ExchangeRateRepository {
+	if s.exchangeRateRepository != nil {
+		return s.exchangeRateRepository
 	}
-	s.timezoneRepository = &TimezoneRepository{
+	s.exchangeRateRepository = &ExchangeRateRepository{
 		store: s,
 	}
-	return s.timezoneRepository
+	return s.exchangeRateRepository
+}
+
+func (s *Store) PromoCampaign() store.PromoCampaignRepository {
+	if s.promoCampaignRepository != nil {
+		return s.promoCampaignRepository
+	}
+	s.promoCampaignRepository = &PromoCampaignRepository{
+		store: s,
+	}
+	return s.promoCampaignRepository
+}
+
+func (s *Store) Loyalty() store.LoyaltyRepository {
+	if s.loyaltyRepository != nil {
+		return s.loyaltyRepository
+	}
+	s.loyaltyRepository = &LoyaltyRepository{
+		store: s,
+	}
+	return s.loyaltyRepository
+}
+
+// selectPage selects up to row_count rows of the table ordered by the key column using keyset pagination
+func (s *Store) selectPage(dest interface{}, table, key string, cursor *store.Cursor, row_count int) error {
+	if row_count < 0 {
+		row_count = 0
+	}
+	switch {
+	case cursor == nil:
+		return s.db.Select(dest, fmt.Sprintf("SELECT * FROM %s ORDER BY %s LIMIT ?", table, key), row_count)
+	case cursor.Backward:
+		return s.db.Select(dest, fmt.Sprintf("SELECT * FROM (SELECT * FROM %[1]s WHERE %[2]s < ? ORDER BY %[2]s DESC LIMIT ?) page ORDER BY %[2]s", table, key), cursor.Key, row_count)
+	default:
+		return s.db.Select(dest, fmt.Sprintf("SELECT * FROM %[1]s WHERE %[2]s > ? ORDER BY %[2]s LIMIT ?", table, key), cursor.Key, row_count)
+	}
+}
+
+// Rows of the name keys are filled in batches of that many
+const nameKeyBatch = 500
+
+// nameKeyColumns are the columns of the names of a table and of their search keys
+type nameKeyColumns struct {
+	table, lastName, givenName, lastNameKey, givenNameKey string
+}
+
+// fillNameKeys computes the search keys of the rows of the table with an empty key, a batch of rows
+// with a statement. Every row is read once, the rows of names with no key are not read again
+func (s *Store) fillNameKeys(c nameKeyColumns) error {
+	var names []struct {
+		ID        int    `db:"id"`
+		LastName  string `db:"last_name"`
+		GivenName string `db:"given_name"`
+	}
+	for lastID := 0; ; lastID = names[len(names)-1].ID {
+		names = names[:0]
+		if err := s.db.Select(&names, fmt.Sprintf("SELECT id, %s last_name, %s given_name FROM %s WHERE id > ? AND (%s = '' OR %s = '') ORDER BY id LIMIT ?",
+			c.lastName, c.givenName, c.table, c.lastNameKey, c.givenNameKey), lastID, nameKeyBatch); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		cases := strings.Repeat(" WHEN ? THEN ?", len(names))
+		in := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+		args := make([]interface{}, 0, 5*len(names))
+		for _, n := range names {
+			args = append(args, n.ID, translit.Key(n.LastName))
+		}
+		for _, n := range names {
+			args = append(args, n.ID, translit.Key(n.GivenName))
+		}
+		for _, n := range names {
+			args = append(args, n.ID)
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("UPDATE %s SET %s = CASE id%s END, %s = CASE id%s END WHERE id IN (%s)",
+			c.table, c.lastNameKey, cases, c.givenNameKey, cases, in), args...); err != nil {
+			return err
+		}
+	}
 }
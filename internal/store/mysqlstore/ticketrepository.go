@@ -2,9 +2,13 @@
 package mysqlstore
 
 import (
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/akionka/aviasales/internal/schedule"
 	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/translit"
 )
 
 type TicketRepository struct {
@@ -12,13 +16,20 @@ type TicketRepository struct {
 }
 
 func (r *TicketRepository) Create(t *store.TicketModel) error {
-	_, err := r.store.db.Exec("INSERT INTO ticket (pass_last_name, pass_given_name, pass_birth_date, pass_passport_number, pass_sex, purchase_id) VALUES (?, ?, ?, ?, ?, ?)",
+	_, err := r.store.db.Exec("INSERT INTO ticket (pass_last_name, pass_given_name, pass_birth_date, pass_passport_number, pass_sex, purchase_id, pass_last_name_key, pass_given_name_key, passenger_id, pass_document_type, pass_document_country, pass_document_expiry, accompanying_ticket_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		t.PassengerLastName,
 		t.PassengerGivenName,
 		t.PassengerBirthDate,
 		t.PassengerPassportNumber,
 		t.PassengerSex,
 		t.PurchaseID,
+		translit.Key(t.PassengerLastName),
+		translit.Key(t.PassengerGivenName),
+		t.PassengerID,
+		t.PassengerDocumentType,
+		t.PassengerDocumentCountry,
+		t.PassengerDocumentExpiry,
+		t.AccompanyingTicketID,
 	)
 	return err
 }
@@ -45,14 +56,69 @@ func (r *TicketRepository) FindAll(row_count, offset int) (*[]store.TicketModel,
 	return tickets, nil
 }
 
+func (r *TicketRepository) FindPage(cursor *store.Cursor, row_count int) (*[]store.TicketModel, error) {
+	tickets := &[]store.TicketModel{}
+	if err := r.store.selectPage(tickets, "ticket", "id", cursor, row_count); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (r *TicketRepository) Search(q *store.PassengerQuery, limit int) ([]store.TicketModel, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	for _, key := range q.NameKeys {
+		conditions = append(conditions, "(pass_last_name_key LIKE ? OR pass_given_name_key LIKE ?)")
+		args = append(args, key+"%", key+"%")
+	}
+	if q.DocumentNumber != "" {
+		conditions = append(conditions, "pass_passport_number = ?")
+		args = append(args, q.DocumentNumber)
+	}
+	if !q.BirthDate.IsZero() {
+		conditions = append(conditions, "pass_birth_date = ?")
+		args = append(args, q.BirthDate.Format("2006-01-02"))
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	args = append(args, limit)
+
+	var tickets []store.TicketModel
+	if err := r.store.db.Select(&tickets, "SELECT * FROM ticket WHERE "+strings.Join(conditions, " AND ")+" ORDER BY purchase_id, id LIMIT ?", args...); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// FillNameKeys computes search keys of the tickets created before the keys were introduced
+func (r *TicketRepository) FillNameKeys() error {
+	return r.store.fillNameKeys(nameKeyColumns{
+		table:        "ticket",
+		lastName:     "pass_last_name",
+		givenName:    "pass_given_name",
+		lastNameKey:  "pass_last_name_key",
+		givenNameKey: "pass_given_name_key",
+	})
+}
+
 func (r *TicketRepository) Update(id int, t *store.TicketModel) error {
-	res, err := r.store.db.Exec("UPDATE ticket SET pass_last_name = ?, pass_given_name = ?, pass_birth_date = ?, pass_passport_number = ?, pass_sex = ?, purchase_id = ? WHERE id = ?",
+	res, err := r.store.db.Exec("UPDATE ticket SET pass_last_name = ?, pass_given_name = ?, pass_birth_date = ?, pass_passport_number = ?, pass_sex = ?, purchase_id = ?, pass_last_name_key = ?, pass_given_name_key = ?, passenger_id = ?, pass_document_type = ?, pass_document_country = ?, pass_document_expiry = ?, accompanying_ticket_id = ? WHERE id = ?",
 		t.PassengerLastName,
 		t.PassengerGivenName,
 		t.PassengerBirthDate,
 		t.PassengerPassportNumber,
 		t.PassengerSex,
 		t.PurchaseID,
+		translit.Key(t.PassengerLastName),
+		translit.Key(t.PassengerGivenName),
+		t.PassengerID,
+		t.PassengerDocumentType,
+		t.PassengerDocumentCountry,
+		t.PassengerDocumentExpiry,
+		t.AccompanyingTicketID,
 		id,
 	)
 	if err != nil {
@@ -92,45 +158,104 @@ func (r *TicketRepository) TotalCount() (int, error) {
 	return count, nil
 }
 
-func (r *TicketRepository) Report(id int) ([]*store.TicketReportFlightModel, *store.BookingOfficeModel, *store.CashierModel, *store.PurchaseModel, time.Duration, error) {
-	var flights []*store.TicketReportFlightModel
-	var office store.BookingOfficeModel
-	var cashier store.CashierModel
-	var purchase store.PurchaseModel
-	var totalTime time.Duration
-	totalTime = 0
+// AddOperation records a refund or an exchange of a segment of the ticket
+func (r *TicketRepository) AddOperation(o *store.TicketOperationModel) error {
+	res, err := r.store.db.Exec(`INSERT INTO ticket_operation
+	(ticket_id, operation, flight_id, booking_class, fare, new_flight_id, new_booking_class, new_fare, taxes, new_taxes, fee, amount, involuntary, cashier_id, created_at, currency)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		o.TicketID,
+		o.Operation,
+		o.FlightID,
+		o.BookingClass,
+		o.Fare,
+		o.NewFlightID,
+		o.NewBookingClass,
+		o.NewFare,
+		o.Taxes,
+		o.NewTaxes,
+		o.Fee,
+		o.Amount,
+		o.Involuntary,
+		o.CashierID,
+		o.CreatedAt,
+		o.Currency,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	o.ID = int(id)
+	return nil
+}
 
-	rows, err := r.store.db.Queryx(`SELECT
+// FindOperations returns the refunds and exchanges of the ticket in the order they were made
+func (r *TicketRepository) FindOperations(ticketID int) ([]store.TicketOperationModel, error) {
+	var operations []store.TicketOperationModel
+	if err := r.store.db.Select(&operations, "SELECT * FROM ticket_operation WHERE ticket_id = ? ORDER BY created_at, id", ticketID); err != nil {
+		return nil, err
+	}
+	for i := range operations {
+		if err := operations[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return operations, nil
+}
+
+// FindPurchaseOperations returns the refunds and exchanges of the tickets of the purchase in the order they were made
+func (r *TicketRepository) FindPurchaseOperations(purchaseID int) ([]store.TicketOperationModel, error) {
+	var operations []store.TicketOperationModel
+	if err := r.store.db.Select(&operations, "SELECT o.* FROM ticket_operation o INNER JOIN ticket t ON t.id = o.ticket_id WHERE t.purchase_id = ? ORDER BY o.created_at, o.id", purchaseID); err != nil {
+		return nil, err
+	}
+	for i := range operations {
+		if err := operations[i].ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return operations, nil
+}
+
+// FindByPurchase returns the tickets of the purchase
+func (r *TicketRepository) FindByPurchase(purchaseID int) ([]store.TicketModel, error) {
+	var tickets []store.TicketModel
+	if err := r.store.db.Select(&tickets, "SELECT * FROM ticket WHERE purchase_id = ? ORDER BY id", purchaseID); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// reportFlightQuery selects the flights of tickets for the reports, to be followed by the condition
+const reportFlightQuery = `SELECT
+	t.id ticket_id,
 	a1.city dep_city,
 	a2.city arr_city,
-	ADDTIME(f.dep_date, l.dep_time) dep_time_local,
-	IF(CONVERT_TZ(ADDTIME(f.dep_date, l.dep_time),
-							a1.timezone,
-							'GMT') > CONVERT_TZ(ADDTIME(f.dep_date, l.arr_time),
-							a2.timezone,
-							'GMT'),
-			DATE_ADD(ADDTIME(f.dep_date, l.arr_time),
-					INTERVAL 1 DAY),
-			ADDTIME(f.dep_date, l.arr_time)) arr_time_local_fixed,
-	CONVERT_TZ(ADDTIME(f.dep_date, l.dep_time),
-					a1.timezone,
-					'GMT') dep_time_gmt,
-	IF(CONVERT_TZ(ADDTIME(f.dep_date, l.dep_time),
-							a1.timezone,
-							'GMT') > CONVERT_TZ(ADDTIME(f.dep_date, l.arr_time),
-							a2.timezone,
-							'GMT'),
-			CONVERT_TZ(DATE_ADD(ADDTIME(f.dep_date, l.arr_time),
-									INTERVAL 1 DAY),
-							a2.timezone,
-							'GMT'),
-			CONVERT_TZ(ADDTIME(f.dep_date, l.arr_time),
-							a2.timezone,
-							'GMT')) arr_time_gmt_fixed,
+	a1.timezone dep_timezone,
+	a2.timezone arr_timezone,
+	f.dep_date,
+	l.dep_time,
+	l.arr_time,
+	l.arr_day_offset,
 	l.line_code,
-	s.number,
-	s.class,
-    (l.base_price * (1-0.5*f.is_hot) * CASE WHEN s.class = 'J' THEN 2 WHEN s.class = 'Y' THEN 1.5 WHEN s.class = 'W' THEN 1 END) 'price'
+	COALESCE(s.number, '') number,
+	fit.class,
+	COALESCE(fit.booking_class, '') booking_class,
+	bc.fare_multiplier,
+	f.id flight_id,
+	l.base_price,
+	l.fuel_surcharge,
+	l.currency,
+	l.dep_airport,
+	l.arr_airport,
+	f.is_hot,
+	s.id IS NOT NULL with_seat,
+	fit.id segment_id,
+	fit.fare sold_fare,
+	fit.fuel_surcharge sold_fuel_surcharge,
+	fit.fare_currency sold_currency
 FROM
 	ticket t
 			INNER JOIN
@@ -143,26 +268,79 @@ FROM
 	airport a1 ON l.dep_airport = a1.iata_code
 			INNER JOIN
 	airport a2 ON l.arr_airport = a2.iata_code
-			INNER JOIN
+			LEFT JOIN
 	seat s ON s.id = fit.seat_id
+			LEFT JOIN
+	booking_class bc ON bc.code = fit.booking_class`
+
+// ReportPurchaseFlights returns the flights of all the tickets of the purchase for the reports ordered by the ticket,
+// without the local times
+func (r *TicketRepository) ReportPurchaseFlights(purchaseID int) ([]*store.TicketReportFlightModel, error) {
+	var flights []*store.TicketReportFlightModel
+	if err := r.store.db.Select(&flights, reportFlightQuery+`
 WHERE
-	t.id = ?
-ORDER BY dep_time`, id)
-	if err != nil {
+	t.purchase_id = ?
+ORDER BY t.id, f.dep_date, l.dep_time`, purchaseID); err != nil {
+		return nil, err
+	}
+	for _, f := range flights {
+		if err := f.ApplyCurrency(); err != nil {
+			return nil, err
+		}
+	}
+	return flights, nil
+}
+
+func (r *TicketRepository) Report(id int) ([]*store.TicketReportFlightModel, *store.BookingOfficeModel, *store.CashierModel, *store.PurchaseModel, time.Duration, error) {
+	var flights []*store.TicketReportFlightModel
+	var office store.BookingOfficeModel
+	var cashier store.CashierModel
+	var purchase store.PurchaseModel
+	var totalTime time.Duration
+	totalTime = 0
+
+	if err := r.store.db.Select(&flights, reportFlightQuery+`
+WHERE
+	t.id = ?`, id); err != nil {
 		return flights, &office, &cashier, &purchase, totalTime, err
 	}
 
-	for rows.Next() {
-		var f store.TicketReportFlightModel
-		rows.Scan(&f.DepCity, &f.ArrCity, &f.DepTimeLocal, &f.ArrTimeLocal, &f.DepTimeGMT, &f.ArrTimeGMT, &f.LineCode, &f.SeatNumber, &f.SeatClass, &f.Price)
-		flights = append(flights, &f)
+	for _, f := range flights {
+		if err := f.ApplyCurrency(); err != nil {
+			return flights, &office, &cashier, &purchase, totalTime, err
+		}
+		dep, arr, err := schedule.Times(f.DepDate, f.DepTime, f.ArrTime, f.ArrDayOffset, f.DepTimezone, f.ArrTimezone)
+		if err != nil {
+			return flights, &office, &cashier, &purchase, totalTime, err
+		}
+		// Local times are the wall clock at the airports written as UTC, as the clients expect
+		f.DepTimeLocal = wallClock(dep)
+		f.ArrTimeLocal = wallClock(arr)
+		f.DepTimeGMT = dep.UTC()
+		f.ArrTimeGMT = arr.UTC()
 	}
+	sort.Slice(flights, func(i, j int) bool {
+		return flights[i].DepTimeGMT.Before(flights[j].DepTimeGMT)
+	})
 
 	r.store.db.Get(&purchase, "SELECT p.* FROM ticket t INNER JOIN purchase p ON t.purchase_id = p.id WHERE t.id = ?", id)
 	r.store.db.Get(&office, "SELECT b.* FROM ticket t INNER JOIN purchase p ON t.purchase_id = p.id INNER JOIN booking_office b ON p.booking_office_id = b.id WHERE t.id = ?", id)
 	r.store.db.Get(&cashier, "SELECT c.* FROM ticket t INNER JOIN purchase p ON t.purchase_id = p.id INNER JOIN cashier c ON p.cashier_id = c.id WHERE t.id = ?", id)
+	if err := purchase.ApplyCurrency(); err != nil {
+		return flights, &office, &cashier, &purchase, totalTime, err
+	}
+	if err := office.ApplyCurrency(); err != nil {
+		return flights, &office, &cashier, &purchase, totalTime, err
+	}
 
-	totalTime = flights[len(flights)-1].ArrTimeGMT.Sub(flights[0].DepTimeGMT)
+	if len(flights) > 0 {
+		totalTime = flights[len(flights)-1].ArrTimeGMT.Sub(flights[0].DepTimeGMT)
+	}
 
 	return flights, &office, &cashier, &purchase, totalTime, nil
 }
+
+func wallClock(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
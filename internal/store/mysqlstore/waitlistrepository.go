@@ -0,0 +1,93 @@
+// Файл internal\store\mysqlstore\waitlistrepository.go содержит код для работы с таблицей Очередь ожидания
+package mysqlstore
+
+import (
+	"github.com/akionka/aviasales/internal/store"
+)
+
+type WaitlistRepository struct {
+	store *Store
+}
+
+func (r *WaitlistRepository) Create(e *store.WaitlistEntryModel) error {
+	res, err := r.store.db.Exec(`INSERT INTO waitlist_entry
+	(flight_id, class, ticket_id, priority, status, created_at, cashier_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.FlightID,
+		e.Class,
+		e.TicketID,
+		e.Priority,
+		e.Status,
+		e.CreatedAt,
+		e.CashierID,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(id)
+	return nil
+}
+
+func (r *WaitlistRepository) Find(id int) (*store.WaitlistEntryModel, error) {
+	entry := &store.WaitlistEntryModel{}
+	if err := r.store.db.Get(entry, "SELECT * FROM waitlist_entry WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Lock returns the entry and locks it until the end of the transaction
+func (r *WaitlistRepository) Lock(id int) (*store.WaitlistEntryModel, error) {
+	entry := &store.WaitlistEntryModel{}
+	if err := r.store.db.Get(entry, "SELECT * FROM waitlist_entry WHERE id = ? FOR UPDATE", id); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// FindByFlight returns the entries of the waitlists of the flight, the ones in the waitlist first by priority
+func (r *WaitlistRepository) FindByFlight(flightID int) ([]store.WaitlistEntryModel, error) {
+	var entries []store.WaitlistEntryModel
+	if err := r.store.db.Select(&entries, `SELECT * FROM waitlist_entry WHERE flight_id = ?
+ORDER BY status NOT IN ('waiting', 'offered'), class, priority DESC, created_at, id`, flightID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindOpenFlights returns the flights that have passengers waiting for a seat or holding an offer
+func (r *WaitlistRepository) FindOpenFlights() ([]int, error) {
+	var flights []int
+	if err := r.store.db.Select(&flights, "SELECT DISTINCT flight_id FROM waitlist_entry WHERE status IN ('waiting', 'offered') ORDER BY flight_id"); err != nil {
+		return nil, err
+	}
+	return flights, nil
+}
+
+func (r *WaitlistRepository) Update(id int, e *store.WaitlistEntryModel) error {
+	res, err := r.store.db.Exec(`UPDATE waitlist_entry SET
+	priority = ?, status = ?, seat_id = ?, offered_at = ?, offer_expires_at = ?
+WHERE id = ?`,
+		e.Priority,
+		e.Status,
+		e.SeatID,
+		e.OfferedAt,
+		e.OfferExpiresAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoChanges
+	}
+	return nil
+}
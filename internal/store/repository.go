@@ -7,15 +7,19 @@ type AirportRepository interface {
 	Create(*AirportModel) error
 	Find(code string) (*AirportModel, error)
 	FindAll(row_count, offset int) (*[]AirportModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]AirportModel, error)
 	Update(code string, a *AirportModel) error
 	Delete(code string) error
 	TotalCount() (int, error)
+	FindTaxes(code string) ([]AirportTaxModel, error)
+	SetTaxes(code string, taxes []AirportTaxModel) error
 }
 
 type BookingOfficeRepository interface {
 	Create(*BookingOfficeModel) error
 	Find(id int) (*BookingOfficeModel, error)
 	FindAll(row_count, offset int) (*[]BookingOfficeModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]BookingOfficeModel, error)
 	Update(id int, o *BookingOfficeModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
@@ -26,6 +30,7 @@ type CashierRepository interface {
 	Find(id int) (*CashierModel, error)
 	FindByLogin(login string) (*CashierModel, error)
 	FindAll(row_count, offset int) (*[]CashierModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]CashierModel, error)
 	Update(id int, c *CashierModel) error
 	UpdatePassword(*CashierModel) error
 	Delete(id int) error
@@ -36,6 +41,21 @@ type FlightInTicketRepository interface {
 	Create(*FlightInTicketModel) error
 	Find(id int) (*FlightInTicketModel, error)
 	FindAll(row_count, offset int) (*[]FlightInTicketModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]FlightInTicketModel, error)
+	FindOnFlight(ticketID, flightID int) (*FlightInTicketModel, error)
+	FindSegments(ticketID int) ([]SegmentModel, error)
+	FindTicketsSegments(ticketIDs []int) ([]SegmentModel, error)
+	FindFlightSegments(flightID int) ([]SegmentModel, error)
+	FindTaxes(id int) ([]SegmentTaxModel, error)
+	FindSegmentsTaxes(ids []int) ([]SegmentTaxModel, error)
+	SetTaxes(id int, taxes []SegmentTaxModel) error
+	LockFlight(flightID int) error
+	FindManifest(flightID int) ([]ManifestEntryModel, error)
+	FindCompanions(flightID, ticketID int) ([]TicketModel, error)
+	CountInfants(flightID, escortTicketID, exceptID int) (int, error)
+	FindByCheckInSequence(flightID, sequence int) (*FlightInTicketModel, error)
+	CheckIn(id int, at time.Time) (int, error)
+	Board(id int, at time.Time) error
 	Update(id int, f *FlightInTicketModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
@@ -44,8 +64,17 @@ type FlightInTicketRepository interface {
 type FlightRepository interface {
 	Create(*FlightModel) error
 	Find(id int) (*FlightModel, error)
+	FindLeg(id int) (*FlightLegModel, error)
+	FindLegs(q *FlightQuery) ([]FlightLegModel, error)
+	FindAdjacentLegs(linerCode string, from, to time.Time, exceptID int) (prev, next *FlightLegModel, err error)
 	FindAll(row_count, offset int) (*[]FlightModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]FlightModel, error)
 	Update(id int, f *FlightModel) error
+	UpdateStatus(id int, f *FlightModel) error
+	AddStatusChange(c *FlightStatusChangeModel) error
+	FindStatusChanges(flightID int) ([]FlightStatusChangeModel, error)
+	FindCapacities(flightID int) ([]FlightCapacityModel, error)
+	SetCapacity(c *FlightCapacityModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
 }
@@ -54,6 +83,7 @@ type LineRepository interface {
 	Create(*LineModel) error
 	Find(code string) (*LineModel, error)
 	FindAll(row_count, offset int) (*[]LineModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]LineModel, error)
 	Update(code string, l *LineModel) error
 	Delete(code string) error
 	TotalCount() (int, error)
@@ -63,7 +93,9 @@ type LinerModelRepository interface {
 	Create(*LinerModelModel) error
 	Find(code string) (*LinerModelModel, error)
 	FindAll(row_count, offset int) (*[]LinerModelModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]LinerModelModel, error)
 	Update(code string, m *LinerModelModel) error
+	SetLayout(code string, layout *string) error
 	Delete(code string) error
 	TotalCount() (int, error)
 }
@@ -72,15 +104,34 @@ type LinerRepository interface {
 	Create(*LinerModel) error
 	Find(code string) (*LinerModel, error)
 	FindAll(row_count, offset int) (*[]LinerModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]LinerModel, error)
 	Update(code string, l *LinerModel) error
 	Delete(code string) error
 	TotalCount() (int, error)
 }
 
+type PassengerRepository interface {
+	Create(*PassengerModel) error
+	Find(id int) (*PassengerModel, error)
+	LockByDocument(docType, number string, birthDate time.Time) (*PassengerModel, error)
+	FindDocuments(id int) ([]DocumentModel, error)
+	SetDocuments(id int, documents []DocumentModel) error
+	FindAll(row_count, offset int) (*[]PassengerModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]PassengerModel, error)
+	FindDuplicateCandidates() ([]PassengerModel, error)
+	Update(id int, p *PassengerModel) error
+	Merge(id int, duplicateIDs []int) error
+	Delete(id int) error
+	TotalCount() (int, error)
+	FillNameKeys() error
+}
+
 type PurchaseRepository interface {
 	Create(*PurchaseModel) error
 	Find(id int) (*PurchaseModel, error)
+	FindByIDs(ids []int) ([]PurchaseModel, error)
 	FindAll(row_count, offset int) (*[]PurchaseModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]PurchaseModel, error)
 	Update(id int, p *PurchaseModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
@@ -89,7 +140,10 @@ type PurchaseRepository interface {
 type SeatRepository interface {
 	Create(*SeatModel) error
 	Find(id int) (*SeatModel, error)
+	FindByModel(modelCode string) ([]SeatModel, error)
+	FindSold(modelCode string) ([]int, error)
 	FindAll(row_count, offset int) (*[]SeatModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]SeatModel, error)
 	Update(id int, s *SeatModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
@@ -97,14 +151,67 @@ type SeatRepository interface {
 
 type TicketRepository interface {
 	Report(id int) ([]*TicketReportFlightModel, *BookingOfficeModel, *CashierModel, *PurchaseModel, time.Duration, error)
+	ReportPurchaseFlights(purchaseID int) ([]*TicketReportFlightModel, error)
 	Create(*TicketModel) error
 	Find(id int) (*TicketModel, error)
+	Search(q *PassengerQuery, limit int) ([]TicketModel, error)
+	FillNameKeys() error
 	FindAll(row_count, offset int) (*[]TicketModel, error)
+	FindPage(cursor *Cursor, row_count int) (*[]TicketModel, error)
 	Update(id int, t *TicketModel) error
 	Delete(id int) error
 	TotalCount() (int, error)
+	AddOperation(o *TicketOperationModel) error
+	FindOperations(ticketID int) ([]TicketOperationModel, error)
+	FindPurchaseOperations(purchaseID int) ([]TicketOperationModel, error)
+	FindByPurchase(purchaseID int) ([]TicketModel, error)
 }
 
-type TimezoneRepository interface {
-	FindAll() ([]string, error)
+type BookingClassRepository interface {
+	Create(*BookingClassModel) error
+	Find(code string) (*BookingClassModel, error)
+	FindAll() ([]BookingClassModel, error)
+	Update(code string, c *BookingClassModel) error
+	Delete(code string) error
+	FindFlightLimits(flightID int) ([]FlightBookingLimitModel, error)
+	SetFlightLimit(l *FlightBookingLimitModel) error
+	DeleteFlightLimit(flightID int, code string) error
+	FindRule(code string) (*FareRuleModel, error)
+	SetRule(r *FareRuleModel) error
+}
+
+type WaitlistRepository interface {
+	Create(*WaitlistEntryModel) error
+	Find(id int) (*WaitlistEntryModel, error)
+	Lock(id int) (*WaitlistEntryModel, error)
+	FindByFlight(flightID int) ([]WaitlistEntryModel, error)
+	FindOpenFlights() ([]int, error)
+	Update(id int, e *WaitlistEntryModel) error
+}
+
+type ExchangeRateRepository interface {
+	FindAll() ([]ExchangeRateModel, error)
+	Set(r *ExchangeRateModel) error
+	Delete(currency string, effectiveDate time.Time) error
+}
+
+type PromoCampaignRepository interface {
+	Create(*PromoCampaignModel) error
+	Find(code string) (*PromoCampaignModel, error)
+	FindAll() ([]PromoCampaignModel, error)
+	Update(code string, c *PromoCampaignModel) error
+	Delete(code string) error
+	CountUses(code, customer string) (total, byCustomer int, err error)
+	AddUsage(u *PromoUsageModel) error
+	FindUsages(code string) ([]PromoUsageModel, error)
+}
+
+type LoyaltyRepository interface {
+	Create(*LoyaltyAccountModel) error
+	Find(id int) (*LoyaltyAccountModel, error)
+	FindByPassenger(passengerID int) (*LoyaltyAccountModel, error)
+	Lock(id int) error
+	Delete(id int) error
+	FindEntries(accountID int) ([]LoyaltyEntryModel, error)
+	AddEntry(e *LoyaltyEntryModel) error
 }
@@ -4,6 +4,7 @@ package store
 import (
 	"time"
 
+	"github.com/akionka/aviasales/internal/money"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -16,22 +17,64 @@ type Store interface {
 	Line() LineRepository
 	Liner() LinerRepository
 	LinerModel() LinerModelRepository
+	Passenger() PassengerRepository
 	Purchase() PurchaseRepository
 	Seat() SeatRepository
 	Ticket() TicketRepository
-	Timezone() TimezoneRepository
+	Waitlist() WaitlistRepository
+	BookingClass() BookingClassRepository
+	ExchangeRate() ExchangeRateRepository
+	PromoCampaign() PromoCampaignRepository
+	Loyalty() LoyaltyRepository
+	Transaction(fn func(Store) error) error
 }
 
+// Cursor points at a row of a list ordered by its key. Forward cursors select
+// rows after the key, backward ones select rows before it
+type Cursor struct {
+	Key      string
+	Backward bool
+}
+
+// AirportModel is an airport. The coordinates in degrees are nil for airports without them,
+// Country is the ISO 3166-1 alpha-2 code
 type AirportModel struct {
-	IATACode string `db:"iata_code"`
-	City     string `db:"city"`
-	Timezone string `db:"timezone"`
+	IATACode  string   `db:"iata_code"`
+	City      string   `db:"city"`
+	Timezone  string   `db:"timezone"`
+	Latitude  *float64 `db:"latitude"`
+	Longitude *float64 `db:"longitude"`
+	ICAOCode  string   `db:"icao_code"`
+	Name      string   `db:"name"`
+	Country   string   `db:"country"`
+}
+
+// AirportTaxModel is a tax the airport levies on every passenger departing from or arriving at it
+type AirportTaxModel struct {
+	IATACode string      `db:"iata_code"`
+	TaxCode  string      `db:"tax_code"`
+	Name     string      `db:"name"`
+	Applies  string      `db:"applies"`
+	Amount   money.Money `db:"amount"`
+	Currency string      `db:"currency"`
+}
+
+func (t *AirportTaxModel) ApplyCurrency() error {
+	return inCurrency(t.Currency, &t.Amount)
 }
 
+// BookingOfficeModel is a booking office. ServiceFee is charged for every ticket it sells, Currency is the local one
+// the office sells in
 type BookingOfficeModel struct {
-	ID          int    `db:"id"`
-	Address     string `db:"address"`
-	PhoneNumber string `db:"phone_number"`
+	ID          int         `db:"id"`
+	Address     string      `db:"address"`
+	PhoneNumber string      `db:"phone_number"`
+	ServiceFee  money.Money `db:"service_fee"`
+	Currency    string      `db:"currency"`
+}
+
+func (o *BookingOfficeModel) ApplyCurrency() error {
+	return inCurrency(o.Currency, &o.ServiceFee)
 }
 
 type CashierModel struct {
@@ -44,6 +87,18 @@ type CashierModel struct {
 	RoleID     int    `db:"role_id"`
 }
 
+// inCurrency puts the amounts read from the database in the currency, they are read in the default one
+func inCurrency(c string, amounts ...*money.Money) error {
+	for _, m := range amounts {
+		v, err := m.In(money.Currency(c))
+		if err != nil {
+			return err
+		}
+		*m = v
+	}
+	return nil
+}
+
 // ComparePassword returns true if the password matches and false otherwise
 func (c *CashierModel) ComparePassword(password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(c.Password), []byte(password)) == nil
@@ -59,27 +114,208 @@ func (c *CashierModel) SetPassword(password string) error {
 }
 
 type FlightModel struct {
-	ID        int       `db:"id"`
-	DepDate   time.Time `db:"dep_date"`
-	LineCode  string    `db:"line_code"`
-	IsHot     bool      `db:"is_hot"`
-	LinerCode string    `db:"liner_code"`
+	ID            int        `db:"id"`
+	DepDate       time.Time  `db:"dep_date"`
+	LineCode      string     `db:"line_code"`
+	IsHot         bool       `db:"is_hot"`
+	LinerCode     string     `db:"liner_code"`
+	Status        string     `db:"status"`
+	EstDepTime    *time.Time `db:"est_dep_time"`
+	EstArrTime    *time.Time `db:"est_arr_time"`
+	ActualDepTime *time.Time `db:"actual_dep_time"`
+	ActualArrTime *time.Time `db:"actual_arr_time"`
+}
+
+// FlightCapacityModel is the overbooking limit of a class of a flight
+type FlightCapacityModel struct {
+	FlightID           int     `db:"flight_id"`
+	Class              string  `db:"class"`
+	OverbookingPercent float64 `db:"overbooking_percent"`
+	AuthorizedCapacity *int    `db:"authorized_capacity"`
+}
+
+// BookingClassModel is a booking class of a cabin. BookingLimitPercent is its nested booking limit
+// as a share of the authorized capacity of the cabin
+type BookingClassModel struct {
+	Code                string  `db:"code"`
+	Cabin               string  `db:"cabin"`
+	FareMultiplier      float64 `db:"fare_multiplier"`
+	BookingLimitPercent float64 `db:"booking_limit_percent"`
+}
+
+// FlightBookingLimitModel is the nested booking limit of a booking class set for a flight
+type FlightBookingLimitModel struct {
+	FlightID     int    `db:"flight_id"`
+	BookingClass string `db:"booking_class"`
+	BookingLimit int    `db:"booking_limit"`
+}
+
+// FareRuleModel is the conditions of the fare of a booking class. Stays and advance purchase are in days
+type FareRuleModel struct {
+	BookingClass        string      `db:"booking_class" json:"-"`
+	Refundable          bool        `db:"refundable" json:"refundable"`
+	ChangeFee           money.Money `db:"change_fee" json:"change_fee"`
+	NoShowFee           money.Money `db:"no_show_fee" json:"no_show_fee"`
+	MinStayDays         *int        `db:"min_stay_days" json:"min_stay_days,omitempty"`
+	MaxStayDays         *int        `db:"max_stay_days" json:"max_stay_days,omitempty"`
+	AdvancePurchaseDays *int        `db:"advance_purchase_days" json:"advance_purchase_days,omitempty"`
+}
+
+// TicketOperationModel is a refund or an exchange of a segment of the ticket. Amount is returned to the passenger
+// for refunds and collected from them for exchanges, negative if it goes the other way
+type TicketOperationModel struct {
+	ID              int          `db:"id"`
+	TicketID        int          `db:"ticket_id"`
+	Operation       string       `db:"operation"`
+	FlightID        int          `db:"flight_id"`
+	BookingClass    *string      `db:"booking_class"`
+	Fare            money.Money  `db:"fare"`
+	NewFlightID     *int         `db:"new_flight_id"`
+	NewBookingClass *string      `db:"new_booking_class"`
+	NewFare         *money.Money `db:"new_fare"`
+	Taxes           money.Money  `db:"taxes"`
+	NewTaxes        *money.Money `db:"new_taxes"`
+	Fee             money.Money  `db:"fee"`
+	Amount          money.Money  `db:"amount"`
+	Involuntary     bool         `db:"involuntary"`
+	CashierID       int          `db:"cashier_id"`
+	CreatedAt       time.Time    `db:"created_at"`
+	Currency        string       `db:"currency"`
+}
+
+func (o *TicketOperationModel) ApplyCurrency() error {
+	for _, m := range []*money.Money{o.NewFare, o.NewTaxes} {
+		if m == nil {
+			continue
+		}
+		if err := inCurrency(o.Currency, m); err != nil {
+			return err
+		}
+	}
+	return inCurrency(o.Currency, &o.Fare, &o.Taxes, &o.Fee, &o.Amount)
+}
+
+// WaitlistEntryModel is a ticket waiting for a seat in a class of a flight
+type WaitlistEntryModel struct {
+	ID             int        `db:"id"`
+	FlightID       int        `db:"flight_id"`
+	Class          string     `db:"class"`
+	TicketID       int        `db:"ticket_id"`
+	Priority       int        `db:"priority"`
+	Status         string     `db:"status"`
+	SeatID         *int       `db:"seat_id"`
+	OfferedAt      *time.Time `db:"offered_at"`
+	OfferExpiresAt *time.Time `db:"offer_expires_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	CashierID      int        `db:"cashier_id"`
+}
+
+// FlightStatusChangeModel is a change of the operational status of a flight along with the times set by it
+type FlightStatusChangeModel struct {
+	ID            int        `db:"id"`
+	FlightID      int        `db:"flight_id"`
+	PrevStatus    string     `db:"prev_status"`
+	Status        string     `db:"status"`
+	EstDepTime    *time.Time `db:"est_dep_time"`
+	EstArrTime    *time.Time `db:"est_arr_time"`
+	ActualDepTime *time.Time `db:"actual_dep_time"`
+	ActualArrTime *time.Time `db:"actual_arr_time"`
+	Reason        string     `db:"reason"`
+	CashierID     int        `db:"cashier_id"`
+	ChangedAt     time.Time  `db:"changed_at"`
+}
+
+// FlightLegModel is a flight along with the schedule of its line and the timezones of the airports
+type FlightLegModel struct {
+	FlightModel
+	DepTime      string      `db:"dep_time"`
+	ArrTime      string      `db:"arr_time"`
+	ArrDayOffset int         `db:"arr_day_offset"`
+	BasePrice    money.Money `db:"base_price"`
+	Currency     string      `db:"currency"`
+	DepAirport   string      `db:"dep_airport"`
+	ArrAirport   string      `db:"arr_airport"`
+	DepTimezone  string      `db:"dep_timezone"`
+	ArrTimezone  string      `db:"arr_timezone"`
+}
+
+func (l *FlightLegModel) ApplyCurrency() error {
+	return inCurrency(l.Currency, &l.BasePrice)
+}
+
+// FlightQuery selects flights. Empty fields match any flight, the departure dates are inclusive
+type FlightQuery struct {
+	DepAirport string
+	ArrAirport string
+	LinerCode  string
+	FromDate   time.Time
+	ToDate     time.Time
 }
 
 type FlightInTicketModel struct {
-	ID       int `db:"id"`
-	FlightID int `db:"flight_id"`
-	SeatID   int `db:"seat_id"`
-	TicketID int `db:"ticket_id"`
+	ID              int          `db:"id"`
+	FlightID        int          `db:"flight_id"`
+	SeatID          *int         `db:"seat_id"`
+	TicketID        int          `db:"ticket_id"`
+	Class           string       `db:"class"`
+	CheckedInAt     *time.Time   `db:"checked_in_at"`
+	CheckInSequence *int         `db:"checkin_sequence"`
+	BoardedAt       *time.Time   `db:"boarded_at"`
+	BookingClass    *string      `db:"booking_class"`
+	Fare            *money.Money `db:"fare"`
+	FareCurrency    *string      `db:"fare_currency"`
+	FuelSurcharge   *money.Money `db:"fuel_surcharge"`
+}
+
+// SetFare records the fare and the fuel surcharge, in the currency of the fare, the segment is sold at
+func (f *FlightInTicketModel) SetFare(fare, fuelSurcharge money.Money) {
+	c := string(fare.Currency)
+	f.Fare, f.FareCurrency, f.FuelSurcharge = &fare, &c, &fuelSurcharge
+}
+
+// ApplyCurrency sets the currency of the fare and the fuel surcharge. Segments sold before the fares
+// were recorded have none, segments sold before the fuel surcharges were recorded have no surcharge
+func (f *FlightInTicketModel) ApplyCurrency() error {
+	if f.Fare == nil || f.FareCurrency == nil {
+		f.Fare, f.FareCurrency, f.FuelSurcharge = nil, nil, nil
+		return nil
+	}
+	if f.FuelSurcharge != nil {
+		if err := inCurrency(*f.FareCurrency, f.FuelSurcharge); err != nil {
+			return err
+		}
+	}
+	return inCurrency(*f.FareCurrency, f.Fare)
+}
+
+// SegmentTaxModel is an airport tax levied on the segment when it was sold
+type SegmentTaxModel struct {
+	SegmentID int         `db:"flight_in_ticket_id"`
+	Airport   string      `db:"airport"`
+	TaxCode   string      `db:"tax_code"`
+	Name      string      `db:"name"`
+	Amount    money.Money `db:"amount"`
+	Currency  string      `db:"currency"`
+}
+
+func (t *SegmentTaxModel) ApplyCurrency() error {
+	return inCurrency(t.Currency, &t.Amount)
 }
 
 type LineModel struct {
-	LineCode   string  `db:"line_code"`
-	DepTime    string  `db:"dep_time"`
-	ArrTime    string  `db:"arr_time"`
-	BasePrice  float64 `db:"base_price"`
-	DepAirport string  `db:"dep_airport"`
-	ArrAirport string  `db:"arr_airport"`
+	LineCode      string      `db:"line_code"`
+	DepTime       string      `db:"dep_time"`
+	ArrTime       string      `db:"arr_time"`
+	ArrDayOffset  int         `db:"arr_day_offset"`
+	BasePrice     money.Money `db:"base_price"`
+	FuelSurcharge money.Money `db:"fuel_surcharge"`
+	Currency      string      `db:"currency"`
+	DepAirport    string      `db:"dep_airport"`
+	ArrAirport    string      `db:"arr_airport"`
+}
+
+func (l *LineModel) ApplyCurrency() error {
+	return inCurrency(l.Currency, &l.BasePrice, &l.FuelSurcharge)
 }
 
 type LinerModel struct {
@@ -87,19 +323,136 @@ type LinerModel struct {
 	ModelCode string `db:"model_code"`
 }
 
+// LinerModelModel is a model of liners. Layout is the JSON cabin layout, nil if it is not defined
 type LinerModelModel struct {
-	IATATypeCode string `db:"iata_type_code"`
-	Name         string `db:"name"`
+	IATATypeCode string  `db:"iata_type_code"`
+	Name         string  `db:"name"`
+	Layout       *string `db:"layout"`
+}
+
+type PassengerModel struct {
+	ID           int       `db:"id"`
+	LastName     string    `db:"last_name"`
+	GivenName    string    `db:"given_name"`
+	BirthDate    time.Time `db:"birth_date"`
+	Sex          uint8     `db:"sex"`
+	ContactPhone string    `db:"contact_phone"`
+	ContactEmail string    `db:"contact_email"`
+	LastNameKey  string    `db:"last_name_key"`
+	GivenNameKey string    `db:"given_name_key"`
 }
 
+type DocumentModel struct {
+	ID             int        `db:"id"`
+	PassengerID    int        `db:"passenger_id"`
+	Type           string     `db:"type"`
+	Number         string     `db:"number"`
+	IssuingCountry string     `db:"issuing_country"`
+	ExpiryDate     *time.Time `db:"expiry_date"`
+}
+
+// PurchaseModel is a purchase paid in its currency. BaseTotalPrice is the total converted to the base currency
+// at ExchangeRate, the rate effective on the date of the purchase, which took effect on RateDate.
+// The total is after the Discount of the PromoCode and MilesAmount, the part paid with the miles redeemed
+// from the loyalty account
 type PurchaseModel struct {
-	ID              int       `db:"id"`
-	Date            time.Time `db:"date"`
-	BookingOfficeID int       `db:"booking_office_id"`
-	TotalPrice      float64   `db:"total_price"`
-	ContactPhone    string    `db:"contact_phone"`
-	ContactEmail    string    `db:"contact_email"`
-	CashierID       string    `db:"cashier_id"`
+	ID               int         `db:"id"`
+	Date             time.Time   `db:"date"`
+	BookingOfficeID  int         `db:"booking_office_id"`
+	TotalPrice       money.Money `db:"total_price"`
+	ContactPhone     string      `db:"contact_phone"`
+	ContactEmail     string      `db:"contact_email"`
+	CashierID        string      `db:"cashier_id"`
+	Currency         string      `db:"currency"`
+	ExchangeRate     float64     `db:"exchange_rate"`
+	RateDate         *time.Time  `db:"rate_date"`
+	BaseTotalPrice   money.Money `db:"base_total_price"`
+	PromoCode        *string     `db:"promo_code"`
+	Discount         money.Money `db:"discount"`
+	LoyaltyAccountID *int        `db:"loyalty_account_id"`
+	RedeemedMiles    int         `db:"redeemed_miles"`
+	MilesAmount      money.Money `db:"miles_amount"`
+	// ServiceFee is the service fee of the booking office for a ticket at the time of the purchase,
+	// in the currency of the office. Purchases made before the fees were recorded have none
+	ServiceFee         *money.Money `db:"service_fee"`
+	ServiceFeeCurrency *string      `db:"service_fee_currency"`
+}
+
+func (p *PurchaseModel) ApplyCurrency() error {
+	if p.ServiceFee != nil && p.ServiceFeeCurrency != nil {
+		if err := inCurrency(*p.ServiceFeeCurrency, p.ServiceFee); err != nil {
+			return err
+		}
+	} else {
+		p.ServiceFee, p.ServiceFeeCurrency = nil, nil
+	}
+	return inCurrency(p.Currency, &p.TotalPrice, &p.Discount, &p.MilesAmount)
+}
+
+// LoyaltyAccountModel is an account of a passenger in the loyalty programme
+type LoyaltyAccountModel struct {
+	ID          int       `db:"id"`
+	PassengerID int       `db:"passenger_id"`
+	EnrolledAt  time.Time `db:"enrolled_at"`
+}
+
+// LoyaltyEntryModel is an entry of the ledger of a loyalty account: miles earned for the segment flown
+// or redeemed in the purchase
+type LoyaltyEntryModel struct {
+	ID         int        `db:"id"`
+	AccountID  int        `db:"account_id"`
+	Kind       string     `db:"kind"`
+	Miles      int        `db:"miles"`
+	SegmentID  *int       `db:"segment_id"`
+	PurchaseID *int       `db:"purchase_id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+}
+
+// PromoCampaignModel is a discount campaign of a promo code. Percent is the discount of the percent kind,
+// Amount in Currency the one of the fixed kind. Empty Lines and Classes allow any, nil limits do not restrict the uses
+type PromoCampaignModel struct {
+	Code               string      `db:"code"`
+	Name               string      `db:"name"`
+	Kind               string      `db:"kind"`
+	Percent            float64     `db:"percent"`
+	Amount             money.Money `db:"amount"`
+	Currency           string      `db:"currency"`
+	ValidFrom          time.Time   `db:"valid_from"`
+	ValidTo            time.Time   `db:"valid_to"`
+	MaxUses            *int        `db:"max_uses"`
+	MaxUsesPerCustomer *int        `db:"max_uses_per_customer"`
+	Lines              []string    `db:"-"`
+	Classes            []string    `db:"-"`
+}
+
+func (c *PromoCampaignModel) ApplyCurrency() error {
+	return inCurrency(c.Currency, &c.Amount)
+}
+
+// PromoUsageModel is a use of a promo code in a purchase by the customer, the contact email of the purchase.
+// BaseDiscount and BaseTotalPrice, the total of the purchase, are in the base currency
+type PromoUsageModel struct {
+	ID             int         `db:"id"`
+	Code           string      `db:"code"`
+	PurchaseID     int         `db:"purchase_id"`
+	Customer       string      `db:"customer"`
+	Discount       money.Money `db:"discount"`
+	Currency       string      `db:"currency"`
+	BaseDiscount   money.Money `db:"base_discount"`
+	UsedAt         time.Time   `db:"used_at"`
+	BaseTotalPrice money.Money `db:"base_total_price"`
+}
+
+func (u *PromoUsageModel) ApplyCurrency() error {
+	return inCurrency(u.Currency, &u.Discount)
+}
+
+// ExchangeRateModel is the price of a unit of the currency in the base currency from the effective date
+type ExchangeRateModel struct {
+	Currency      string    `db:"currency"`
+	EffectiveDate time.Time `db:"effective_date"`
+	Rate          float64   `db:"rate"`
 }
 
 type SeatModel struct {
@@ -110,26 +463,121 @@ type SeatModel struct {
 }
 
 type TicketModel struct {
-	ID                      int       `db:"id"`
-	PassengerLastName       string    `db:"pass_last_name"`
-	PassengerGivenName      string    `db:"pass_given_name"`
-	PassengerBirthDate      time.Time `db:"pass_birth_date"`
-	PassengerPassportNumber string    `db:"pass_passport_number"`
-	PassengerSex            uint8     `db:"pass_sex"`
-	PurchaseID              int       `db:"purchase_id"`
+	ID                       int        `db:"id"`
+	PassengerLastName        string     `db:"pass_last_name"`
+	PassengerGivenName       string     `db:"pass_given_name"`
+	PassengerBirthDate       time.Time  `db:"pass_birth_date"`
+	PassengerPassportNumber  string     `db:"pass_passport_number"`
+	PassengerSex             uint8      `db:"pass_sex"`
+	PurchaseID               int        `db:"purchase_id"`
+	PassengerLastNameKey     string     `db:"pass_last_name_key"`
+	PassengerGivenNameKey    string     `db:"pass_given_name_key"`
+	PassengerID              *int       `db:"passenger_id"`
+	PassengerDocumentType    string     `db:"pass_document_type"`
+	PassengerDocumentCountry string     `db:"pass_document_country"`
+	PassengerDocumentExpiry  *time.Time `db:"pass_document_expiry"`
+	AccompanyingTicketID     *int       `db:"accompanying_ticket_id"`
+}
+
+// PassengerQuery describes a passenger search. All the given conditions must match.
+// NameKeys are prefixes of search keys of either the last or the given name
+type PassengerQuery struct {
+	NameKeys       []string
+	DocumentNumber string
+	BirthDate      time.Time
+}
+
+// SegmentModel is a flight of a ticket along with the seat taken
+type SegmentModel struct {
+	ID              int        `db:"id"`
+	TicketID        int        `db:"ticket_id"`
+	FlightID        int        `db:"flight_id"`
+	DepDate         time.Time  `db:"dep_date"`
+	LineCode        string     `db:"line_code"`
+	DepAirport      string     `db:"dep_airport"`
+	ArrAirport      string     `db:"arr_airport"`
+	SeatID          *int       `db:"seat_id"`
+	SeatNumber      string     `db:"number"`
+	SeatClass       string     `db:"class"`
+	BookingClass    *string    `db:"booking_class"`
+	CheckedInAt     *time.Time `db:"checked_in_at"`
+	CheckInSequence *int       `db:"checkin_sequence"`
+	BoardedAt       *time.Time `db:"boarded_at"`
+}
+
+// ManifestEntryModel is a passenger of a flight with the seat taken
+type ManifestEntryModel struct {
+	SegmentID            int        `db:"id"`
+	TicketID             int        `db:"ticket_id"`
+	LastName             string     `db:"pass_last_name"`
+	GivenName            string     `db:"pass_given_name"`
+	BirthDate            time.Time  `db:"pass_birth_date"`
+	Sex                  uint8      `db:"pass_sex"`
+	DocumentType         string     `db:"pass_document_type"`
+	DocumentNumber       string     `db:"pass_passport_number"`
+	DocumentCountry      string     `db:"pass_document_country"`
+	AccompanyingTicketID *int       `db:"accompanying_ticket_id"`
+	SeatNumber           string     `db:"number"`
+	Class                string     `db:"class"`
+	BookingClass         *string    `db:"booking_class"`
+	CheckedInAt          *time.Time `db:"checked_in_at"`
+	BoardedAt            *time.Time `db:"boarded_at"`
 }
 
 type TicketReportFlightModel struct {
-	DepCity      string    `db:"dep_city" json:"dep_city"`
-	ArrCity      string    `db:"arr_city" json:"arr_city"`
-	DepTimeLocal time.Time `db:"dep_time_local" json:"dep_time_local"`
-	DepTimeGMT   time.Time `db:"dep_time_gmt" json:"dep_time_gmt"`
-	ArrTimeLocal time.Time `db:"arr_time_local" json:"arr_time_local"`
-	ArrTimeGMT   time.Time `db:"arr_time_gmt" json:"arr_time_gmt"`
-	LineCode     string    `db:"line_code" json:"line_code"`
-	SeatNumber   string    `db:"number" json:"number"`
-	SeatClass    string    `db:"class" json:"class"`
-	Price        float64   `db:"price" json:"price"`
+	TicketID      int         `db:"ticket_id" json:"-"`
+	FlightID      int         `db:"flight_id" json:"flight_id"`
+	DepDate       time.Time   `db:"dep_date" json:"-"`
+	DepTime       string      `db:"dep_time" json:"-"`
+	ArrTime       string      `db:"arr_time" json:"-"`
+	ArrDayOffset  int         `db:"arr_day_offset" json:"-"`
+	DepTimezone   string      `db:"dep_timezone" json:"-"`
+	ArrTimezone   string      `db:"arr_timezone" json:"-"`
+	BasePrice     money.Money `db:"base_price" json:"-"`
+	FuelSurcharge money.Money `db:"fuel_surcharge" json:"-"`
+	Currency      string      `db:"currency" json:"currency"`
+	DepAirport    string      `db:"dep_airport" json:"dep_airport"`
+	ArrAirport    string      `db:"arr_airport" json:"arr_airport"`
+	IsHot         bool        `db:"is_hot" json:"-"`
+	WithSeat      bool        `db:"with_seat" json:"-"`
+	DepCity       string      `db:"dep_city" json:"dep_city"`
+	ArrCity       string      `db:"arr_city" json:"arr_city"`
+	DepTimeLocal  time.Time   `db:"dep_time_local" json:"dep_time_local"`
+	DepTimeGMT    time.Time   `db:"dep_time_gmt" json:"dep_time_gmt"`
+	ArrTimeLocal  time.Time   `db:"arr_time_local" json:"arr_time_local"`
+	ArrTimeGMT    time.Time   `db:"arr_time_gmt" json:"arr_time_gmt"`
+	LineCode      string      `db:"line_code" json:"line_code"`
+	SeatNumber    string      `db:"number" json:"number"`
+	SeatClass     string      `db:"class" json:"class"`
+	BookingClass  string      `db:"booking_class" json:"booking_class,omitempty"`
+	Multiplier    *float64    `db:"fare_multiplier" json:"-"`
+	Price         money.Money `db:"price" json:"price"`
+	// SegmentID, SoldFare and SoldFuelSurcharge, in SoldCurrency, are of the segment sold on the flight.
+	// They are nil for segments sold before they were recorded
+	SegmentID         int          `db:"segment_id" json:"-"`
+	SoldFare          *money.Money `db:"sold_fare" json:"-"`
+	SoldFuelSurcharge *money.Money `db:"sold_fuel_surcharge" json:"-"`
+	SoldCurrency      *string      `db:"sold_currency" json:"-"`
+
+	PassengerType      string         `db:"-" json:"passenger_type"`
+	UnaccompaniedMinor bool           `db:"-" json:"unaccompanied_minor"`
+	FareRules          *FareRuleModel `db:"-" json:"fare_rules,omitempty"`
+	DistanceKm         *int           `db:"-" json:"distance_km,omitempty"`
+}
+
+func (f *TicketReportFlightModel) ApplyCurrency() error {
+	if f.SoldCurrency != nil {
+		for _, m := range []*money.Money{f.SoldFare, f.SoldFuelSurcharge} {
+			if m != nil {
+				if err := inCurrency(*f.SoldCurrency, m); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		f.SoldFare, f.SoldFuelSurcharge = nil, nil
+	}
+	return inCurrency(f.Currency, &f.BasePrice, &f.FuelSurcharge)
 }
 
 type RoleModel struct {
@@ -0,0 +1,115 @@
+// Файл internal\translit\translit.go содержит код транслитерации кириллицы и построения поисковых ключей имён
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// icao maps russian letters to latin ones according to ICAO Doc 9303 used in passports
+var icao = map[rune]string{
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "E", 'Ж': "ZH", 'З': "Z", 'И': "I",
+	'Й': "I", 'К': "K", 'Л': "L", 'М': "M", 'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T",
+	'У': "U", 'Ф': "F", 'Х': "KH", 'Ц': "TS", 'Ч': "CH", 'Ш': "SH", 'Щ': "SHCH", 'Ъ': "IE", 'Ы': "Y", 'Ь': "",
+	'Э': "E", 'Ю': "IU", 'Я': "IA",
+}
+
+// letterFolds and syllableFolds replace latin spellings that differ between transliteration systems with a single one
+var (
+	letterFolds = strings.NewReplacer(
+		"Y", "I",
+		"J", "I",
+		"W", "V",
+		"Q", "K",
+		"X", "KS",
+	)
+	syllableFolds = strings.NewReplacer(
+		"KH", "H",
+		"IE", "E",
+	)
+)
+
+// Latin transliterates the string to upper case latin letters, dropping anything but letters and digits
+func Latin(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(s) {
+		if l, ok := icao[c]; ok {
+			b.WriteString(l)
+			continue
+		}
+		if c <= unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Text transliterates the cyrillic letters of the text keeping their case and everything else as it is
+func Text(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, c := range runes {
+		l, ok := icao[unicode.ToUpper(c)]
+		switch {
+		case !ok:
+			b.WriteRune(c)
+		case unicode.IsLower(c):
+			b.WriteString(strings.ToLower(l))
+		case len(l) > 1 && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// "Щукин" becomes "Shchukin" rather than "SHCHukin"
+			b.WriteString(l[:1] + strings.ToLower(l[1:]))
+		default:
+			b.WriteString(l)
+		}
+	}
+	return b.String()
+}
+
+// Key returns the search key of the name. Names written in cyrillic and in any common latin transliteration
+// of it produce the same key, so the prefix of the key of a query matches the key of a stored name
+func Key(s string) string {
+	return syllableFolds.Replace(letterFolds.Replace(Latin(s)))
+}
+
+// Similarity returns how close the search keys of the two names are, from 0 for completely different names to 1 for equal ones
+func Similarity(a, b string) float64 {
+	ka, kb := []rune(Key(a)), []rune(Key(b))
+	longest := len(ka)
+	if len(kb) > longest {
+		longest = len(kb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ka, kb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+package translit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string
+		query  string
+	}{
+		{name: "same script", stored: "Иванов", query: "иван"},
+		{name: "icao", stored: "Юрий", query: "Iurii"},
+		{name: "gost", stored: "Юрий", query: "Yuriy"},
+		{name: "kh", stored: "Хабибуллин", query: "Habib"},
+		{name: "ye", stored: "Евгений", query: "Yevgeny"},
+		{name: "latin name", stored: "O'Connor", query: "oconn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.HasPrefix(Key(tt.stored), Key(tt.query)) {
+				t.Errorf("Key(%q) = %q does not start with Key(%q) = %q", tt.stored, Key(tt.stored), tt.query, Key(tt.query))
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{name: "transliterated", a: "Петров Алексей", b: "Petrov Aleksey", min: 1, max: 1},
+		{name: "typo", a: "Петров Алексей", b: "Петрова Алексей", min: 0.9, max: 0.99},
+		{name: "different", a: "Петров Алексей", b: "Smith John", min: 0, max: 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.a, tt.b); got < tt.min || got > tt.max {
+				t.Errorf("Similarity(%q, %q) = %v, want in [%v, %v]", tt.a, tt.b, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Щукин Юрий", "Shchukin Iurii"},
+		{"ЖУКОВА", "ZHUKOVA"},
+		{"Кирилл-2 (O'Neil)", "Kirill-2 (O'Neil)"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.s); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
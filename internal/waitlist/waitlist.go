@@ -0,0 +1,162 @@
+// Файл internal\waitlist\waitlist.go содержит очередь ожидания мест на рейс: порядок очереди, предложение освободившихся мест и истечение предложений
+package waitlist
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/akionka/aviasales/internal/reseat"
+)
+
+// OfferTTL is how long a seat offered to a waitlisted passenger is held for them
+const OfferTTL = 2 * time.Hour
+
+type Status string
+
+const (
+	Waiting   Status = "waiting"
+	Offered   Status = "offered"
+	Accepted  Status = "accepted"
+	Declined  Status = "declined"
+	Expired   Status = "expired"
+	Cancelled Status = "cancelled"
+)
+
+var (
+	ErrNotOffered   = errors.New("no seat is offered to the entry")
+	ErrOfferExpired = errors.New("the offer has expired")
+	ErrClosed       = errors.New("the entry is no longer in the waitlist")
+)
+
+// Entry is a passenger waiting for a seat in a class of a flight. An offered entry holds SeatID until OfferExpiresAt
+type Entry struct {
+	ID             int
+	Class          string
+	Priority       int
+	CreatedAt      time.Time
+	Status         Status
+	SeatID         *int
+	OfferExpiresAt *time.Time
+}
+
+// Active reports whether the entry is still waiting or holds an offer that has not expired
+func (e *Entry) Active(now time.Time) bool {
+	switch e.Status {
+	case Waiting:
+		return true
+	case Offered:
+		return e.OfferExpiresAt != nil && now.Before(*e.OfferExpiresAt)
+	}
+	return false
+}
+
+// Less orders the entries by priority, higher first, and then by the time they joined the waitlist
+func Less(a, b *Entry) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// Accept checks that the entry may take the seat offered to it
+func Accept(e *Entry, now time.Time) error {
+	if e.Status != Offered || e.SeatID == nil {
+		return ErrNotOffered
+	}
+	if !e.Active(now) {
+		return ErrOfferExpired
+	}
+	return nil
+}
+
+// Close checks that the entry may be declined or cancelled
+func Close(e *Entry, now time.Time) error {
+	if !e.Active(now) {
+		return ErrClosed
+	}
+	return nil
+}
+
+type Seat struct {
+	ID     int
+	Number string
+	Class  string
+}
+
+type Offer struct {
+	EntryID   int
+	Seat      Seat
+	ExpiresAt time.Time
+}
+
+// Result lists the entries whose offers expired, the entries back in the waitlist since the seat offered
+// is no longer free, as after a change of the aircraft, and the new offers
+type Result struct {
+	Expired   []int
+	Withdrawn []int
+	Offers    []Offer
+}
+
+// Plan expires the overdue offers and offers the free seats to the first waiting entries of their classes.
+// free are the seats not taken by any passenger, the seats held by offers are not offered again.
+// available is how many more passengers may be sold in each class, offers held count against it
+func Plan(entries []Entry, free []Seat, available map[string]int, now time.Time) Result {
+	var result Result
+	vacant := make(map[int]bool, len(free))
+	for _, seat := range free {
+		vacant[seat.ID] = true
+	}
+	held := make(map[int]bool)
+	left := make(map[string]int, len(available))
+	for class, n := range available {
+		left[class] = n
+	}
+	var waiting []*Entry
+	for i := range entries {
+		e := &entries[i]
+		switch {
+		case e.Status == Offered && !e.Active(now):
+			result.Expired = append(result.Expired, e.ID)
+		case e.Status == Offered && (e.SeatID == nil || !vacant[*e.SeatID]):
+			result.Withdrawn = append(result.Withdrawn, e.ID)
+			waiting = append(waiting, e)
+		case e.Status == Offered:
+			if e.SeatID != nil {
+				held[*e.SeatID] = true
+			}
+			left[e.Class]--
+		case e.Status == Waiting:
+			waiting = append(waiting, e)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return Less(waiting[i], waiting[j])
+	})
+
+	seats := make(map[string][]Seat)
+	for _, seat := range free {
+		if !held[seat.ID] {
+			seats[seat.Class] = append(seats[seat.Class], seat)
+		}
+	}
+	for class := range seats {
+		s := seats[class]
+		sort.SliceStable(s, func(i, j int) bool {
+			return reseat.Less(s[i].Number, s[j].Number)
+		})
+	}
+
+	for _, e := range waiting {
+		if len(seats[e.Class]) == 0 || left[e.Class] <= 0 {
+			continue
+		}
+		result.Offers = append(result.Offers, Offer{EntryID: e.ID, Seat: seats[e.Class][0], ExpiresAt: now.Add(OfferTTL)})
+		seats[e.Class] = seats[e.Class][1:]
+		left[e.Class]--
+	}
+	return result
+}
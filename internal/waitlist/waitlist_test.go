@@ -0,0 +1,132 @@
+package waitlist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	joined := func(h int) time.Time {
+		return time.Date(2024, 4, 30, h, 0, 0, 0, time.UTC)
+	}
+	seatID := func(id int) *int {
+		return &id
+	}
+	until := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	free := []Seat{{ID: 3, Number: "12A", Class: "Y"}, {ID: 2, Number: "2A", Class: "Y"}, {ID: 1, Number: "1A", Class: "J"}}
+
+	tests := []struct {
+		name          string
+		entries       []Entry
+		available     map[string]int
+		wantExpired   []int
+		wantWithdrawn []int
+		wantOffers    map[int]int
+	}{
+		{
+			name: "priority first",
+			entries: []Entry{
+				{ID: 1, Class: "Y", CreatedAt: joined(1), Status: Waiting},
+				{ID: 2, Class: "Y", CreatedAt: joined(2), Priority: 5, Status: Waiting},
+				{ID: 3, Class: "Y", CreatedAt: joined(3), Status: Waiting},
+			},
+			available:  map[string]int{"Y": 2},
+			wantOffers: map[int]int{2: 2, 1: 3},
+		},
+		{
+			name: "held seat is not offered again",
+			entries: []Entry{
+				{ID: 1, Class: "Y", CreatedAt: joined(1), Status: Offered, SeatID: seatID(2), OfferExpiresAt: until(time.Hour)},
+				{ID: 2, Class: "Y", CreatedAt: joined(2), Status: Waiting},
+			},
+			available:  map[string]int{"Y": 5},
+			wantOffers: map[int]int{2: 3},
+		},
+		{
+			name: "expired offer goes to the next",
+			entries: []Entry{
+				{ID: 1, Class: "Y", CreatedAt: joined(1), Status: Offered, SeatID: seatID(2), OfferExpiresAt: until(-time.Minute)},
+				{ID: 2, Class: "Y", CreatedAt: joined(2), Status: Waiting},
+			},
+			available:   map[string]int{"Y": 1},
+			wantExpired: []int{1},
+			wantOffers:  map[int]int{2: 2},
+		},
+		{
+			name: "offered seat is gone",
+			entries: []Entry{
+				{ID: 1, Class: "Y", CreatedAt: joined(1), Status: Offered, SeatID: seatID(9), OfferExpiresAt: until(time.Hour)},
+			},
+			available:     map[string]int{"Y": 1},
+			wantWithdrawn: []int{1},
+			wantOffers:    map[int]int{1: 2},
+		},
+		{
+			name: "capacity held by offers",
+			entries: []Entry{
+				{ID: 1, Class: "Y", CreatedAt: joined(1), Status: Offered, SeatID: seatID(2), OfferExpiresAt: until(time.Hour)},
+				{ID: 2, Class: "Y", CreatedAt: joined(2), Status: Waiting},
+			},
+			available: map[string]int{"Y": 1},
+		},
+		{
+			name: "class of the entry",
+			entries: []Entry{
+				{ID: 1, Class: "W", CreatedAt: joined(1), Status: Waiting},
+				{ID: 2, Class: "J", CreatedAt: joined(2), Status: Waiting},
+			},
+			available:  map[string]int{"J": 1, "W": 1, "Y": 1},
+			wantOffers: map[int]int{2: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Plan(tt.entries, free, tt.available, now)
+			if !reflect.DeepEqual(got.Expired, tt.wantExpired) {
+				t.Errorf("Plan() expired = %v, want %v", got.Expired, tt.wantExpired)
+			}
+			if !reflect.DeepEqual(got.Withdrawn, tt.wantWithdrawn) {
+				t.Errorf("Plan() withdrawn = %v, want %v", got.Withdrawn, tt.wantWithdrawn)
+			}
+			offers := make(map[int]int)
+			for _, o := range got.Offers {
+				offers[o.EntryID] = o.Seat.ID
+				if !o.ExpiresAt.Equal(now.Add(OfferTTL)) {
+					t.Errorf("Plan() offer expires at %v", o.ExpiresAt)
+				}
+			}
+			if len(offers) != len(tt.wantOffers) || (len(offers) > 0 && !reflect.DeepEqual(offers, tt.wantOffers)) {
+				t.Errorf("Plan() offers = %v, want %v", offers, tt.wantOffers)
+			}
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	seat := 1
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		entry   Entry
+		wantErr error
+	}{
+		{name: "offered", entry: Entry{Status: Offered, SeatID: &seat, OfferExpiresAt: &later}},
+		{name: "expired", entry: Entry{Status: Offered, SeatID: &seat, OfferExpiresAt: &earlier}, wantErr: ErrOfferExpired},
+		{name: "waiting", entry: Entry{Status: Waiting}, wantErr: ErrNotOffered},
+		{name: "declined", entry: Entry{Status: Declined}, wantErr: ErrNotOffered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Accept(&tt.entry, now); err != tt.wantErr {
+				t.Errorf("Accept() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
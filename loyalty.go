@@ -0,0 +1,285 @@
+// Файл loyalty.go содержит счета программы лояльности, начисление миль за полёт, их списание в оплату покупки и журнал миль
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/geo"
+	"github.com/akionka/aviasales/internal/loyalty"
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errLoyaltyAccountUnknown = errors.New("счёт программы лояльности не существует")
+	errLoyaltyAccountExists  = errors.New("у пассажира уже есть счёт программы лояльности")
+)
+
+// loyaltyErrors are the messages shown for the errors of redeeming miles
+var loyaltyErrors = map[error]error{
+	loyalty.ErrInsufficientMiles: errors.New("на счёте недостаточно миль"),
+	loyalty.ErrAboveTotal:        errors.New("мили стоят больше суммы покупки"),
+}
+
+// loyaltyErrorStatus returns the status code for an error of a purchase paid with miles
+func loyaltyErrorStatus(err error) (int, error) {
+	if e, ok := loyaltyErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	if err == errLoyaltyAccountUnknown {
+		return http.StatusBadRequest, err
+	}
+	return promoErrorStatus(err)
+}
+
+// loyaltyStatus returns the status of the account at the time from its ledger
+func (s *server) loyaltyStatus(st store.Store, accountID int, at time.Time) (loyalty.Status, error) {
+	models, err := st.Loyalty().FindEntries(accountID)
+	if err != nil {
+		return loyalty.Status{}, err
+	}
+	entries := make([]loyalty.Entry, len(models))
+	for i, e := range models {
+		entries[i] = loyalty.Entry{Kind: loyalty.Kind(e.Kind), Miles: e.Miles, At: e.CreatedAt, ExpiresAt: e.ExpiresAt}
+	}
+	return loyalty.StatusOf(entries, at), nil
+}
+
+// redeemMiles pays for a part of the total of the purchase with the miles of the loyalty account. The value
+// of the miles is converted to the currency of the purchase. The account stays locked until the end
+// of the transaction, so the miles are not redeemed twice
+func (s *server) redeemMiles(st store.Store, p *Purchase, m *store.PurchaseModel) (*store.LoyaltyEntryModel, error) {
+	if err := st.Loyalty().Lock(*p.LoyaltyAccountID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errLoyaltyAccountUnknown
+		}
+		return nil, err
+	}
+	now := time.Now()
+	status, err := s.loyaltyStatus(st, *p.LoyaltyAccountID, now)
+	if err != nil {
+		return nil, err
+	}
+	convert, err := s.converter(st, currencyOf(p.Currency), now)
+	if err != nil {
+		return nil, err
+	}
+	value, err := convert(loyalty.Value(p.RedeemedMiles))
+	if err != nil {
+		return nil, err
+	}
+	if err := loyalty.Redeem(status.Balance, p.RedeemedMiles, value, p.TotalPrice); err != nil {
+		return nil, err
+	}
+
+	if p.TotalPrice, err = p.TotalPrice.Sub(value); err != nil {
+		return nil, err
+	}
+	p.MilesAmount = value
+	p.BaseTotalPrice = p.TotalPrice.Convert(money.Default, p.ExchangeRate, money.HalfUp)
+	m.TotalPrice, m.BaseTotalPrice = p.TotalPrice, p.BaseTotalPrice
+	m.LoyaltyAccountID, m.RedeemedMiles, m.MilesAmount = p.LoyaltyAccountID, p.RedeemedMiles, p.MilesAmount
+	return &store.LoyaltyEntryModel{
+		AccountID: *p.LoyaltyAccountID,
+		Kind:      string(loyalty.Redeemed),
+		Miles:     -p.RedeemedMiles,
+		CreatedAt: now,
+	}, nil
+}
+
+// accrueMiles credits the loyalty account of the passenger with the miles for the segment flown. Infants
+// without a seat, passengers without an account and flights between airports without coordinates earn nothing
+func (s *server) accrueMiles(st store.Store, segment *store.FlightInTicketModel, leg *store.FlightLegModel, at time.Time) error {
+	if segment.SeatID == nil {
+		return nil
+	}
+	t, err := st.Ticket().Find(segment.TicketID)
+	if err != nil || t.PassengerID == nil {
+		return err
+	}
+	account, err := st.Loyalty().FindByPassenger(*t.PassengerID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dep, err := st.Airport().Find(leg.DepAirport)
+	if err != nil {
+		return err
+	}
+	arr, err := st.Airport().Find(leg.ArrAirport)
+	if err != nil {
+		return err
+	}
+	from, ok := airportPoint(dep)
+	if !ok {
+		return nil
+	}
+	to, ok := airportPoint(arr)
+	if !ok {
+		return nil
+	}
+
+	status, err := s.loyaltyStatus(st, account.ID, at)
+	if err != nil {
+		return err
+	}
+	distance := geo.Miles(geo.Distance(from, to))
+	expires := at.AddDate(0, loyalty.ExpiryMonths, 0)
+	return st.Loyalty().AddEntry(&store.LoyaltyEntryModel{
+		AccountID: account.ID,
+		Kind:      string(loyalty.Earned),
+		Miles:     loyalty.Accrual(distance, segment.Class, status.Tier),
+		SegmentID: &segment.ID,
+		CreatedAt: at,
+		ExpiresAt: &expires,
+	})
+}
+
+func (s *server) loyaltyAccount(a *store.LoyaltyAccountModel) (*LoyaltyAccount, error) {
+	status, err := s.loyaltyStatus(s.store, a.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &LoyaltyAccount{
+		ID:              a.ID,
+		PassengerID:     a.PassengerID,
+		EnrolledAt:      a.EnrolledAt,
+		Tier:            string(status.Tier),
+		Balance:         status.Balance,
+		QualifyingMiles: status.Qualifying,
+		ExpiredMiles:    status.Expired,
+		NextExpiry:      status.NextExpiry,
+		ExpiringMiles:   status.Expiring,
+	}, nil
+}
+
+// handleLoyaltyAccountsCreate enrols the passenger in the loyalty programme
+func (s *server) handleLoyaltyAccountsCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := &LoyaltyAccount{}
+		if err := json.NewDecoder(r.Body).Decode(a); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := a.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := s.store.Passenger().Find(a.PassengerID); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if _, err := s.store.Loyalty().FindByPassenger(a.PassengerID); err == nil {
+			s.error(w, r, http.StatusBadRequest, errLoyaltyAccountExists)
+			return
+		} else if err != sql.ErrNoRows {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		m := &store.LoyaltyAccountModel{
+			PassengerID: a.PassengerID,
+			EnrolledAt:  time.Now().UTC().Truncate(time.Second),
+		}
+		if err := s.store.Loyalty().Create(m); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response, err := s.loyaltyAccount(m)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleLoyaltyAccountGetDelete returns the account with its balance and tier or closes it
+func (s *server) handleLoyaltyAccountGetDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		a, err := s.store.Loyalty().Find(id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := s.store.Loyalty().Delete(id); err != nil {
+				if err == mysqlstore.ErrDeletedItemDoesNotExist {
+					s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+					return
+				}
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		response, err := s.loyaltyAccount(a)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleLoyaltyLedgerGet returns the ledger of the account, the earliest entries first
+func (s *server) handleLoyaltyLedgerGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := s.store.Loyalty().Find(id); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		entries, err := s.store.Loyalty().FindEntries(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]LoyaltyEntry, len(entries))
+		for i, e := range entries {
+			response[i] = LoyaltyEntry{
+				ID:         e.ID,
+				Kind:       e.Kind,
+				Miles:      e.Miles,
+				SegmentID:  e.SegmentID,
+				PurchaseID: e.PurchaseID,
+				CreatedAt:  e.CreatedAt,
+				ExpiresAt:  e.ExpiresAt,
+			}
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
@@ -2,7 +2,9 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
 
 	"github.com/akionka/aviasales/internal/store/mysqlstore"
 	_ "github.com/go-sql-driver/mysql"
@@ -10,12 +12,52 @@ import (
 )
 
 func main() {
+	airportsFile := flag.String("import-airports", "", "update the airports from the airports.csv file of OurAirports and exit")
+	importEntity := flag.String("import", "", "import the file of airports, liner_models, liners, seats or lines and exit")
+	importPath := flag.String("file", "", "the CSV or JSON file to import")
+	importMode := flag.String("mode", importInsert, "insert to fail the rows that exist or upsert to update them")
+	dryRun := flag.Bool("dry-run", false, "check the file without saving it")
+	fillNameKeys := flag.Bool("fill-name-keys", false, "compute the search keys of the tickets and passengers saved before the keys and exit")
+	flag.Parse()
+
 	db, err := sqlx.Connect("mysql", "root:password@(localhost)/aviacompany?parseTime=true&time_zone=%27GMT%27")
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	store := mysqlstore.New(db)
+	if *airportsFile != "" {
+		f, err := os.Open(*airportsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		result, err := importAirports(store, f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Airports updated: %d, skipped: %d", result.Updated, result.Skipped)
+		return
+	}
+
+	if *importEntity != "" {
+		if err := runImport(newServer(store), *importEntity, *importPath, *importMode, *dryRun); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *fillNameKeys {
+		if err := store.Ticket().FillNameKeys(); err != nil {
+			log.Fatal(err)
+		}
+		if err := store.Passenger().FillNameKeys(); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Search keys of the names filled")
+		return
+	}
+
 	server := newServer(store)
 	log.Println("Starting server on port :8080...")
 	log.Fatal(server.start())
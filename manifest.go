@@ -0,0 +1,247 @@
+// Файл manifest.go содержит список пассажиров рейса (манифест) с выводом в JSON, CSV и PDF
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akionka/aviasales/internal/pdf"
+	"github.com/akionka/aviasales/internal/pricing"
+	"github.com/akionka/aviasales/internal/reseat"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/translit"
+	"github.com/gorilla/mux"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeCSV  = "text/csv"
+	contentTypePDF  = "application/pdf"
+)
+
+var errBadManifestSort = errors.New("сортировка возможна по месту (seat) или по имени (name)")
+
+// negotiate returns the offered content type the client accepts with the highest quality,
+// the first offer if the client does not say what it accepts
+func negotiate(accept string, offers ...string) string {
+	if accept == "" {
+		return offers[0]
+	}
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		for _, offer := range offers {
+			matches := mediaType == offer || mediaType == "*/*" ||
+				(strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*")))
+			if matches && q > bestQ {
+				best, bestQ = offer, q
+			}
+		}
+	}
+	return best
+}
+
+func sexLetter(sex uint8) string {
+	if sex == 1 {
+		return "M"
+	}
+	return "F"
+}
+
+// sortManifest orders the passengers by seat with infants without a seat after their escorts, or by name
+func sortManifest(passengers []ManifestPassenger, by string) {
+	seats := make(map[int]string, len(passengers))
+	for _, p := range passengers {
+		seats[p.TicketID] = p.SeatNumber
+	}
+	seatOf := func(p ManifestPassenger) string {
+		if p.SeatNumber == "" && p.AccompanyingTicketID != nil {
+			return seats[*p.AccompanyingTicketID]
+		}
+		return p.SeatNumber
+	}
+	sort.SliceStable(passengers, func(i, j int) bool {
+		a, b := passengers[i], passengers[j]
+		if by == "seat" {
+			sa, sb := seatOf(a), seatOf(b)
+			if sa != sb {
+				return reseat.Less(sa, sb)
+			}
+			if (a.SeatNumber == "") != (b.SeatNumber == "") {
+				return a.SeatNumber != ""
+			}
+		}
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		return a.GivenName < b.GivenName
+	})
+}
+
+func (s *server) writeManifestCSV(w http.ResponseWriter, m *Manifest) {
+	w.Header().Set("Content-Type", contentTypeCSV+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"manifest-%d.csv\"", m.Flight.ID))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"seat", "class", "ticket", "last_name", "given_name", "birth_date", "sex", "type", "document_type", "document_number", "document_country", "infant_of_ticket"})
+	for _, p := range m.Passengers {
+		infantOf := ""
+		if p.AccompanyingTicketID != nil && p.SeatNumber == "" {
+			infantOf = strconv.Itoa(*p.AccompanyingTicketID)
+		}
+		cw.Write([]string{
+			p.SeatNumber,
+			p.Class,
+			strconv.Itoa(p.TicketID),
+			p.LastName,
+			p.GivenName,
+			p.BirthDate.Format("2006-01-02"),
+			p.Sex,
+			p.PassengerType,
+			p.DocumentType,
+			p.DocumentNumber,
+			p.DocumentCountry,
+			infantOf,
+		})
+	}
+	cw.Flush()
+}
+
+func (s *server) writeManifestPDF(w http.ResponseWriter, m *Manifest) {
+	const row = "%-5s %-2s %-8s %-24s %-20s %-10s %-1s %-3s %-16s %-20s %-3s"
+	title := fmt.Sprintf("Manifest %s %s %s-%s", m.Flight.LineCode, m.Flight.Departure.Format("2006-01-02 15:04 MST"), m.Flight.DepAirport, m.Flight.ArrAirport)
+	doc := &pdf.Document{
+		Title: title,
+		Header: []string{
+			title,
+			fmt.Sprintf("Aircraft %s, passengers: %d", m.Flight.LinerCode, len(m.Passengers)),
+			"",
+			fmt.Sprintf(row, "SEAT", "CL", "TICKET", "LAST NAME", "GIVEN NAME", "BORN", "S", "PAX", "DOCUMENT", "NUMBER", "CTY"),
+			strings.Repeat("-", pdf.LineWidth),
+		},
+	}
+	for _, p := range m.Passengers {
+		seat := p.SeatNumber
+		if seat == "" {
+			seat = "INF"
+		}
+		doc.Lines = append(doc.Lines, fmt.Sprintf(row,
+			seat,
+			p.Class,
+			strconv.Itoa(p.TicketID),
+			translit.Text(p.LastName),
+			translit.Text(p.GivenName),
+			p.BirthDate.Format("2006-01-02"),
+			p.Sex,
+			p.PassengerType,
+			p.DocumentType,
+			p.DocumentNumber,
+			p.DocumentCountry,
+		))
+	}
+
+	w.Header().Set("Content-Type", contentTypePDF)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"manifest-%d.pdf\"", m.Flight.ID))
+	w.WriteHeader(http.StatusOK)
+	doc.WriteTo(w)
+}
+
+// handleFlightManifestGet lists the passengers of the flight. Document numbers are masked unless the cashier
+// may see them, the format is chosen by the Accept header
+func (s *server) handleFlightManifestGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		by := r.URL.Query().Get("sort")
+		if by == "" {
+			by = "seat"
+		}
+		if by != "seat" && by != "name" {
+			s.error(w, r, http.StatusBadRequest, errBadManifestSort)
+			return
+		}
+		format := negotiate(r.Header.Get("Accept"), contentTypeJSON, contentTypeCSV, contentTypePDF)
+		if format == "" {
+			s.error(w, r, http.StatusNotAcceptable, errors.New("манифест доступен в форматах "+strings.Join([]string{contentTypeJSON, contentTypeCSV, contentTypePDF}, ", ")))
+			return
+		}
+
+		leg, err := s.store.Flight().FindLeg(id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		flight, err := flightLegFromModel(leg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		entries, err := s.store.FlightInTicket().FindManifest(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		fullDocuments := hasPermission(c, permPassengerDocuments)
+		manifest := &Manifest{
+			Flight:     *flight,
+			Passengers: make([]ManifestPassenger, len(entries)),
+		}
+		for i, e := range entries {
+			number := e.DocumentNumber
+			if !fullDocuments {
+				number = maskDocumentNumber(number)
+			}
+			manifest.Passengers[i] = ManifestPassenger{
+				SegmentID:            e.SegmentID,
+				TicketID:             e.TicketID,
+				LastName:             e.LastName,
+				GivenName:            e.GivenName,
+				BirthDate:            e.BirthDate,
+				Sex:                  sexLetter(e.Sex),
+				PassengerType:        string(pricing.PassengerTypeOf(ageAt(e.BirthDate, leg.DepDate))),
+				DocumentType:         e.DocumentType,
+				DocumentNumber:       number,
+				DocumentCountry:      e.DocumentCountry,
+				SeatNumber:           e.SeatNumber,
+				Class:                e.Class,
+				AccompanyingTicketID: e.AccompanyingTicketID,
+			}
+		}
+		sortManifest(manifest.Passengers, by)
+
+		switch format {
+		case contentTypeCSV:
+			s.writeManifestCSV(w, manifest)
+		case contentTypePDF:
+			s.writeManifestPDF(w, manifest)
+		default:
+			s.respond(w, r, http.StatusOK, manifest)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+-- Поисковые ключи имён пассажиров (см. internal/translit). Для существующих билетов заполняются один раз командой aviasales -fill-name-keys
+ALTER TABLE ticket
+    ADD COLUMN pass_last_name_key VARCHAR(128) NOT NULL DEFAULT '',
+    ADD COLUMN pass_given_name_key VARCHAR(256) NOT NULL DEFAULT '',
+    ADD INDEX ticket_pass_last_name_key_idx (pass_last_name_key),
+    ADD INDEX ticket_pass_given_name_key_idx (pass_given_name_key),
+    ADD INDEX ticket_pass_passport_number_idx (pass_passport_number),
+    ADD INDEX ticket_pass_birth_date_idx (pass_birth_date);
@@ -0,0 +1,31 @@
+-- Профили пассажиров. Билеты ссылаются на профиль, сохраняя собственную копию данных пассажира на момент продажи
+CREATE TABLE passenger (
+    id INT NOT NULL AUTO_INCREMENT,
+    last_name VARCHAR(64) NOT NULL,
+    given_name VARCHAR(128) NOT NULL,
+    birth_date DATE NOT NULL,
+    sex TINYINT UNSIGNED NOT NULL,
+    passport_number VARCHAR(10) NOT NULL,
+    contact_phone VARCHAR(15) NOT NULL DEFAULT '',
+    contact_email VARCHAR(255) NOT NULL DEFAULT '',
+    last_name_key VARCHAR(128) NOT NULL DEFAULT '',
+    given_name_key VARCHAR(256) NOT NULL DEFAULT '',
+    PRIMARY KEY (id),
+    INDEX passenger_passport_number_idx (passport_number),
+    INDEX passenger_birth_date_idx (birth_date),
+    INDEX passenger_last_name_key_idx (last_name_key)
+);
+
+ALTER TABLE ticket
+    ADD COLUMN passenger_id INT NULL,
+    ADD CONSTRAINT ticket_passenger_fk FOREIGN KEY (passenger_id) REFERENCES passenger (id) ON DELETE SET NULL;
+
+-- Профили для уже проданных билетов: по одному на пару номер паспорта и дата рождения
+INSERT INTO passenger (last_name, given_name, birth_date, sex, passport_number)
+SELECT MAX(pass_last_name), MAX(pass_given_name), pass_birth_date, MAX(pass_sex), pass_passport_number
+FROM ticket
+GROUP BY pass_passport_number, pass_birth_date;
+
+UPDATE ticket t
+    INNER JOIN passenger p ON p.passport_number = t.pass_passport_number AND p.birth_date = t.pass_birth_date
+SET t.passenger_id = p.id;
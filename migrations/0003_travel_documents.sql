@@ -0,0 +1,23 @@
+-- Документы пассажиров разных типов вместо единственного номера внутреннего паспорта
+CREATE TABLE passenger_document (
+    id INT NOT NULL AUTO_INCREMENT,
+    passenger_id INT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    number VARCHAR(32) NOT NULL,
+    issuing_country CHAR(3) NOT NULL,
+    expiry_date DATE NULL,
+    PRIMARY KEY (id),
+    INDEX passenger_document_number_idx (number),
+    CONSTRAINT passenger_document_passenger_fk FOREIGN KEY (passenger_id) REFERENCES passenger (id) ON DELETE CASCADE
+);
+
+INSERT INTO passenger_document (passenger_id, type, number, issuing_country)
+SELECT id, 'ru_internal', passport_number, 'RUS' FROM passenger;
+
+ALTER TABLE passenger DROP COLUMN passport_number;
+
+ALTER TABLE ticket
+    MODIFY pass_passport_number VARCHAR(32) NOT NULL,
+    ADD COLUMN pass_document_type VARCHAR(32) NOT NULL DEFAULT 'ru_internal',
+    ADD COLUMN pass_document_country CHAR(3) NOT NULL DEFAULT 'RUS',
+    ADD COLUMN pass_document_expiry DATE NULL;
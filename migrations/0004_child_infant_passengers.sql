@@ -0,0 +1,13 @@
+-- Младенцы без места летят с сопровождающим взрослым. Класс обслуживания хранится в полёте билета,
+-- так как у полёта младенца без места нет места
+ALTER TABLE ticket
+    ADD COLUMN accompanying_ticket_id INT NULL,
+    ADD CONSTRAINT ticket_accompanying_ticket_fk FOREIGN KEY (accompanying_ticket_id) REFERENCES ticket (id) ON DELETE SET NULL;
+
+ALTER TABLE flight_in_ticket
+    MODIFY seat_id INT NULL,
+    ADD COLUMN class CHAR(1) NOT NULL DEFAULT 'Y';
+
+UPDATE flight_in_ticket fit
+    INNER JOIN seat s ON s.id = fit.seat_id
+SET fit.class = s.class;
@@ -0,0 +1,7 @@
+-- Число суток между местными датами вылета и прилёта. Раньше сервер добавлял сутки, если прилёт по GMT
+-- получался раньше вылета. Для существующих рейсов смещение проставляется по местному времени,
+-- рейсы на запад через полночь нужно проверить вручную
+ALTER TABLE line
+    ADD COLUMN arr_day_offset TINYINT UNSIGNED NOT NULL DEFAULT 0;
+
+UPDATE line SET arr_day_offset = 1 WHERE arr_time < dep_time;
@@ -0,0 +1,4 @@
+-- Схема салона модели самолёта в формате JSON: салоны по классам, буквы кресел, проходы, аварийные ряды,
+-- заблокированные места и особенности мест. Места (таблица seat) генерируются по схеме
+ALTER TABLE liner_model
+    ADD COLUMN layout JSON NULL;
@@ -0,0 +1,25 @@
+-- Оперативный статус рейса с расчётным и фактическим временем и история его изменений
+ALTER TABLE flight
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'scheduled',
+    ADD COLUMN est_dep_time DATETIME NULL,
+    ADD COLUMN est_arr_time DATETIME NULL,
+    ADD COLUMN actual_dep_time DATETIME NULL,
+    ADD COLUMN actual_arr_time DATETIME NULL;
+
+CREATE TABLE flight_status_change (
+    id INT NOT NULL AUTO_INCREMENT,
+    flight_id INT NOT NULL,
+    prev_status VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    est_dep_time DATETIME NULL,
+    est_arr_time DATETIME NULL,
+    actual_dep_time DATETIME NULL,
+    actual_arr_time DATETIME NULL,
+    reason VARCHAR(256) NOT NULL DEFAULT '',
+    cashier_id INT NOT NULL,
+    changed_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    INDEX flight_status_change_flight_idx (flight_id, changed_at),
+    CONSTRAINT flight_status_change_flight_fk FOREIGN KEY (flight_id) REFERENCES flight (id) ON DELETE CASCADE,
+    CONSTRAINT flight_status_change_cashier_fk FOREIGN KEY (cashier_id) REFERENCES cashier (id)
+);
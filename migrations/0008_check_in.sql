@@ -0,0 +1,6 @@
+-- Регистрация пассажиров на рейс и посадка: время регистрации, порядковый номер регистрации на рейсе и время посадки
+ALTER TABLE flight_in_ticket
+    ADD COLUMN checked_in_at DATETIME NULL,
+    ADD COLUMN checkin_sequence INT NULL,
+    ADD COLUMN boarded_at DATETIME NULL,
+    ADD UNIQUE INDEX flight_in_ticket_checkin_sequence_idx (flight_id, checkin_sequence);
@@ -0,0 +1,9 @@
+-- Разрешённая к продаже ёмкость классов рейса: процент овербукинга сверх физических мест или явно заданное число пассажиров
+CREATE TABLE flight_capacity (
+    flight_id INT NOT NULL,
+    class CHAR(1) NOT NULL,
+    overbooking_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    authorized_capacity INT NULL,
+    PRIMARY KEY (flight_id, class),
+    CONSTRAINT flight_capacity_flight_fk FOREIGN KEY (flight_id) REFERENCES flight (id) ON DELETE CASCADE
+);
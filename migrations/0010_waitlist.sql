@@ -0,0 +1,20 @@
+-- Очередь ожидания мест на рейс по классам с приоритетом и предложениями освободившихся мест
+CREATE TABLE waitlist_entry (
+    id INT NOT NULL AUTO_INCREMENT,
+    flight_id INT NOT NULL,
+    class CHAR(1) NOT NULL,
+    ticket_id INT NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'waiting',
+    seat_id INT NULL,
+    offered_at DATETIME NULL,
+    offer_expires_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    cashier_id INT NOT NULL,
+    PRIMARY KEY (id),
+    INDEX waitlist_entry_flight_idx (flight_id, status),
+    CONSTRAINT waitlist_entry_flight_fk FOREIGN KEY (flight_id) REFERENCES flight (id) ON DELETE CASCADE,
+    CONSTRAINT waitlist_entry_ticket_fk FOREIGN KEY (ticket_id) REFERENCES ticket (id) ON DELETE CASCADE,
+    CONSTRAINT waitlist_entry_seat_fk FOREIGN KEY (seat_id) REFERENCES seat (id) ON DELETE SET NULL,
+    CONSTRAINT waitlist_entry_cashier_fk FOREIGN KEY (cashier_id) REFERENCES cashier (id)
+);
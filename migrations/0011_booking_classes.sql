@@ -0,0 +1,33 @@
+-- Классы бронирования (RBD) внутри салонов: собственный тариф и вложенный лимит продаж, лимиты рейса и класс, проданный в сегменте
+CREATE TABLE booking_class (
+    code CHAR(1) NOT NULL,
+    cabin CHAR(1) NOT NULL,
+    fare_multiplier DECIMAL(5, 3) NOT NULL,
+    booking_limit_percent DECIMAL(5, 2) NOT NULL DEFAULT 100,
+    PRIMARY KEY (code)
+);
+
+INSERT INTO booking_class (code, cabin, fare_multiplier, booking_limit_percent) VALUES
+    ('J', 'J', 2.000, 100),
+    ('C', 'J', 1.800, 70),
+    ('D', 'J', 1.600, 40),
+    ('Y', 'Y', 1.500, 100),
+    ('B', 'Y', 1.350, 70),
+    ('M', 'Y', 1.200, 40),
+    ('W', 'W', 1.000, 100),
+    ('H', 'W', 0.900, 75),
+    ('K', 'W', 0.800, 50),
+    ('L', 'W', 0.700, 25);
+
+CREATE TABLE flight_booking_limit (
+    flight_id INT NOT NULL,
+    booking_class CHAR(1) NOT NULL,
+    booking_limit INT NOT NULL,
+    PRIMARY KEY (flight_id, booking_class),
+    CONSTRAINT flight_booking_limit_flight_fk FOREIGN KEY (flight_id) REFERENCES flight (id) ON DELETE CASCADE,
+    CONSTRAINT flight_booking_limit_class_fk FOREIGN KEY (booking_class) REFERENCES booking_class (code) ON DELETE CASCADE
+);
+
+ALTER TABLE flight_in_ticket
+    ADD COLUMN booking_class CHAR(1) NULL,
+    ADD CONSTRAINT flight_in_ticket_booking_class_fk FOREIGN KEY (booking_class) REFERENCES booking_class (code);
@@ -0,0 +1,45 @@
+-- Условия тарифов классов бронирования и журнал возвратов и обменов сегментов
+CREATE TABLE fare_rule (
+    booking_class CHAR(1) NOT NULL,
+    refundable BOOLEAN NOT NULL DEFAULT TRUE,
+    change_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    no_show_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    min_stay_days INT NULL,
+    max_stay_days INT NULL,
+    advance_purchase_days INT NULL,
+    PRIMARY KEY (booking_class),
+    CONSTRAINT fare_rule_booking_class_fk FOREIGN KEY (booking_class) REFERENCES booking_class (code) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+INSERT INTO fare_rule (booking_class, refundable, change_fee, no_show_fee, min_stay_days, max_stay_days, advance_purchase_days) VALUES
+    ('J', TRUE, 0, 0, NULL, NULL, NULL),
+    ('C', TRUE, 1000, 2000, NULL, NULL, NULL),
+    ('D', FALSE, 3000, 5000, NULL, NULL, 7),
+    ('Y', TRUE, 0, 0, NULL, NULL, NULL),
+    ('B', TRUE, 1000, 2000, NULL, NULL, NULL),
+    ('M', FALSE, 2000, 3000, NULL, NULL, 7),
+    ('W', TRUE, 500, 1000, NULL, NULL, NULL),
+    ('H', TRUE, 1000, 1500, NULL, NULL, 3),
+    ('K', FALSE, 1500, 2000, NULL, 30, 14),
+    ('L', FALSE, 2500, 2500, 3, 30, 21);
+
+CREATE TABLE ticket_operation (
+    id INT NOT NULL AUTO_INCREMENT,
+    ticket_id INT NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    flight_id INT NOT NULL,
+    booking_class CHAR(1) NULL,
+    fare DECIMAL(10, 2) NOT NULL,
+    new_flight_id INT NULL,
+    new_booking_class CHAR(1) NULL,
+    new_fare DECIMAL(10, 2) NULL,
+    fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    amount DECIMAL(10, 2) NOT NULL,
+    involuntary BOOLEAN NOT NULL DEFAULT FALSE,
+    cashier_id INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    INDEX ticket_operation_ticket_idx (ticket_id, created_at),
+    CONSTRAINT ticket_operation_ticket_fk FOREIGN KEY (ticket_id) REFERENCES ticket (id) ON DELETE CASCADE,
+    CONSTRAINT ticket_operation_cashier_fk FOREIGN KEY (cashier_id) REFERENCES cashier (id)
+);
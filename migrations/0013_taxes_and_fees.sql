@@ -0,0 +1,15 @@
+-- Сборы аэропортов за вылет и прилёт, топливный сбор линии и сервисный сбор кассы за билет
+CREATE TABLE airport_tax (
+    iata_code CHAR(3) NOT NULL,
+    tax_code CHAR(2) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    applies VARCHAR(16) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (iata_code, tax_code, applies)
+);
+
+ALTER TABLE line
+    ADD COLUMN fuel_surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE booking_office
+    ADD COLUMN service_fee DECIMAL(10, 2) NOT NULL DEFAULT 0;
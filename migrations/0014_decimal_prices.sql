@@ -0,0 +1,6 @@
+-- Базовая цена линии и итоговая цена покупки хранятся точно, в копейках, как остальные суммы
+ALTER TABLE line
+    MODIFY COLUMN base_price DECIMAL(10, 2) NOT NULL;
+
+ALTER TABLE purchase
+    MODIFY COLUMN total_price DECIMAL(10, 2) NOT NULL;
@@ -0,0 +1,27 @@
+-- Валюты цен линий, сборов аэропортов, касс и покупок, курсы валют к рублю с датами начала действия и пересчёт покупки в рубли по курсу на дату продажи
+CREATE TABLE exchange_rate (
+    currency CHAR(3) NOT NULL,
+    effective_date DATE NOT NULL,
+    rate DECIMAL(18, 6) NOT NULL,
+    PRIMARY KEY (currency, effective_date)
+);
+
+ALTER TABLE line
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE airport_tax
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE booking_office
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE purchase
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    ADD COLUMN exchange_rate DECIMAL(18, 6) NOT NULL DEFAULT 1,
+    ADD COLUMN rate_date DATE NULL,
+    ADD COLUMN base_total_price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+UPDATE purchase SET base_total_price = total_price;
+
+ALTER TABLE ticket_operation
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
@@ -0,0 +1,48 @@
+-- Промокоды акций со сроком действия, допустимыми линиями и классами и лимитами использований, журнал их использования в покупках
+CREATE TABLE promo_campaign (
+    code VARCHAR(32) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    valid_from DATETIME NOT NULL,
+    valid_to DATETIME NOT NULL,
+    max_uses INT NULL,
+    max_uses_per_customer INT NULL,
+    PRIMARY KEY (code)
+);
+
+CREATE TABLE promo_campaign_line (
+    code VARCHAR(32) NOT NULL,
+    line_code VARCHAR(8) NOT NULL,
+    PRIMARY KEY (code, line_code),
+    CONSTRAINT promo_campaign_line_code_fk FOREIGN KEY (code) REFERENCES promo_campaign (code) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE promo_campaign_class (
+    code VARCHAR(32) NOT NULL,
+    class CHAR(1) NOT NULL,
+    PRIMARY KEY (code, class),
+    CONSTRAINT promo_campaign_class_code_fk FOREIGN KEY (code) REFERENCES promo_campaign (code) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+ALTER TABLE purchase
+    ADD COLUMN promo_code VARCHAR(32) NULL,
+    ADD COLUMN discount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE promo_usage (
+    id INT NOT NULL AUTO_INCREMENT,
+    code VARCHAR(32) NOT NULL,
+    purchase_id INT NOT NULL,
+    customer VARCHAR(255) NOT NULL,
+    discount DECIMAL(10, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    base_discount DECIMAL(10, 2) NOT NULL,
+    used_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX promo_usage_purchase_idx (purchase_id),
+    INDEX promo_usage_customer_idx (code, customer),
+    CONSTRAINT promo_usage_code_fk FOREIGN KEY (code) REFERENCES promo_campaign (code) ON UPDATE CASCADE,
+    CONSTRAINT promo_usage_purchase_fk FOREIGN KEY (purchase_id) REFERENCES purchase (id) ON DELETE CASCADE
+);
@@ -0,0 +1,37 @@
+-- Координаты аэропортов, счета участников программы лояльности, журнал начисления и списания миль и оплата покупок милями
+-- Миля начисляется за расстояние между аэропортами, поэтому координаты аэропортов добавляются здесь,
+-- остальные данные аэропортов добавляются отдельно
+ALTER TABLE airport
+    ADD COLUMN latitude DECIMAL(9, 6) NULL,
+    ADD COLUMN longitude DECIMAL(9, 6) NULL;
+
+CREATE TABLE loyalty_account (
+    id INT NOT NULL AUTO_INCREMENT,
+    passenger_id INT NOT NULL,
+    enrolled_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX loyalty_account_passenger_idx (passenger_id),
+    CONSTRAINT loyalty_account_passenger_fk FOREIGN KEY (passenger_id) REFERENCES passenger (id) ON DELETE CASCADE
+);
+
+ALTER TABLE purchase
+    ADD COLUMN loyalty_account_id INT NULL,
+    ADD COLUMN redeemed_miles INT NOT NULL DEFAULT 0,
+    ADD COLUMN miles_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT purchase_loyalty_account_fk FOREIGN KEY (loyalty_account_id) REFERENCES loyalty_account (id) ON DELETE SET NULL;
+
+CREATE TABLE loyalty_entry (
+    id INT NOT NULL AUTO_INCREMENT,
+    account_id INT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    miles INT NOT NULL,
+    segment_id INT NULL,
+    purchase_id INT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX loyalty_entry_segment_idx (segment_id),
+    INDEX loyalty_entry_account_idx (account_id, created_at),
+    CONSTRAINT loyalty_entry_account_fk FOREIGN KEY (account_id) REFERENCES loyalty_account (id) ON DELETE CASCADE,
+    CONSTRAINT loyalty_entry_purchase_fk FOREIGN KEY (purchase_id) REFERENCES purchase (id) ON DELETE CASCADE
+);
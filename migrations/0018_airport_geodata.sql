@@ -0,0 +1,5 @@
+-- Код ИКАО, название и страна аэропортов
+ALTER TABLE airport
+    ADD COLUMN icao_code VARCHAR(4) NOT NULL DEFAULT '',
+    ADD COLUMN name VARCHAR(128) NOT NULL DEFAULT '',
+    ADD COLUMN country CHAR(2) NOT NULL DEFAULT '';
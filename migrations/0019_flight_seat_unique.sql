@@ -0,0 +1,3 @@
+-- Место на рейсе может занимать только один полёт билета. Полёты без места (NULL) индекс не ограничивает
+ALTER TABLE flight_in_ticket
+    ADD UNIQUE INDEX flight_in_ticket_seat_idx (flight_id, seat_id);
@@ -0,0 +1,5 @@
+-- Тариф, по которому продан сегмент, в валюте продажи: возврат и обмен считаются от него, а не от текущей цены линии.
+-- У сегментов, проданных раньше, тариф не записан и считается по цене линии
+ALTER TABLE flight_in_ticket
+    ADD COLUMN fare DECIMAL(10, 2) NULL,
+    ADD COLUMN fare_currency CHAR(3) NULL;
@@ -0,0 +1,19 @@
+-- Состав цены, записанный при продаже: топливный сбор и сборы аэропортов сегмента, сервисный сбор кассы за билет покупки.
+-- Отчёт по билету строится по ним, а не по текущим сборам. У проданных раньше сборы не записаны и берутся текущие
+ALTER TABLE flight_in_ticket
+    ADD COLUMN fuel_surcharge DECIMAL(10, 2) NULL;
+
+CREATE TABLE segment_tax (
+    flight_in_ticket_id INT NOT NULL,
+    airport CHAR(3) NOT NULL,
+    tax_code CHAR(2) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    PRIMARY KEY (flight_in_ticket_id, airport, tax_code),
+    CONSTRAINT segment_tax_flight_in_ticket_fk FOREIGN KEY (flight_in_ticket_id) REFERENCES flight_in_ticket (id) ON DELETE CASCADE
+);
+
+ALTER TABLE purchase
+    ADD COLUMN service_fee DECIMAL(10, 2) NULL,
+    ADD COLUMN service_fee_currency CHAR(3) NULL;
@@ -0,0 +1,5 @@
+-- Сборы аэропортов и топливный сбор сегмента до и после операции в валюте операции: по ним итог покупки
+-- сверяется с билетами после возвратов и обменов. У операций, сделанных раньше, сборы не записаны и считаются нулевыми
+ALTER TABLE ticket_operation
+    ADD COLUMN taxes DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN new_taxes DECIMAL(10, 2) NULL;
@@ -0,0 +1,6 @@
+-- Списание миль остаётся в журнале счёта, если покупка удалена: мили не возвращаются на счёт вместе с удалённой записью
+ALTER TABLE loyalty_entry
+    DROP FOREIGN KEY loyalty_entry_purchase_fk;
+
+ALTER TABLE loyalty_entry
+    ADD CONSTRAINT loyalty_entry_purchase_fk FOREIGN KEY (purchase_id) REFERENCES purchase (id) ON DELETE SET NULL;
@@ -0,0 +1,325 @@
+// Файл overbooking.go содержит ёмкость классов рейса с овербукингом, продажу сверх мест и отчёт о перепроданных рейсах
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/overbooking"
+	"github.com/akionka/aviasales/internal/reseat"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// Oversold flights are looked for within that many days from today unless the period is given
+const oversoldReportDays = 7
+
+var cabinClasses = []string{"J", "W", "Y"}
+
+var (
+	errClassSoldOut  = segmentRuleError("в этом классе рейса продана вся разрешённая ёмкость")
+	errClassRequired = segmentRuleError("для продажи без места укажите класс")
+)
+
+// classLoads returns the sales of every class of the flight against its physical seats and overbooking limits
+// along with the seats held for the waitlist. Infants without a seat are not counted, nor is the segment exceptID
+func (s *server) classLoads(st store.Store, flight *store.FlightModel, exceptID int) (map[string]*overbooking.Load, error) {
+	loads := make(map[string]*overbooking.Load, len(cabinClasses))
+	for _, class := range cabinClasses {
+		loads[class] = &overbooking.Load{Class: class}
+	}
+
+	liner, err := st.Liner().Find(flight.LinerCode)
+	if err != nil {
+		return nil, err
+	}
+	seats, err := st.Seat().FindByModel(liner.ModelCode)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range seats {
+		if l, ok := loads[v.Class]; ok {
+			l.Physical++
+		}
+	}
+
+	capacities, err := st.Flight().FindCapacities(flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range capacities {
+		if l, ok := loads[v.Class]; ok {
+			l.Limit = overbooking.Limit{Percent: v.OverbookingPercent, Authorized: v.AuthorizedCapacity}
+		}
+	}
+
+	offers, err := s.activeOffers(st, flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range offers {
+		if l, ok := loads[v.Class]; ok {
+			l.Held++
+		}
+	}
+
+	entries, err := st.FlightInTicket().FindManifest(flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		l, ok := loads[e.Class]
+		if !ok || e.SegmentID == exceptID {
+			continue
+		}
+		if e.SeatNumber == "" && e.AccompanyingTicketID != nil {
+			continue
+		}
+		if e.SeatNumber != "" {
+			l.Seated++
+		} else {
+			l.Seatless++
+		}
+		if e.CheckedInAt != nil {
+			l.CheckedIn++
+		}
+		if e.BoardedAt != nil {
+			l.Boarded++
+		}
+	}
+	return loads, nil
+}
+
+// checkCapacity checks that the passenger of the segment fits the authorized capacity of the class
+// and sets the booking class the segment is sold in. A segment without a seat must have the class it is sold in
+func (s *server) checkCapacity(st store.Store, f *store.FlightInTicketModel, flight *store.FlightModel) error {
+	if f.Class == "" {
+		return errClassRequired
+	}
+	loads, err := s.classLoads(st, flight, f.ID)
+	if err != nil {
+		return err
+	}
+	offers, err := s.activeOffers(st, flight.ID)
+	if err != nil {
+		return err
+	}
+	for _, v := range offers {
+		if v.TicketID == f.TicketID && v.Class == f.Class {
+			// The passenger takes the seat held for them
+			loads[f.Class].Held--
+		}
+	}
+	if err := loads[f.Class].CanSell(); err != nil {
+		return errClassSoldOut
+	}
+	return s.sellBookingClass(st, f, flight, loads)
+}
+
+// vacantSeats returns the seats of the liner of the flight not taken by any passenger
+func (s *server) vacantSeats(st store.Store, flight *store.FlightModel) ([]store.SeatModel, error) {
+	liner, err := st.Liner().Find(flight.LinerCode)
+	if err != nil {
+		return nil, err
+	}
+	seats, err := st.Seat().FindByModel(liner.ModelCode)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := st.FlightInTicket().FindFlightSegments(flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[int]bool, len(segments))
+	for _, v := range segments {
+		if v.SeatID != nil {
+			taken[*v.SeatID] = true
+		}
+	}
+	vacant := seats[:0]
+	for _, v := range seats {
+		if !taken[v.ID] {
+			vacant = append(vacant, v)
+		}
+	}
+	return vacant, nil
+}
+
+// freeSeat returns the frontmost vacant seat of the class not held for the waitlist
+func (s *server) freeSeat(st store.Store, flight *store.FlightModel, class string) (*store.SeatModel, error) {
+	seats, err := s.vacantSeats(st, flight)
+	if err != nil {
+		return nil, err
+	}
+	offers, err := s.activeOffers(st, flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[int]bool, len(offers))
+	for _, v := range offers {
+		if v.SeatID != nil {
+			held[*v.SeatID] = true
+		}
+	}
+
+	var free *store.SeatModel
+	for i := range seats {
+		v := &seats[i]
+		if v.Class != class || held[v.ID] {
+			continue
+		}
+		if free == nil || reseat.Less(v.Number, free.Number) {
+			free = v
+		}
+	}
+	if free == nil {
+		return nil, overbooking.ErrNoSeat
+	}
+	return free, nil
+}
+
+func flightCapacities(loads map[string]*overbooking.Load) []FlightCapacity {
+	capacities := make([]FlightCapacity, 0, len(cabinClasses))
+	for _, class := range cabinClasses {
+		l := loads[class]
+		if l.Physical == 0 && l.Sold() == 0 {
+			continue
+		}
+		capacities = append(capacities, FlightCapacity{
+			Class:              class,
+			Physical:           l.Physical,
+			OverbookingPercent: l.Limit.Percent,
+			AuthorizedCapacity: l.Limit.Authorized,
+			Authorized:         l.Authorized(),
+			Seated:             l.Seated,
+			Seatless:           l.Seatless,
+			Sold:               l.Sold(),
+			Held:               l.Held,
+			Available:          l.Available(),
+			CheckedIn:          l.CheckedIn,
+			Boarded:            l.Boarded,
+			Oversold:           l.Oversold(),
+		})
+	}
+	return capacities
+}
+
+func (s *server) flightLoad(leg *store.FlightLegModel) (*FlightLoad, error) {
+	flight, err := flightLegFromModel(leg)
+	if err != nil {
+		return nil, err
+	}
+	loads, err := s.classLoads(s.store, &leg.FlightModel, 0)
+	if err != nil {
+		return nil, err
+	}
+	load := &FlightLoad{Flight: *flight, Classes: flightCapacities(loads)}
+	for _, c := range load.Classes {
+		load.Oversold += c.Oversold
+	}
+	return load, nil
+}
+
+// handleFlightCapacityGetUpdate returns the sales of the classes of the flight against their capacity
+// or sets the overbooking limits of the classes given
+func (s *server) handleFlightCapacityGetUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		leg, err := s.store.Flight().FindLeg(id)
+		if err != nil {
+			code, err := flightStatusErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var limits []FlightCapacityLimit
+			if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			for i := range limits {
+				if err := limits[i].Validate(); err != nil {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+			}
+			if err := s.store.Transaction(func(tx store.Store) error {
+				for _, v := range limits {
+					if err := tx.Flight().SetCapacity(&store.FlightCapacityModel{
+						FlightID:           id,
+						Class:              v.Class,
+						OverbookingPercent: v.OverbookingPercent,
+						AuthorizedCapacity: v.AuthorizedCapacity,
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.offerFreedSeats(id)
+		}
+
+		load, err := s.flightLoad(leg)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, load)
+	}
+}
+
+// handleOversoldFlightsGet lists the flights of the period that have more passengers sold than seats
+// in any class, the most oversold first
+func (s *server) handleOversoldFlightsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		query := &store.FlightQuery{FromDate: today, ToDate: today.AddDate(0, 0, oversoldReportDays)}
+		for param, date := range map[string]*time.Time{"from": &query.FromDate, "to": &query.ToDate} {
+			if v := r.URL.Query().Get(param); v != "" {
+				d, err := time.Parse("2006-01-02", v)
+				if err != nil {
+					s.error(w, r, http.StatusBadRequest, errBadDate)
+					return
+				}
+				*date = d
+			}
+		}
+
+		legs, err := s.store.Flight().FindLegs(query)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := []FlightLoad{}
+		for i := range legs {
+			load, err := s.flightLoad(&legs[i])
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			if load.Oversold > 0 {
+				response = append(response, *load)
+			}
+		}
+		sort.SliceStable(response, func(i, j int) bool {
+			return response[i].Oversold > response[j].Oversold
+		})
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// isLapInfant tells if the passenger of the ticket flies without a seat on the lap of the escort
+func isLapInfant(t *store.TicketModel, f *store.FlightInTicketModel) bool {
+	return f.SeatID == nil && t.AccompanyingTicketID != nil
+}
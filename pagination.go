@@ -0,0 +1,89 @@
+// Файл pagination.go содержит код постраничного вывода списков: курсоры, ограничение размера страницы и общее количество записей
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/akionka/aviasales/internal/store"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 500
+)
+
+var errBadCursor = errors.New("некорректный курсор")
+
+type paginationInfo struct {
+	rowCount  int
+	page      int
+	cursor    *store.Cursor
+	withTotal bool
+}
+
+// encodeCursor returns an opaque token for the cursor
+func encodeCursor(c store.Cursor) string {
+	direction := "a"
+	if c.Backward {
+		direction = "b"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(direction + ":" + c.Key))
+}
+
+func decodeCursor(token string) (*store.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	direction, key, ok := strings.Cut(string(b), ":")
+	if !ok || key == "" {
+		return nil, errBadCursor
+	}
+	switch direction {
+	case "a":
+		return &store.Cursor{Key: key}, nil
+	case "b":
+		return &store.Cursor{Key: key, Backward: true}, nil
+	}
+	return nil, errBadCursor
+}
+
+// offset returns the row offset of the page for page based pagination
+func (p paginationInfo) offset() int {
+	return (p.page - 1) * p.rowCount
+}
+
+// cursors returns the tokens of the previous and the next pages given the keys of the first and the last items of the
+// current page and the number of items on it. Empty token means there is no such page
+func (p paginationInfo) cursors(first, last string, n int) (prev, next string) {
+	if n == 0 {
+		return "", ""
+	}
+	full := n >= p.rowCount
+	hasPrev := p.page > 1 || p.cursor != nil
+	hasNext := full
+	if p.cursor != nil && p.cursor.Backward {
+		hasPrev, hasNext = full, true
+	}
+	if hasPrev {
+		prev = encodeCursor(store.Cursor{Key: first, Backward: true})
+	}
+	if hasNext {
+		next = encodeCursor(store.Cursor{Key: last})
+	}
+	return prev, next
+}
+
+// totalCount calls count only if the client asked for the total number of items
+func (p paginationInfo) totalCount(count func() (int, error)) (*int, error) {
+	if !p.withTotal {
+		return nil, nil
+	}
+	c, err := count()
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
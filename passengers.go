@@ -0,0 +1,549 @@
+// Файл passengers.go содержит обработчики HTTP запросов профилей пассажиров: просмотр, добавление, удаление, изменение, поиск и объединение дубликатов
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/akionka/aviasales/internal/checkin"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/akionka/aviasales/internal/translit"
+	"github.com/gorilla/mux"
+)
+
+const (
+	passengerSearchLimit = 100
+	// Passengers with the same document are duplicates if their names are at least that similar
+	// or they were born on the same day
+	duplicateDocumentNameSimilarity = 0.8
+	// Passengers born on the same day with different documents are duplicates if their names are at least that similar
+	duplicateBirthDateNameSimilarity = 0.9
+)
+
+var (
+	errEmptySearchQuery       = errors.New("пустой поисковый запрос")
+	errPassengerHasNoDocument = errors.New("у пассажира нет документа указанного типа")
+	errMergeSelf              = errors.New("профиль нельзя объединить с самим собой")
+	errMergeRepeated          = errors.New("дубликат указан в объединении несколько раз")
+)
+
+// parsePassengerQuery splits the query into words. Dates are taken as a birth date, other words containing digits
+// as a document number and everything else as prefixes of the passenger's names
+func parsePassengerQuery(q string) *store.PassengerQuery {
+	query := &store.PassengerQuery{}
+	for _, word := range strings.Fields(q) {
+		if d, err := time.Parse("2006-01-02", word); err == nil {
+			query.BirthDate = d
+			continue
+		}
+		if d, err := time.Parse("02.01.2006", word); err == nil {
+			query.BirthDate = d
+			continue
+		}
+		if strings.IndexFunc(word, unicode.IsDigit) != -1 {
+			query.DocumentNumber = strings.ToUpper(word)
+			continue
+		}
+		if key := translit.Key(word); key != "" {
+			query.NameKeys = append(query.NameKeys, key)
+		}
+	}
+	return query
+}
+
+func (s *server) handlePassengersSearch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := parsePassengerQuery(r.URL.Query().Get("q"))
+		if len(query.NameKeys) == 0 && query.DocumentNumber == "" && query.BirthDate.IsZero() {
+			s.error(w, r, http.StatusBadRequest, errEmptySearchQuery)
+			return
+		}
+
+		tickets, err := s.store.Ticket().Search(query, passengerSearchLimit)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		// The purchases and the segments of all the tickets found are read at once
+		purchaseIDs := make([]int, 0, len(tickets))
+		ticketIDs := make([]int, len(tickets))
+		for i, t := range tickets {
+			if i == 0 || tickets[i-1].PurchaseID != t.PurchaseID {
+				purchaseIDs = append(purchaseIDs, t.PurchaseID)
+			}
+			ticketIDs[i] = t.ID
+		}
+		purchases, err := s.store.Purchase().FindByIDs(purchaseIDs)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		purchaseByID := make(map[int]*store.PurchaseModel, len(purchases))
+		for i := range purchases {
+			purchaseByID[purchases[i].ID] = &purchases[i]
+		}
+		allSegments, err := s.store.FlightInTicket().FindTicketsSegments(ticketIDs)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		segmentsByTicket := make(map[int][]store.SegmentModel, len(tickets))
+		for _, v := range allSegments {
+			segmentsByTicket[v.TicketID] = append(segmentsByTicket[v.TicketID], v)
+		}
+
+		response := PassengerSearchResult{
+			Items: []PassengerSearchPurchase{},
+		}
+		for _, t := range tickets {
+			n := len(response.Items)
+			if n == 0 || response.Items[n-1].Purchase.ID != t.PurchaseID {
+				p, ok := purchaseByID[t.PurchaseID]
+				if !ok {
+					s.error(w, r, http.StatusInternalServerError, sql.ErrNoRows)
+					return
+				}
+				response.Items = append(response.Items, PassengerSearchPurchase{
+					Purchase: Purchase{
+						ID:               p.ID,
+						Date:             p.Date,
+						BookingOfficeID:  p.BookingOfficeID,
+						TotalPrice:       p.TotalPrice,
+						ContactPhone:     p.ContactPhone,
+						ContactEmail:     p.ContactEmail,
+						CashierID:        p.CashierID,
+						Currency:         p.Currency,
+						ExchangeRate:     p.ExchangeRate,
+						RateDate:         p.RateDate,
+						BaseTotalPrice:   p.BaseTotalPrice,
+						PromoCode:        promoCodeOf(p.PromoCode),
+						Discount:         p.Discount,
+						LoyaltyAccountID: p.LoyaltyAccountID,
+						RedeemedMiles:    p.RedeemedMiles,
+						MilesAmount:      p.MilesAmount,
+					},
+				})
+				n++
+			}
+
+			segments := segmentsByTicket[t.ID]
+			ticket := PassengerSearchTicket{
+				Ticket: Ticket{
+					ID:                       t.ID,
+					PassengerLastName:        t.PassengerLastName,
+					PassengerGivenName:       t.PassengerGivenName,
+					PassengerBirthDate:       t.PassengerBirthDate,
+					PassengerPassportNumber:  t.PassengerPassportNumber,
+					PassengerSex:             t.PassengerSex,
+					PurchaseID:               t.PurchaseID,
+					PassengerID:              t.PassengerID,
+					PassengerDocumentType:    t.PassengerDocumentType,
+					PassengerDocumentCountry: t.PassengerDocumentCountry,
+					PassengerDocumentExpiry:  t.PassengerDocumentExpiry,
+					AccompanyingTicketID:     t.AccompanyingTicketID,
+				},
+				Segments: make([]Segment, len(segments)),
+			}
+			for i, v := range segments {
+				ticket.Segments[i] = Segment{
+					ID:           v.ID,
+					FlightID:     v.FlightID,
+					DepDate:      v.DepDate,
+					LineCode:     v.LineCode,
+					DepAirport:   v.DepAirport,
+					ArrAirport:   v.ArrAirport,
+					SeatNumber:   v.SeatNumber,
+					SeatClass:    v.SeatClass,
+					BookingClass: bookingClassOf(v.BookingClass),
+					CheckInState: string(checkin.StateOf(v.CheckedInAt, v.BoardedAt)),
+					CheckedInAt:  v.CheckedInAt,
+					BoardedAt:    v.BoardedAt,
+				}
+			}
+			response.Items[n-1].Tickets = append(response.Items[n-1].Tickets, ticket)
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+func passengerFromModel(p *store.PassengerModel, documents []store.DocumentModel) Passenger {
+	passenger := Passenger{
+		ID:           p.ID,
+		LastName:     p.LastName,
+		GivenName:    p.GivenName,
+		BirthDate:    p.BirthDate,
+		Sex:          p.Sex,
+		Documents:    make([]Document, len(documents)),
+		ContactPhone: p.ContactPhone,
+		ContactEmail: p.ContactEmail,
+	}
+	for i, d := range documents {
+		passenger.Documents[i] = Document{
+			Type:           d.Type,
+			Number:         d.Number,
+			IssuingCountry: d.IssuingCountry,
+			ExpiryDate:     d.ExpiryDate,
+		}
+	}
+	return passenger
+}
+
+func (p *Passenger) model() *store.PassengerModel {
+	return &store.PassengerModel{
+		ID:           p.ID,
+		LastName:     p.LastName,
+		GivenName:    p.GivenName,
+		BirthDate:    p.BirthDate,
+		Sex:          p.Sex,
+		ContactPhone: p.ContactPhone,
+		ContactEmail: p.ContactEmail,
+	}
+}
+
+func (p *Passenger) documentModels() []store.DocumentModel {
+	documents := make([]store.DocumentModel, len(p.Documents))
+	for i, d := range p.Documents {
+		documents[i] = store.DocumentModel{
+			PassengerID:    p.ID,
+			Type:           d.Type,
+			Number:         d.Number,
+			IssuingCountry: d.IssuingCountry,
+			ExpiryDate:     d.ExpiryDate,
+		}
+	}
+	return documents
+}
+
+func (s *server) findPassenger(id int) (*Passenger, error) {
+	p, err := s.store.Passenger().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := s.store.Passenger().FindDocuments(id)
+	if err != nil {
+		return nil, err
+	}
+	passenger := passengerFromModel(p, documents)
+	return &passenger, nil
+}
+
+// fillTicketPassenger copies the passenger data of the ticket from the stored profile it refers to.
+// The document of the requested type is used, or the first one of the profile if the type is not given
+func (s *server) fillTicketPassenger(t *Ticket) error {
+	p, err := s.findPassenger(*t.PassengerID)
+	if err != nil {
+		return err
+	}
+	document := -1
+	for i, d := range p.Documents {
+		if t.PassengerDocumentType == "" || t.PassengerDocumentType == d.Type {
+			document = i
+			break
+		}
+	}
+	if document == -1 {
+		return errPassengerHasNoDocument
+	}
+	t.PassengerLastName = p.LastName
+	t.PassengerGivenName = p.GivenName
+	t.PassengerBirthDate = p.BirthDate
+	t.PassengerSex = p.Sex
+	t.setDocument(p.Documents[document])
+	return nil
+}
+
+// linkTicketPassenger refers the ticket to the profile of the passenger with the same document and birth date
+// creating the profile if there is none yet. It is called in the transaction creating the ticket, which keeps
+// the documents with the number locked, so concurrent sales to the passenger do not create two profiles
+func (s *server) linkTicketPassenger(st store.Store, t *Ticket) error {
+	document := t.document()
+	t.setDocument(document)
+	p, err := st.Passenger().LockByDocument(document.Type, document.Number, t.PassengerBirthDate)
+	if err == sql.ErrNoRows {
+		passenger := &Passenger{
+			LastName:  t.PassengerLastName,
+			GivenName: t.PassengerGivenName,
+			BirthDate: t.PassengerBirthDate,
+			Sex:       t.PassengerSex,
+			Documents: []Document{document},
+		}
+		p = passenger.model()
+		if err = st.Passenger().Create(p); err == nil {
+			err = st.Passenger().SetDocuments(p.ID, passenger.documentModels())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	t.PassengerID = &p.ID
+	return nil
+}
+
+// findDuplicates returns the pairs of passengers that are probably the same person
+func findDuplicates(passengers []Passenger) []PassengerDuplicate {
+	byDocument := map[string][]int{}
+	byBirthDate := map[string][]int{}
+	for i, p := range passengers {
+		for _, d := range p.Documents {
+			byDocument[d.Type+":"+d.Number] = append(byDocument[d.Type+":"+d.Number], i)
+		}
+		byBirthDate[p.BirthDate.Format("2006-01-02")] = append(byBirthDate[p.BirthDate.Format("2006-01-02")], i)
+	}
+
+	duplicates := []PassengerDuplicate{}
+	seen := map[[2]int]bool{}
+	compare := func(group []int, sameDocument bool) {
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				if a == b || seen[[2]int{a, b}] {
+					continue
+				}
+
+				pa, pb := &passengers[a], &passengers[b]
+				sameBirthDate := pa.BirthDate.Equal(pb.BirthDate)
+				similarity := translit.Similarity(pa.LastName+" "+pa.GivenName, pb.LastName+" "+pb.GivenName)
+				if !(sameDocument && (sameBirthDate || similarity >= duplicateDocumentNameSimilarity)) &&
+					!(sameBirthDate && similarity >= duplicateBirthDateNameSimilarity) {
+					continue
+				}
+				seen[[2]int{a, b}] = true
+
+				var reasons []string
+				if sameDocument {
+					reasons = append(reasons, "document")
+				}
+				if sameBirthDate {
+					reasons = append(reasons, "birth_date")
+				}
+				if similarity >= duplicateBirthDateNameSimilarity {
+					reasons = append(reasons, "name")
+				}
+				duplicates = append(duplicates, PassengerDuplicate{
+					Passengers: [2]Passenger{*pa, *pb},
+					Similarity: similarity,
+					Reasons:    reasons,
+				})
+			}
+		}
+	}
+	for _, group := range byDocument {
+		compare(group, true)
+	}
+	for _, group := range byBirthDate {
+		compare(group, false)
+	}
+	return duplicates
+}
+
+func (s *server) handlePassengersGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
+		var passengers *[]store.PassengerModel
+		var err error
+		if p.page > 0 {
+			passengers, err = s.store.Passenger().FindAll(p.rowCount, p.offset())
+		} else {
+			passengers, err = s.store.Passenger().FindPage(p.cursor, p.rowCount)
+		}
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		totalCount, err := p.totalCount(s.store.Passenger().TotalCount)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		response := PassengerList{
+			Items:      make([]Passenger, len(*passengers)),
+			TotalCount: totalCount,
+		}
+
+		for i, v := range *passengers {
+			documents, err := s.store.Passenger().FindDocuments(v.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			response.Items[i] = passengerFromModel(&v, documents)
+		}
+
+		if n := len(*passengers); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*passengers)[0].ID), strconv.Itoa((*passengers)[n-1].ID), n)
+		}
+		s.respond(w, r, 200, response)
+	}
+}
+
+func (s *server) handlePassengersCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := &Passenger{}
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := p.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			m := p.model()
+			if err := tx.Passenger().Create(m); err != nil {
+				return err
+			}
+			p.ID = m.ID
+			return tx.Passenger().SetDocuments(p.ID, p.documentModels())
+		}); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, p)
+	}
+}
+
+func (s *server) handlePassengerGetDeleteUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		passenger, err := s.findPassenger(id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			s.respond(w, r, http.StatusOK, passenger)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := s.store.Passenger().Delete(id); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			p := &Passenger{}
+			if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := p.Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+
+			p.ID = id
+			if err := s.store.Transaction(func(tx store.Store) error {
+				if err := tx.Passenger().Update(id, p.model()); err != nil && err != mysqlstore.ErrNoChanges {
+					return err
+				}
+				return tx.Passenger().SetDocuments(id, p.documentModels())
+			}); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, p)
+		}
+	}
+}
+
+func (s *server) handlePassengerDuplicatesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		candidates, err := s.store.Passenger().FindDuplicateCandidates()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		passengers := make([]Passenger, len(candidates))
+		for i, v := range candidates {
+			documents, err := s.store.Passenger().FindDocuments(v.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			passengers[i] = passengerFromModel(&v, documents)
+		}
+		s.respond(w, r, http.StatusOK, findDuplicates(passengers))
+	}
+}
+
+// checkIDs rejects a merge listing the kept profile or the same duplicate twice
+func (m *PassengerMerge) checkIDs(id int) error {
+	seen := make(map[int]bool, len(m.DuplicateIDs))
+	for _, duplicateID := range m.DuplicateIDs {
+		if duplicateID == id {
+			return errMergeSelf
+		}
+		if seen[duplicateID] {
+			return errMergeRepeated
+		}
+		seen[duplicateID] = true
+	}
+	return nil
+}
+
+func (s *server) handlePassengerMerge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		m := &PassengerMerge{}
+		if err := json.NewDecoder(r.Body).Decode(m); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := m.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := m.checkIDs(id); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.Passenger().Merge(id, m.DuplicateIDs); err != nil {
+			if err == sql.ErrNoRows || err == mysqlstore.ErrMergedItemDoesNotExist {
+				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		passenger, err := s.findPassenger(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, passenger)
+	}
+}
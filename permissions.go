@@ -0,0 +1,60 @@
+// Файл permissions.go содержит описание прав доступа ролей кассиров
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/gorilla/mux"
+)
+
+var errPermissionDenied = errors.New("недостаточно прав для выполнения операции")
+
+const (
+	roleCashier = 1
+	roleAdmin   = 2
+)
+
+type permission uint8
+
+const (
+	// permPassengerSearch allows to look up passengers and their personal data across all purchases
+	permPassengerSearch permission = iota
+	// permPassengerMerge allows to look for duplicate passenger profiles and merge them
+	permPassengerMerge
+	// permFlightOperations allows to change the operational status of flights, cancel them and move their passengers
+	permFlightOperations
+	// permPassengerDocuments allows to see full document numbers of passengers in manifests
+	permPassengerDocuments
+	// permReferenceImport allows to import airports, liner models, liners, seats and lines from files
+	permReferenceImport
+)
+
+var rolePermissions = map[int][]permission{
+	roleCashier: {},
+	roleAdmin:   {permPassengerSearch, permPassengerMerge, permFlightOperations, permPassengerDocuments, permReferenceImport},
+}
+
+func hasPermission(c *store.CashierModel, p permission) bool {
+	for _, v := range rolePermissions[c.RoleID] {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// requirePermission rejects requests of cashiers that do not have the permission
+func (s *server) requirePermission(p permission) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+			if !ok || !hasPermission(c, p) {
+				s.error(w, r, http.StatusForbidden, errPermissionDenied)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
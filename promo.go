@@ -0,0 +1,315 @@
+// Файл promo.go содержит промокоды акций, их применение при продаже и отчёт об их использовании
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/promo"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+var (
+	errPromoCodeUnknown   = errors.New("промокод не существует")
+	errPromoFlightUnknown = errors.New("рейс покупки не существует")
+)
+
+// promoErrors are the messages shown for the errors of applying a promo code
+var promoErrors = map[error]error{
+	promo.ErrNotStarted:     errors.New("акция промокода ещё не началась"),
+	promo.ErrExpired:        errors.New("акция промокода закончилась"),
+	promo.ErrNoFlights:      errors.New("промокод действует только на некоторых линиях или классах, укажите рейсы покупки"),
+	promo.ErrLine:           errors.New("промокод не действует на линии рейса"),
+	promo.ErrClass:          errors.New("промокод не действует в классе рейса"),
+	promo.ErrUsedUp:         errors.New("промокод больше нельзя использовать"),
+	promo.ErrCustomerUsedUp: errors.New("покупатель уже использовал промокод максимальное число раз"),
+}
+
+// promoErrorStatus returns the status code for an error of a purchase with a promo code
+func promoErrorStatus(err error) (int, error) {
+	if e, ok := promoErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	if err == errPromoCodeUnknown || err == errPromoFlightUnknown {
+		return http.StatusBadRequest, err
+	}
+	return currencyErrorStatus(err)
+}
+
+func promoCodeOf(code *string) string {
+	if code == nil {
+		return ""
+	}
+	return *code
+}
+
+func promoCampaignFromModel(c *store.PromoCampaignModel) PromoCampaign {
+	return PromoCampaign{
+		Code:               c.Code,
+		Name:               c.Name,
+		Kind:               c.Kind,
+		Percent:            c.Percent,
+		Amount:             c.Amount,
+		Currency:           c.Currency,
+		ValidFrom:          c.ValidFrom,
+		ValidTo:            c.ValidTo,
+		Lines:              append([]string{}, c.Lines...),
+		Classes:            append([]string{}, c.Classes...),
+		MaxUses:            c.MaxUses,
+		MaxUsesPerCustomer: c.MaxUsesPerCustomer,
+	}
+}
+
+// promoCampaignModel puts the amount of the campaign in its currency
+func promoCampaignModel(c *PromoCampaign) (*store.PromoCampaignModel, error) {
+	c.Currency = string(currencyOf(c.Currency))
+	if err := inCurrency(currencyOf(c.Currency), &c.Amount); err != nil {
+		return nil, err
+	}
+	return &store.PromoCampaignModel{
+		Code:               c.Code,
+		Name:               c.Name,
+		Kind:               c.Kind,
+		Percent:            c.Percent,
+		Amount:             c.Amount,
+		Currency:           c.Currency,
+		ValidFrom:          c.ValidFrom,
+		ValidTo:            c.ValidTo,
+		Lines:              c.Lines,
+		Classes:            c.Classes,
+		MaxUses:            c.MaxUses,
+		MaxUsesPerCustomer: c.MaxUsesPerCustomer,
+	}, nil
+}
+
+// applyPromo checks the promo code of the purchase and takes its discount off the total. The customer is
+// the contact email of the purchase. A fixed discount is converted to the currency of the purchase.
+// The campaign stays locked until the end of the transaction, so its limits hold for concurrent sales
+func (s *server) applyPromo(st store.Store, p *Purchase, m *store.PurchaseModel) (*store.PromoUsageModel, error) {
+	c, err := st.PromoCampaign().Find(p.PromoCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errPromoCodeUnknown
+		}
+		return nil, err
+	}
+	flights := make([]promo.Flight, len(p.Flights))
+	for i, f := range p.Flights {
+		flight, err := st.Flight().Find(f.FlightID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errPromoFlightUnknown
+			}
+			return nil, err
+		}
+		flights[i] = promo.Flight{LineCode: flight.LineCode, Class: f.Class}
+	}
+	customer := strings.ToLower(p.ContactEmail)
+	total, byCustomer, err := st.PromoCampaign().CountUses(c.Code, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	campaign := promo.Campaign{
+		Code:               c.Code,
+		Kind:               promo.Kind(c.Kind),
+		Percent:            c.Percent,
+		Amount:             c.Amount,
+		ValidFrom:          c.ValidFrom,
+		ValidTo:            c.ValidTo,
+		Lines:              c.Lines,
+		Classes:            c.Classes,
+		MaxUses:            c.MaxUses,
+		MaxUsesPerCustomer: c.MaxUsesPerCustomer,
+	}
+	if err := promo.Check(campaign, now, flights, promo.Usage{Total: total, Customer: byCustomer}); err != nil {
+		return nil, err
+	}
+	if campaign.Kind == promo.Fixed {
+		convert, err := s.converter(st, currencyOf(p.Currency), now)
+		if err != nil {
+			return nil, err
+		}
+		if campaign.Amount, err = convert(campaign.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	discount, err := promo.Discount(campaign, p.TotalPrice)
+	if err != nil {
+		return nil, err
+	}
+	if p.TotalPrice, err = p.TotalPrice.Sub(discount); err != nil {
+		return nil, err
+	}
+	p.Discount = discount
+	p.BaseTotalPrice = p.TotalPrice.Convert(money.Default, p.ExchangeRate, money.HalfUp)
+	m.TotalPrice, m.Discount, m.BaseTotalPrice, m.PromoCode = p.TotalPrice, p.Discount, p.BaseTotalPrice, &c.Code
+	return &store.PromoUsageModel{
+		Code:         c.Code,
+		Customer:     customer,
+		Discount:     discount,
+		Currency:     p.Currency,
+		BaseDiscount: discount.Convert(money.Default, p.ExchangeRate, money.HalfUp),
+		UsedAt:       now,
+	}, nil
+}
+
+func (s *server) handlePromoCampaignsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaigns, err := s.store.PromoCampaign().FindAll()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]PromoCampaign, len(campaigns))
+		for i := range campaigns {
+			response[i] = promoCampaignFromModel(&campaigns[i])
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+func (s *server) handlePromoCampaignsCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := &PromoCampaign{}
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := c.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		m, err := promoCampaignModel(c)
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		if err := s.store.PromoCampaign().Create(m); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, c)
+	}
+}
+
+func (s *server) handlePromoCampaignGetDeleteUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		c, err := s.store.PromoCampaign().Find(vars["code"])
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			response := promoCampaignFromModel(c)
+			s.respond(w, r, http.StatusOK, &response)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := s.store.PromoCampaign().Delete(vars["code"]); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			c := &PromoCampaign{}
+			if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := c.Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			m, err := promoCampaignModel(c)
+			if err != nil {
+				code, err := currencyErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
+
+			if err := s.store.PromoCampaign().Update(vars["code"], m); err != nil {
+				if err == mysqlstore.ErrNoChanges {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, c)
+		}
+	}
+}
+
+// handlePromoCampaignUsageGet reports the uses of the promo code with the discounts given, the revenue
+// of the purchases made with it and the number of customers who used it
+func (s *server) handlePromoCampaignUsageGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+		if _, err := s.store.PromoCampaign().Find(code); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		usages, err := s.store.PromoCampaign().FindUsages(code)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		report := &PromoUsageReport{
+			Code:         code,
+			Uses:         len(usages),
+			BaseDiscount: money.New(0, money.Default),
+			BaseRevenue:  money.New(0, money.Default),
+			Usages:       make([]PromoUsage, len(usages)),
+		}
+		customers := make(map[string]bool)
+		for i, u := range usages {
+			customers[u.Customer] = true
+			if report.BaseDiscount, err = report.BaseDiscount.Add(u.BaseDiscount); err == nil {
+				report.BaseRevenue, err = report.BaseRevenue.Add(u.BaseTotalPrice)
+			}
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			report.Usages[i] = PromoUsage{
+				PurchaseID:     u.PurchaseID,
+				Customer:       u.Customer,
+				Discount:       u.Discount,
+				Currency:       u.Currency,
+				BaseDiscount:   u.BaseDiscount,
+				BaseTotalPrice: u.BaseTotalPrice,
+				UsedAt:         u.UsedAt,
+			}
+		}
+		report.Customers = len(customers)
+		s.respond(w, r, http.StatusOK, report)
+	}
+}
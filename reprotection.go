@@ -0,0 +1,315 @@
+// Файл reprotection.go содержит перевозку пассажиров отменённого рейса на другие рейсы того же или стыковочного маршрута
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/reprotect"
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// Alternative flights are looked for within that many days after the departure of the cancelled one
+const reprotectionDays = 3
+
+var errFlightNotCancelled = errors.New("пересадить пассажиров можно только с отменённого рейса")
+
+// reprotectionLegs returns the flights on sale that may take the passengers of the cancelled flight
+// with the seats free on them. With lock the seats of the flights stay free until the end of the transaction
+func (s *server) reprotectionLegs(st store.Store, cancelled *store.FlightLegModel, now time.Time, lock bool) ([]reprotect.Leg, error) {
+	models, err := st.Flight().FindLegs(&store.FlightQuery{
+		FromDate: cancelled.DepDate.AddDate(0, 0, -1),
+		ToDate:   cancelled.DepDate.AddDate(0, 0, reprotectionDays),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seatsByModel := make(map[string][]store.SeatModel)
+	var legs []reprotect.Leg
+	for i := range models {
+		m := &models[i]
+		if m.ID == cancelled.ID || !flightstatus.OnSale(flightstatus.Status(m.Status)) {
+			continue
+		}
+		if m.DepAirport != cancelled.DepAirport && m.ArrAirport != cancelled.ArrAirport {
+			continue
+		}
+		dep, arr, err := schedule.Times(m.DepDate, m.DepTime, m.ArrTime, m.ArrDayOffset, m.DepTimezone, m.ArrTimezone)
+		if err != nil {
+			return nil, err
+		}
+		if dep.Before(now) {
+			continue
+		}
+
+		liner, err := st.Liner().Find(m.LinerCode)
+		if err != nil {
+			return nil, err
+		}
+		seats, ok := seatsByModel[liner.ModelCode]
+		if !ok {
+			if seats, err = st.Seat().FindByModel(liner.ModelCode); err != nil {
+				return nil, err
+			}
+			seatsByModel[liner.ModelCode] = seats
+		}
+		if lock {
+			if err := st.FlightInTicket().LockFlight(m.ID); err != nil {
+				return nil, err
+			}
+		}
+		segments, err := st.FlightInTicket().FindFlightSegments(m.ID)
+		if err != nil {
+			return nil, err
+		}
+		taken := make(map[int]bool, len(segments))
+		for _, v := range segments {
+			if v.SeatID != nil {
+				taken[*v.SeatID] = true
+			}
+		}
+
+		leg := reprotect.Leg{
+			FlightID:   m.ID,
+			LineCode:   m.LineCode,
+			DepAirport: m.DepAirport,
+			ArrAirport: m.ArrAirport,
+			Departure:  dep,
+			Arrival:    arr,
+		}
+		for _, seat := range seats {
+			if !taken[seat.ID] {
+				leg.Free = append(leg.Free, reprotect.Seat{ID: seat.ID, Number: seat.Number, Class: seat.Class})
+			}
+		}
+		legs = append(legs, leg)
+	}
+	return legs, nil
+}
+
+// reprotectionPassenger returns the passenger of the segment of the cancelled flight bounded by the flights
+// of the ticket before and after it
+func (s *server) reprotectionPassenger(st store.Store, segment *store.SegmentModel, now time.Time) (reprotect.Passenger, error) {
+	p := reprotect.Passenger{
+		SegmentID: segment.ID,
+		TicketID:  segment.TicketID,
+		Class:     segment.SeatClass,
+		Seated:    segment.SeatID != nil,
+		NotBefore: now,
+	}
+	t, err := st.Ticket().Find(segment.TicketID)
+	if err != nil {
+		return p, err
+	}
+	if segment.SeatID == nil && t.AccompanyingTicketID != nil {
+		p.EscortTicketID = *t.AccompanyingTicketID
+	} else {
+		// Passengers sold without a seat get one on the new flight
+		p.Seated = true
+	}
+
+	segments, err := st.FlightInTicket().FindSegments(segment.TicketID)
+	if err != nil {
+		return p, err
+	}
+	for i, v := range segments {
+		if v.ID != segment.ID {
+			continue
+		}
+		if i > 0 {
+			prev, err := st.Flight().FindLeg(segments[i-1].FlightID)
+			if err != nil {
+				return p, err
+			}
+			_, arr, err := schedule.Times(prev.DepDate, prev.DepTime, prev.ArrTime, prev.ArrDayOffset, prev.DepTimezone, prev.ArrTimezone)
+			if err != nil {
+				return p, err
+			}
+			if notBefore := arr.Add(reprotect.MinConnection); notBefore.After(p.NotBefore) {
+				p.NotBefore = notBefore
+			}
+		}
+		if i+1 < len(segments) {
+			next, err := st.Flight().FindLeg(segments[i+1].FlightID)
+			if err != nil {
+				return p, err
+			}
+			dep, _, err := schedule.Times(next.DepDate, next.DepTime, next.ArrTime, next.ArrDayOffset, next.DepTimezone, next.ArrTimezone)
+			if err != nil {
+				return p, err
+			}
+			p.NotAfter = dep.Add(-reprotect.MinConnection)
+		}
+		break
+	}
+	return p, nil
+}
+
+func (s *server) reprotectedPassenger(p reprotect.Passenger, legs []reprotect.Assignment) (ReprotectedPassenger, error) {
+	t, err := s.store.Ticket().Find(p.TicketID)
+	if err != nil {
+		return ReprotectedPassenger{}, err
+	}
+	r := ReprotectedPassenger{
+		SegmentID:          p.SegmentID,
+		TicketID:           p.TicketID,
+		PassengerLastName:  t.PassengerLastName,
+		PassengerGivenName: t.PassengerGivenName,
+		Class:              p.Class,
+	}
+	for _, a := range legs {
+		leg := ReprotectedLeg{
+			FlightID:   a.Leg.FlightID,
+			LineCode:   a.Leg.LineCode,
+			DepAirport: a.Leg.DepAirport,
+			ArrAirport: a.Leg.ArrAirport,
+			Departure:  a.Leg.Departure,
+			Arrival:    a.Leg.Arrival,
+		}
+		if a.Seat != nil {
+			leg.SeatNumber = a.Seat.Number
+		}
+		r.Flights = append(r.Flights, leg)
+	}
+	return r, nil
+}
+
+// planReprotection places the passengers of the cancelled flight on other flights. With lock the segments
+// of the cancelled flight and of the flights taking its passengers are locked first, so the plan can be applied
+// in the same transaction without a concurrent sale or reprotection taking its seats
+func (s *server) planReprotection(st store.Store, cancelled *store.FlightLegModel, now time.Time, lock bool) (reprotect.Result, error) {
+	if lock {
+		if err := st.FlightInTicket().LockFlight(cancelled.ID); err != nil {
+			return reprotect.Result{}, err
+		}
+	}
+	legs, err := s.reprotectionLegs(st, cancelled, now, lock)
+	if err != nil {
+		return reprotect.Result{}, err
+	}
+	segments, err := st.FlightInTicket().FindFlightSegments(cancelled.ID)
+	if err != nil {
+		return reprotect.Result{}, err
+	}
+	passengers := make([]reprotect.Passenger, len(segments))
+	for i := range segments {
+		if passengers[i], err = s.reprotectionPassenger(st, &segments[i], now); err != nil {
+			return reprotect.Result{}, err
+		}
+	}
+	return reprotect.Plan(cancelled.DepAirport, cancelled.ArrAirport, passengers, legs), nil
+}
+
+// applyReprotection moves the segments of the cancelled flight to the first flights of the new itineraries
+// and adds segments for the connecting flights. The moved segments keep the fares and the taxes they were
+// sold with, the connecting flights are not paid for
+func applyReprotection(st store.Store, placements []reprotect.Placement) error {
+	for _, p := range placements {
+		sold, err := st.FlightInTicket().Find(p.Passenger.SegmentID)
+		if err != nil {
+			return err
+		}
+		for i, a := range p.Legs {
+			segment := &store.FlightInTicketModel{
+				FlightID: a.Leg.FlightID,
+				TicketID: p.Passenger.TicketID,
+				Class:    p.Passenger.Class,
+			}
+			if a.Seat != nil {
+				seatID := a.Seat.ID
+				segment.SeatID = &seatID
+			}
+			if i == 0 {
+				if err := st.FlightInTicket().Update(p.Passenger.SegmentID, segment); err != nil {
+					return err
+				}
+				continue
+			}
+			currency := money.Default
+			if sold.Fare != nil {
+				currency = sold.Fare.Currency
+			}
+			segment.SetFare(money.Money{Currency: currency}, money.Money{Currency: currency})
+			if err := st.FlightInTicket().Create(segment); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleFlightReprotection proposes new flights for the passengers of the cancelled flight
+// and moves them there if the client asks to apply the proposal
+func (s *server) handleFlightReprotection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		req := &ReprotectionRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		cancelled, err := s.store.Flight().FindLeg(id)
+		if err != nil {
+			code, err := flightStatusErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		if cancelled.Status != string(flightstatus.Cancelled) {
+			s.error(w, r, http.StatusBadRequest, errFlightNotCancelled)
+			return
+		}
+
+		// The plan applied is made in the transaction that applies it, on the seats free at that moment
+		now := time.Now()
+		var plan reprotect.Result
+		if req.Apply {
+			err = s.store.Transaction(func(tx store.Store) error {
+				if plan, err = s.planReprotection(tx, cancelled, now, true); err != nil {
+					return err
+				}
+				return applyReprotection(tx, plan.Placements)
+			})
+		} else {
+			plan, err = s.planReprotection(s.store, cancelled, now, false)
+		}
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		response := &Reprotection{FlightID: id}
+		for _, p := range plan.Placements {
+			moved, err := s.reprotectedPassenger(p.Passenger, p.Legs)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			response.Moved = append(response.Moved, moved)
+		}
+		for _, p := range plan.Unplaced {
+			unplaced, err := s.reprotectedPassenger(p, nil)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			response.Unplaced = append(response.Unplaced, unplaced)
+		}
+
+		response.Applied = req.Apply
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,238 @@
+// Файл rotation.go содержит проверку ротации самолётов при планировании рейсов и обработчик просмотра ротации
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/rotation"
+	"github.com/akionka/aviasales/internal/schedule"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Rotation is shown for a week unless the client asks for other dates
+	defaultRotationDays = 7
+	// rotationWindowDays is how many days around the date of a flight the flights overlapping it can depart
+	rotationWindowDays = maxArrDayOffset + 1
+)
+
+var errUnknownFlightLine = errors.New("линия рейса не существует")
+
+// rotationError is returned when the aircraft cannot fly the flight, as opposed to failing to check the rotation
+type rotationError string
+
+func (e rotationError) Error() string {
+	return string(e)
+}
+
+// rotationConflictError explains why the aircraft cannot fly the flight
+func rotationConflictError(linerCode string, c *rotation.ConflictError) rotationError {
+	const layout = "02.01.2006 15:04 UTC"
+	if c.Kind == rotation.Overlap {
+		return rotationError(fmt.Sprintf("самолёт %s занят: рейс %s вылетает %s, а рейс %s прибывает только %s",
+			linerCode, describeLeg(c.Next), c.Next.Departure.UTC().Format(layout), describeLeg(c.Previous), c.Previous.Arrival.UTC().Format(layout)))
+	}
+	return rotationError(fmt.Sprintf("самолёт %s не может выполнить рейс %s из %s: предыдущим рейсом %s он прибывает в %s",
+		linerCode, describeLeg(c.Next), c.Next.DepAirport, describeLeg(c.Previous), c.Previous.ArrAirport))
+}
+
+func describeLeg(l rotation.Leg) string {
+	if l.FlightID == 0 {
+		return l.LineCode + " (новый)"
+	}
+	return fmt.Sprintf("%s (№ %d)", l.LineCode, l.FlightID)
+}
+
+func rotationLeg(f *store.FlightLegModel) (rotation.Leg, error) {
+	dep, arr, err := schedule.Times(f.DepDate, f.DepTime, f.ArrTime, f.ArrDayOffset, f.DepTimezone, f.ArrTimezone)
+	if err != nil {
+		return rotation.Leg{}, err
+	}
+	return rotation.Leg{
+		FlightID:   f.ID,
+		LineCode:   f.LineCode,
+		DepAirport: f.DepAirport,
+		ArrAirport: f.ArrAirport,
+		Departure:  dep,
+		Arrival:    arr,
+	}, nil
+}
+
+// flightLeg joins the flight that is about to be stored with its line and airports
+func (s *server) flightLeg(id int, f *Flight) (*store.FlightLegModel, error) {
+	l, err := s.store.Line().Find(f.LineCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errUnknownFlightLine
+		}
+		return nil, err
+	}
+	dep, err := s.store.Airport().Find(l.DepAirport)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := s.store.Airport().Find(l.ArrAirport)
+	if err != nil {
+		return nil, err
+	}
+	return &store.FlightLegModel{
+		FlightModel: store.FlightModel{
+			ID:        id,
+			DepDate:   f.DepDate,
+			LineCode:  f.LineCode,
+			IsHot:     f.IsHot,
+			LinerCode: f.LinerCode,
+		},
+		DepTime:      l.DepTime,
+		ArrTime:      l.ArrTime,
+		ArrDayOffset: l.ArrDayOffset,
+		BasePrice:    l.BasePrice,
+		Currency:     l.Currency,
+		DepAirport:   l.DepAirport,
+		ArrAirport:   l.ArrAirport,
+		DepTimezone:  dep.Timezone,
+		ArrTimezone:  arr.Timezone,
+	}, nil
+}
+
+// checkRotation checks that the liner of the flight can fly it after its previous flight and before the next one.
+// id is zero for a new flight
+func (s *server) checkRotation(id int, f *Flight) error {
+	model, err := s.flightLeg(id, f)
+	if err != nil {
+		return err
+	}
+	leg, err := rotationLeg(model)
+	if err != nil {
+		return err
+	}
+
+	// Only the flights that can overlap the new one are read, a flight lasts up to maxArrDayOffset days and
+	// the timezones shift it by less than a day. The flights next to the window are read for the positioning
+	from, to := f.DepDate.AddDate(0, 0, -rotationWindowDays), f.DepDate.AddDate(0, 0, rotationWindowDays)
+	others, err := s.store.Flight().FindLegs(&store.FlightQuery{LinerCode: f.LinerCode, FromDate: from, ToDate: to})
+	if err != nil {
+		return err
+	}
+	prev, next, err := s.store.Flight().FindAdjacentLegs(f.LinerCode, from, to, id)
+	if err != nil {
+		return err
+	}
+	for _, l := range []*store.FlightLegModel{prev, next} {
+		if l != nil {
+			others = append(others, *l)
+		}
+	}
+	legs := make([]rotation.Leg, 0, len(others))
+	for i := range others {
+		if others[i].Status == string(flightstatus.Cancelled) {
+			continue
+		}
+		l, err := rotationLeg(&others[i])
+		if err != nil {
+			return err
+		}
+		legs = append(legs, l)
+	}
+
+	if err := rotation.Check(legs, leg); err != nil {
+		if c, ok := err.(*rotation.ConflictError); ok {
+			return rotationConflictError(f.LinerCode, c)
+		}
+		return err
+	}
+	return nil
+}
+
+// rotationErrorStatus returns the status code for an error of checkRotation
+func rotationErrorStatus(err error) (int, error) {
+	if err == errUnknownFlightLine {
+		return http.StatusBadRequest, err
+	}
+	switch err.(type) {
+	case rotationError:
+		return http.StatusBadRequest, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+func (s *server) handleLinerRotationGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+		if _, err := s.store.Liner().Find(code); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		y, m, d := time.Now().Date()
+		query := &store.FlightQuery{LinerCode: code, FromDate: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+		if from := r.URL.Query().Get("from"); from != "" {
+			t, err := time.Parse("2006-01-02", from)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, errBadDate)
+				return
+			}
+			query.FromDate = t
+		}
+		query.ToDate = query.FromDate.AddDate(0, 0, defaultRotationDays)
+		if to := r.URL.Query().Get("to"); to != "" {
+			t, err := time.Parse("2006-01-02", to)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, errBadDate)
+				return
+			}
+			query.ToDate = t
+		}
+
+		models, err := s.store.Flight().FindLegs(query)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		// Cancelled flights do not take the aircraft
+		active := models[:0]
+		for _, m := range models {
+			if m.Status != string(flightstatus.Cancelled) {
+				active = append(active, m)
+			}
+		}
+		models = active
+		legs := make([]rotation.Leg, len(models))
+		flights := make(map[int]*FlightLeg, len(models))
+		for i := range models {
+			if legs[i], err = rotationLeg(&models[i]); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			if flights[models[i].ID], err = flightLegFromModel(&models[i]); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		turns := rotation.Timeline(legs)
+		response := make([]RotationTurn, len(turns))
+		for i, t := range turns {
+			response[i] = RotationTurn{FlightLeg: *flights[t.FlightID]}
+			if t.HasNext {
+				groundTime := int(t.GroundTime.Seconds())
+				response[i].GroundTime = &groundTime
+			}
+			if t.Conflict != nil {
+				response[i].Conflict = string(t.Conflict.Kind)
+				response[i].ConflictMessage = rotationConflictError(code, t.Conflict).Error()
+			}
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
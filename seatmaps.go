@@ -0,0 +1,241 @@
+// Файл seatmaps.go содержит обработчики схем салонов моделей самолётов: просмотр и изменение схемы, генерацию мест и сетку мест
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/akionka/aviasales/internal/seatmap"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/gorilla/mux"
+)
+
+var errLayoutNotDefined = errors.New("для модели самолёта не задана схема салона")
+
+// seatSoldError is returned when regenerating the seats would remove a seat taken by a ticket
+type seatSoldError string
+
+func (e seatSoldError) Error() string {
+	return string(e)
+}
+
+func (s *server) findLayout(code string) (*seatmap.Layout, error) {
+	m, err := s.store.LinerModel().Find(code)
+	if err != nil {
+		return nil, err
+	}
+	if m.Layout == nil {
+		return nil, errLayoutNotDefined
+	}
+	layout := &seatmap.Layout{}
+	if err := json.Unmarshal([]byte(*m.Layout), layout); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+func (s *server) handleLinerModelLayoutGetUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+
+		if r.Method == http.MethodGet {
+			layout, err := s.findLayout(code)
+			if err != nil {
+				if err == sql.ErrNoRows || err == errLayoutNotDefined {
+					s.error(w, r, http.StatusNotFound, err)
+					return
+				}
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, layout)
+			return
+		}
+
+		if _, err := s.store.LinerModel().Find(code); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		layout := &seatmap.Layout{}
+		if err := json.NewDecoder(r.Body).Decode(layout); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := layout.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		b, err := json.Marshal(layout)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		value := string(b)
+		// Setting the layout the model already has changes no rows, the layout is still the one asked for
+		if err := s.store.LinerModel().SetLayout(code, &value); err != nil && err != mysqlstore.ErrNoChanges {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, layout)
+	}
+}
+
+// generateSeats makes the seats of the model match its layout. Seats that stay keep their ids,
+// seats removed from the layout or blocked are deleted unless they are sold
+func (s *server) generateSeats(code string, layout *seatmap.Layout) (*SeatGeneration, error) {
+	result := &SeatGeneration{}
+	err := s.store.Transaction(func(tx store.Store) error {
+		existing, err := tx.Seat().FindByModel(code)
+		if err != nil {
+			return err
+		}
+		sold, err := tx.Seat().FindSold(code)
+		if err != nil {
+			return err
+		}
+		isSold := make(map[int]bool, len(sold))
+		for _, id := range sold {
+			isSold[id] = true
+		}
+		byNumber := make(map[string]store.SeatModel, len(existing))
+		for _, v := range existing {
+			byNumber[v.Number] = v
+		}
+
+		kept := make(map[string]bool)
+		for _, seat := range layout.Seats() {
+			if seat.Blocked {
+				continue
+			}
+			kept[seat.Number] = true
+			if v, ok := byNumber[seat.Number]; ok {
+				if v.Class == seat.Class {
+					continue
+				}
+				if isSold[v.ID] {
+					return seatSoldError(fmt.Sprintf("место %s продано, его класс нельзя изменить", v.Number))
+				}
+				v.Class = seat.Class
+				if err := tx.Seat().Update(v.ID, &v); err != nil {
+					return err
+				}
+				result.Updated++
+				continue
+			}
+			if err := tx.Seat().Create(&store.SeatModel{
+				Number:         seat.Number,
+				Class:          seat.Class,
+				LinerModelCode: code,
+			}); err != nil {
+				return err
+			}
+			result.Created++
+		}
+
+		for _, v := range existing {
+			if kept[v.Number] {
+				continue
+			}
+			if isSold[v.ID] {
+				return seatSoldError(fmt.Sprintf("место %s продано, его нельзя убрать из схемы", v.Number))
+			}
+			if err := tx.Seat().Delete(v.ID); err != nil {
+				return err
+			}
+			result.Deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *server) handleLinerModelSeatsGenerate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+		layout, err := s.findLayout(code)
+		if err != nil {
+			if err == sql.ErrNoRows || err == errLayoutNotDefined {
+				s.error(w, r, http.StatusNotFound, err)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		result, err := s.generateSeats(code, layout)
+		if err != nil {
+			if _, ok := err.(seatSoldError); ok {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, r, http.StatusOK, result)
+	}
+}
+
+// handleLinerModelSeatMapGet returns the grid of the cabin with the ids of the seats that can be sold
+func (s *server) handleLinerModelSeatMapGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := mux.Vars(r)["code"]
+		layout, err := s.findLayout(code)
+		if err != nil {
+			if err == sql.ErrNoRows || err == errLayoutNotDefined {
+				s.error(w, r, http.StatusNotFound, err)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		seats, err := s.store.Seat().FindByModel(code)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		ids := make(map[string]int, len(seats))
+		for _, v := range seats {
+			ids[v.Number] = v.ID
+		}
+
+		grid := layout.Grid()
+		response := SeatMap{
+			ModelCode: code,
+			Rows:      make([]SeatMapRow, len(grid)),
+		}
+		for i, row := range grid {
+			response.Rows[i] = SeatMapRow{
+				Number: row.Number,
+				Class:  row.Class,
+				Exit:   row.Exit,
+				Cells:  make([]SeatMapCell, len(row.Cells)),
+			}
+			for j, cell := range row.Cells {
+				c := SeatMapCell{Type: cell.Kind}
+				if seat := cell.Seat; seat != nil {
+					c.Number = seat.Number
+					c.Class = seat.Class
+					c.Position = seat.Position
+					c.Blocked = seat.Blocked
+					c.Characteristics = seat.Characteristics
+					if id, ok := ids[seat.Number]; ok {
+						c.SeatID = &id
+					}
+				}
+				response.Rows[i].Cells[j] = c
+			}
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,138 @@
+// Файл segments.go содержит правила продажи полётов в билете (сегментов)
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/pricing"
+	"github.com/akionka/aviasales/internal/store"
+)
+
+const (
+	maxInfantsPerAdult = 1
+	escortMinAge       = 18
+)
+
+var (
+	errInfantWithoutEscort = segmentRuleError("младенцу без места нужен сопровождающий взрослый")
+	errEscortNotOnFlight   = segmentRuleError("сопровождающий не летит этим рейсом")
+	errEscortTooYoung      = segmentRuleError("сопровождающему должно быть не менее 18 лет")
+	errTooManyInfants      = segmentRuleError("с сопровождающим уже летит младенец без места")
+	errFlightNotOnSale     = segmentRuleError("рейс отменён или уже вылетел, билеты на него не продаются")
+	errSeatHeld            = segmentRuleError("место удерживается для пассажира из листа ожидания")
+)
+
+// segmentRuleError is returned when a segment breaks a sale rule, as opposed to failing to check the rule
+type segmentRuleError string
+
+func (e segmentRuleError) Error() string {
+	return string(e)
+}
+
+// checkSegment checks that the ticket can be sold for the flight and sets the class of the segment.
+// Passengers other than infants may be sold without a seat in the class given, up to its authorized capacity.
+// The flight is locked first, so the check is made in the transaction saving the segment and other sales
+// of the flight wait for it
+func (s *server) checkSegment(st store.Store, f *store.FlightInTicketModel) error {
+	if err := st.FlightInTicket().LockFlight(f.FlightID); err != nil {
+		return err
+	}
+	t, err := st.Ticket().Find(f.TicketID)
+	if err != nil {
+		return err
+	}
+	flight, err := st.Flight().Find(f.FlightID)
+	if err != nil {
+		return err
+	}
+	if !flightstatus.OnSale(flightstatus.Status(flight.Status)) {
+		return errFlightNotOnSale
+	}
+	if err := checkTravelDocument(t, flight.DepDate); err != nil {
+		return err
+	}
+
+	if f.SeatID != nil {
+		seat, err := st.Seat().Find(*f.SeatID)
+		if err != nil {
+			return err
+		}
+		f.Class = seat.Class
+		offers, err := s.activeOffers(st, flight.ID)
+		if err != nil {
+			return err
+		}
+		for _, v := range offers {
+			if v.SeatID != nil && *v.SeatID == seat.ID && v.TicketID != f.TicketID {
+				return errSeatHeld
+			}
+		}
+		return s.checkCapacity(st, f, flight)
+	}
+
+	if pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, flight.DepDate)) != pricing.Infant {
+		// The seat is assigned at check-in, the sale counts against the capacity of the class
+		return s.checkCapacity(st, f, flight)
+	}
+	if t.AccompanyingTicketID == nil {
+		return errInfantWithoutEscort
+	}
+	escort, err := st.Ticket().Find(*t.AccompanyingTicketID)
+	if err != nil {
+		return err
+	}
+	if ageAt(escort.PassengerBirthDate, flight.DepDate) < escortMinAge {
+		return errEscortTooYoung
+	}
+	escortSegment, err := st.FlightInTicket().FindOnFlight(escort.ID, flight.ID)
+	if err == sql.ErrNoRows {
+		return errEscortNotOnFlight
+	}
+	if err != nil {
+		return err
+	}
+	infants, err := st.FlightInTicket().CountInfants(flight.ID, escort.ID, f.ID)
+	if err != nil {
+		return err
+	}
+	if infants >= maxInfantsPerAdult {
+		return errTooManyInfants
+	}
+	// The infant flies at the fare of the booking class of the escort
+	f.Class = escortSegment.Class
+	f.BookingClass = escortSegment.BookingClass
+	return nil
+}
+
+// isUnaccompaniedMinor tells if the passenger of the ticket is a child or an infant flying
+// without an adult from the same purchase
+func (s *server) isUnaccompaniedMinor(t *store.TicketModel, flightID int, depDate time.Time) (bool, error) {
+	if pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, depDate)) == pricing.Adult {
+		return false, nil
+	}
+	companions, err := s.store.FlightInTicket().FindCompanions(flightID, t.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range companions {
+		if ageAt(c.PassengerBirthDate, depDate) >= escortMinAge {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// segmentErrorStatus returns the HTTP status code for the error of checkSegment
+func segmentErrorStatus(err error) (int, error) {
+	switch err.(type) {
+	case segmentRuleError:
+		return http.StatusBadRequest, err
+	}
+	if err == sql.ErrNoRows {
+		return http.StatusBadRequest, errRequestedItemDoesNotExist
+	}
+	return http.StatusInternalServerError, err
+}
@@ -6,11 +6,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/akionka/aviasales/internal/checkin"
+	"github.com/akionka/aviasales/internal/exchange"
+	"github.com/akionka/aviasales/internal/money"
 	"github.com/akionka/aviasales/internal/store"
 	"github.com/akionka/aviasales/internal/store/mysqlstore"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -32,11 +35,6 @@ const (
 	ctxKeyPagination
 )
 
-type paginationInfo struct {
-	rowCount int
-	page     int
-}
-
 type ctxKey uint8
 
 type server struct {
@@ -53,6 +51,7 @@ func newServer(store store.Store) *server {
 }
 
 func (s *server) start() error {
+	go s.sweepWaitlists(waitlistSweepInterval)
 	return http.ListenAndServe(":8080", s.router)
 }
 
@@ -67,14 +66,7 @@ func (s *server) configureRouter() {
 	s.router.HandleFunc("/user", s.handleCashiersCreate()).Methods(http.MethodPost, http.MethodOptions)
 	s.router.HandleFunc("/session", s.handleSessionsCreate()).Methods(http.MethodPost, http.MethodOptions)
 
-	s.router.HandleFunc("/timezones", func(w http.ResponseWriter, r *http.Request) {
-		timezones, err := s.store.Timezone().FindAll()
-		if err != nil {
-			s.error(w, r, http.StatusInternalServerError, err)
-			return
-		}
-		s.respond(w, r, http.StatusOK, timezones)
-	}).Methods(http.MethodGet, http.MethodOptions)
+	s.router.HandleFunc("/timezones", s.handleTimezonesGet()).Methods(http.MethodGet, http.MethodOptions)
 
 	secured := s.router.NewRoute().Subrouter()
 	secured.Use(s.authenticateUser)
@@ -100,18 +92,35 @@ func (s *server) configureRouter() {
 	securedGet.Use(s.paginateMiddleware)
 
 	securedGet.HandleFunc("/airports", s.handleAirportsGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/airports/{code}/taxes", s.handleAirportTaxesGetUpdate()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/booking_classes", s.handleBookingClassesGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/booking_classes/{code}/rules", s.handleFareRuleGetUpdate()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/booking_offices", s.handleBookingOfficesGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/cashiers", s.handleCashiersGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/exchange_rates", s.handleExchangeRatesGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/flight_in_tickets", s.handleFlightInTicketsGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/flights", s.handleFlightsGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/search", s.handleFlightsSearch()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/{id:[0-9]+}/manifest", s.handleFlightManifestGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/segments/{id:[0-9]+}/boarding_pass", s.handleSegmentBoardingPassGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/{id:[0-9]+}/capacity", s.handleFlightCapacityGetUpdate()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/{id:[0-9]+}/inventory", s.handleFlightInventoryGetUpdate()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/oversold", s.handleOversoldFlightsGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/{id:[0-9]+}/waitlist", s.handleFlightWaitlistGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/flights/{id:[0-9]+}/status_history", s.handleFlightStatusChangesGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/lines", s.handleLinesGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/liner_models", s.handleLinerModelsGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/liner_models/{code}/seatmap", s.handleLinerModelSeatMapGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/liners", s.handleLinersGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/liners/{code}/rotation", s.handleLinerRotationGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/loyalty_accounts/{id:[0-9]+}/ledger", s.handleLoyaltyLedgerGet()).Methods(http.MethodGet, http.MethodOptions)
+	securedGet.HandleFunc("/promo_campaigns", s.handlePromoCampaignsGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/purchases", s.handlePurchasesGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/seats", s.handleSeatsGet()).Methods(http.MethodGet, http.MethodOptions)
 	securedGet.HandleFunc("/tickets", s.handleTicketsGet()).Methods(http.MethodGet, http.MethodOptions)
 
 	secured.HandleFunc("/airports", s.handleAirportsCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/booking_classes", s.handleBookingClassesCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/booking_offices", s.handleBookingOfficesCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/cashiers", s.handleCashiersCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/flight_in_tickets", s.handleFlightInTicketsCreate()).Methods(http.MethodPost, http.MethodOptions)
@@ -119,9 +128,45 @@ func (s *server) configureRouter() {
 	secured.HandleFunc("/lines", s.handleLinesCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/liner_models", s.handleLinerModelsCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/liners", s.handleLinersCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/loyalty_accounts", s.handleLoyaltyAccountsCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/passengers", s.handlePassengersCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/promo_campaigns", s.handlePromoCampaignsCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/purchases", s.handlePurchasesCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/seats", s.handleSeatsCreate()).Methods(http.MethodPost, http.MethodOptions)
 	secured.HandleFunc("/tickets", s.handleTicketsCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/segments/{id:[0-9]+}/checkin", s.handleSegmentCheckIn()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/segments/{id:[0-9]+}/refund", s.handleSegmentRefund()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/segments/{id:[0-9]+}/exchange", s.handleSegmentExchange()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/flights/{id:[0-9]+}/boarding", s.handleFlightBoardingScan()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/flights/{id:[0-9]+}/waitlist", s.handleFlightWaitlistCreate()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/waitlist/{id:[0-9]+}/accept", s.handleWaitlistEntryAccept()).Methods(http.MethodPost, http.MethodOptions)
+	secured.HandleFunc("/waitlist/{id:[0-9]+}/decline", s.handleWaitlistEntryDecline()).Methods(http.MethodPost, http.MethodOptions)
+
+	passengerSearch := secured.NewRoute().Subrouter()
+	passengerSearch.Use(s.requirePermission(permPassengerSearch))
+	passengerSearch.HandleFunc("/passengers/search", s.handlePassengersSearch()).Methods(http.MethodGet, http.MethodOptions)
+	passengerSearch.HandleFunc("/passengers/{id:[0-9]+}", s.handlePassengerGetDeleteUpdate()).Methods(http.MethodGet, http.MethodOptions)
+
+	passengerSearchGet := passengerSearch.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	passengerSearchGet.Use(s.paginateMiddleware)
+	passengerSearchGet.HandleFunc("/passengers", s.handlePassengersGet()).Methods(http.MethodGet, http.MethodOptions)
+
+	passengerMerge := secured.NewRoute().Subrouter()
+	passengerMerge.Use(s.requirePermission(permPassengerMerge))
+	passengerMerge.HandleFunc("/passengers/duplicates", s.handlePassengerDuplicatesGet()).Methods(http.MethodGet, http.MethodOptions)
+	passengerMerge.HandleFunc("/passengers/{id:[0-9]+}/merge", s.handlePassengerMerge()).Methods(http.MethodPost, http.MethodOptions)
+
+	flightOperations := secured.NewRoute().Subrouter()
+	flightOperations.Use(s.requirePermission(permFlightOperations))
+	flightOperations.HandleFunc("/flights/{id:[0-9]+}/status", s.handleFlightStatusUpdate()).Methods(http.MethodPost, http.MethodOptions)
+	flightOperations.HandleFunc("/flights/{id:[0-9]+}/cancel", s.handleFlightCancel()).Methods(http.MethodPost, http.MethodOptions)
+	flightOperations.HandleFunc("/flights/{id:[0-9]+}/capacity", s.handleFlightCapacityGetUpdate()).Methods(http.MethodPut, http.MethodOptions)
+	flightOperations.HandleFunc("/flights/{id:[0-9]+}/inventory", s.handleFlightInventoryGetUpdate()).Methods(http.MethodPut, http.MethodOptions)
+	flightOperations.HandleFunc("/flights/{id:[0-9]+}/reprotection", s.handleFlightReprotection()).Methods(http.MethodPost, http.MethodOptions)
+
+	referenceImport := secured.NewRoute().Subrouter()
+	referenceImport.Use(s.requirePermission(permReferenceImport))
+	referenceImport.HandleFunc("/import/{entity}", s.handleImport()).Methods(http.MethodPost, http.MethodOptions)
 
 	adminOnlyUpdateDelete := secured.NewRoute().Subrouter()
 	adminOnlyUpdateDelete.Use(func(h http.Handler) http.Handler {
@@ -139,19 +184,32 @@ func (s *server) configureRouter() {
 		})
 	})
 
+	adminOnlyUpdateDelete.HandleFunc("/airports/{code}/taxes", s.handleAirportTaxesGetUpdate()).Methods(http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/airports/{code}", s.handleAirportGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/booking_classes/{code}", s.handleBookingClassGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/booking_classes/{code}/rules", s.handleFareRuleGetUpdate()).Methods(http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/booking_offices/{id:[0-9]+}", s.handleBookingOfficeGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/cashiers/{id:[0-9]+}", s.handleCashierGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/cashiers/{id:[0-9]+}/password", s.handleCashierPasswordUpdate()).Methods(http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/exchange_rates", s.handleExchangeRatesUpdate()).Methods(http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/exchange_rates/{currency}/{date}", s.handleExchangeRateDelete()).Methods(http.MethodDelete, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/flight_in_tickets/{id:[0-9]+}", s.handleFlightInTicketGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/flights/{id:[0-9]+}", s.handleFlightGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/flights/{id:[0-9]+}/aircraft", s.handleFlightAircraftUpdate()).Methods(http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/lines/{code}", s.handleLineGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/liner_models/{code}", s.handleLinerModelGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/liner_models/{code}/layout", s.handleLinerModelLayoutGetUpdate()).Methods(http.MethodGet, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/liner_models/{code}/seats", s.handleLinerModelSeatsGenerate()).Methods(http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/liners/{code}", s.handleLinerGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/loyalty_accounts/{id:[0-9]+}", s.handleLoyaltyAccountGetDelete()).Methods(http.MethodGet, http.MethodDelete, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/passengers/{id:[0-9]+}", s.handlePassengerGetDeleteUpdate()).Methods(http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/promo_campaigns/{code}", s.handlePromoCampaignGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/promo_campaigns/{code}/usage", s.handlePromoCampaignUsageGet()).Methods(http.MethodGet, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/purchases/{id:[0-9]+}", s.handlePurchaseGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/seats/{id:[0-9]+}", s.handleSeatGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/tickets/{id:[0-9]+}", s.handleTicketGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 	adminOnlyUpdateDelete.HandleFunc("/tickets/{id:[0-9]+}/report", s.handleTicketReportGet()).Methods(http.MethodGet, http.MethodOptions)
+	adminOnlyUpdateDelete.HandleFunc("/waitlist/{id:[0-9]+}", s.handleWaitlistEntryGetDeleteUpdate()).Methods(http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodOptions)
 }
 
 func (s *server) authenticateUser(next http.Handler) http.Handler {
@@ -201,21 +259,33 @@ func (s *server) authenticateUser(next http.Handler) http.Handler {
 
 func (s *server) paginateMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		page, err := strconv.Atoi(r.URL.Query().Get("page"))
-		if err != nil {
-			page = 0
+		query := r.URL.Query()
+		p := paginationInfo{}
+
+		if token := query.Get("cursor"); token != "" {
+			cursor, err := decodeCursor(token)
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			p.cursor = cursor
+		} else if page, err := strconv.Atoi(query.Get("page")); err == nil && page > 0 {
+			p.page = page
 		}
-		count, err := strconv.Atoi(r.URL.Query().Get("count"))
-		if err != nil {
-			count = 10
+
+		count, err := strconv.Atoi(query.Get("count"))
+		if err != nil || count == 0 || count < -1 {
+			count = defaultPageSize
 		}
-		if count == -1 {
-			count = math.MaxInt64
+		if count == -1 || count > maxPageSize {
+			count = maxPageSize
 		}
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyPagination, paginationInfo{
-			page:     page,
-			rowCount: count,
-		})))
+		p.rowCount = count
+
+		// Counting all the rows is expensive on large tables, so the total is only returned when asked for
+		p.withTotal, _ = strconv.ParseBool(query.Get("total"))
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyPagination, p)))
 	})
 }
 
@@ -270,13 +340,19 @@ func (s *server) handleSessionsCreate() http.HandlerFunc {
 func (s *server) handleAirportsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		airports, err := s.store.Airport().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var airports *[]store.AirportModel
+		var err error
+		if p.page > 0 {
+			airports, err = s.store.Airport().FindAll(p.rowCount, p.offset())
+		} else {
+			airports, err = s.store.Airport().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Airport().TotalCount()
+		totalCount, err := p.totalCount(s.store.Airport().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -289,12 +365,20 @@ func (s *server) handleAirportsGet() http.HandlerFunc {
 
 		for i, v := range *airports {
 			response.Items[i] = Airport{
-				IATACode: v.IATACode,
-				City:     v.City,
-				Timezone: v.Timezone,
+				IATACode:  v.IATACode,
+				City:      v.City,
+				Timezone:  v.Timezone,
+				Latitude:  v.Latitude,
+				Longitude: v.Longitude,
+				ICAOCode:  v.ICAOCode,
+				Name:      v.Name,
+				Country:   v.Country,
 			}
 		}
 
+		if n := len(*airports); n > 0 {
+			response.Prev, response.Next = p.cursors((*airports)[0].IATACode, (*airports)[n-1].IATACode, n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -323,9 +407,14 @@ func (s *server) handleAirportGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 			s.respond(w, r, http.StatusOK, &Airport{
-				IATACode: a.IATACode,
-				City:     a.City,
-				Timezone: a.Timezone,
+				IATACode:  a.IATACode,
+				City:      a.City,
+				Timezone:  a.Timezone,
+				Latitude:  a.Latitude,
+				Longitude: a.Longitude,
+				ICAOCode:  a.ICAOCode,
+				Name:      a.Name,
+				Country:   a.Country,
 			})
 			return
 		}
@@ -352,9 +441,14 @@ func (s *server) handleAirportGetDeleteUpdate() http.HandlerFunc {
 			}
 
 			if err := s.store.Airport().Update(vars["code"], &store.AirportModel{
-				IATACode: a.IATACode,
-				City:     a.City,
-				Timezone: a.Timezone,
+				IATACode:  a.IATACode,
+				City:      a.City,
+				Timezone:  a.Timezone,
+				Latitude:  a.Latitude,
+				Longitude: a.Longitude,
+				ICAOCode:  a.ICAOCode,
+				Name:      a.Name,
+				Country:   a.Country,
 			}); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
@@ -371,13 +465,19 @@ func (s *server) handleAirportGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleBookingOfficesGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		offices, err := s.store.BookingOffice().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var offices *[]store.BookingOfficeModel
+		var err error
+		if p.page > 0 {
+			offices, err = s.store.BookingOffice().FindAll(p.rowCount, p.offset())
+		} else {
+			offices, err = s.store.BookingOffice().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.BookingOffice().TotalCount()
+		totalCount, err := p.totalCount(s.store.BookingOffice().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -393,9 +493,14 @@ func (s *server) handleBookingOfficesGet() http.HandlerFunc {
 				ID:          v.ID,
 				Address:     v.Address,
 				PhoneNumber: v.PhoneNumber,
+				ServiceFee:  v.ServiceFee,
+				Currency:    v.Currency,
 			}
 		}
 
+		if n := len(*offices); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*offices)[0].ID), strconv.Itoa((*offices)[n-1].ID), n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -432,6 +537,8 @@ func (s *server) handleBookingOfficeGetDeleteUpdate() http.HandlerFunc {
 				ID:          o.ID,
 				Address:     o.Address,
 				PhoneNumber: o.PhoneNumber,
+				ServiceFee:  o.ServiceFee,
+				Currency:    o.Currency,
 			})
 			return
 		}
@@ -456,11 +563,19 @@ func (s *server) handleBookingOfficeGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusBadRequest, err)
 				return
 			}
+			b.Currency = string(currencyOf(b.Currency))
+			if err := inCurrency(currencyOf(b.Currency), &b.ServiceFee); err != nil {
+				code, err := currencyErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
 
 			if err := s.store.BookingOffice().Update(id, &store.BookingOfficeModel{
 				ID:          b.ID,
 				Address:     b.Address,
 				PhoneNumber: b.PhoneNumber,
+				ServiceFee:  b.ServiceFee,
+				Currency:    b.Currency,
 			}); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
@@ -477,13 +592,19 @@ func (s *server) handleBookingOfficeGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleCashiersGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		cashiers, err := s.store.Cashier().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var cashiers *[]store.CashierModel
+		var err error
+		if p.page > 0 {
+			cashiers, err = s.store.Cashier().FindAll(p.rowCount, p.offset())
+		} else {
+			cashiers, err = s.store.Cashier().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Cashier().TotalCount()
+		totalCount, err := p.totalCount(s.store.Cashier().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -504,6 +625,9 @@ func (s *server) handleCashiersGet() http.HandlerFunc {
 			}
 		}
 
+		if n := len(*cashiers); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*cashiers)[0].ID), strconv.Itoa((*cashiers)[n-1].ID), n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -632,13 +756,19 @@ func (s *server) handleCashierPasswordUpdate() http.HandlerFunc {
 func (s *server) handleFlightInTicketsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		flightInTickets, err := s.store.FlightInTicket().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var flightInTickets *[]store.FlightInTicketModel
+		var err error
+		if p.page > 0 {
+			flightInTickets, err = s.store.FlightInTicket().FindAll(p.rowCount, p.offset())
+		} else {
+			flightInTickets, err = s.store.FlightInTicket().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.FlightInTicket().TotalCount()
+		totalCount, err := p.totalCount(s.store.FlightInTicket().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -651,12 +781,20 @@ func (s *server) handleFlightInTicketsGet() http.HandlerFunc {
 
 		for i, v := range *flightInTickets {
 			response.Items[i] = FlightInTicket{
-				ID:       v.ID,
-				FlightID: v.FlightID,
-				SeatID:   v.SeatID,
-				TicketID: v.TicketID,
+				ID:           v.ID,
+				FlightID:     v.FlightID,
+				SeatID:       v.SeatID,
+				TicketID:     v.TicketID,
+				Class:        v.Class,
+				BookingClass: bookingClassOf(v.BookingClass),
+				CheckInState: string(checkin.StateOf(v.CheckedInAt, v.BoardedAt)),
+				CheckedInAt:  v.CheckedInAt,
+				BoardedAt:    v.BoardedAt,
 			}
 		}
+		if n := len(*flightInTickets); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*flightInTickets)[0].ID), strconv.Itoa((*flightInTickets)[n-1].ID), n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -670,7 +808,8 @@ func (s *server) handleFlightInTicketGetDeleteUpdate() http.HandlerFunc {
 			return
 		}
 
-		if _, err := s.store.FlightInTicket().Find(id); err != nil {
+		current, err := s.store.FlightInTicket().Find(id)
+		if err != nil {
 			if err == sql.ErrNoRows {
 				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
 				return
@@ -690,10 +829,15 @@ func (s *server) handleFlightInTicketGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 			s.respond(w, r, http.StatusOK, &FlightInTicket{
-				ID:       id,
-				FlightID: f.FlightID,
-				SeatID:   f.SeatID,
-				TicketID: f.SeatID,
+				ID:           id,
+				FlightID:     f.FlightID,
+				SeatID:       f.SeatID,
+				TicketID:     f.TicketID,
+				Class:        f.Class,
+				BookingClass: bookingClassOf(f.BookingClass),
+				CheckInState: string(checkin.StateOf(f.CheckedInAt, f.BoardedAt)),
+				CheckedInAt:  f.CheckedInAt,
+				BoardedAt:    f.BoardedAt,
 			})
 			return
 		}
@@ -704,6 +848,7 @@ func (s *server) handleFlightInTicketGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
+			s.offerFreedSeats(current.FlightID)
 			s.respond(w, r, http.StatusNoContent, nil)
 			return
 		}
@@ -719,18 +864,30 @@ func (s *server) handleFlightInTicketGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 
-			if err := s.store.FlightInTicket().Update(id, &store.FlightInTicketModel{
-				FlightID: f.FlightID,
-				SeatID:   f.SeatID,
-				TicketID: f.TicketID,
+			segment := &store.FlightInTicketModel{
+				ID:           id,
+				FlightID:     f.FlightID,
+				SeatID:       f.SeatID,
+				TicketID:     f.TicketID,
+				Class:        f.Class,
+				BookingClass: bookingClassPtr(f.BookingClass),
+			}
+			if err := s.store.Transaction(func(tx store.Store) error {
+				if err := s.checkSegment(tx, segment); err != nil {
+					return err
+				}
+				return tx.FlightInTicket().Update(id, segment)
 			}); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
 					return
 				}
-				s.error(w, r, http.StatusInternalServerError, err)
+				code, err := segmentErrorStatus(err)
+				s.error(w, r, code, err)
 				return
 			}
+			f.Class, f.BookingClass = segment.Class, bookingClassOf(segment.BookingClass)
+			s.offerFreedSeats(current.FlightID)
 			s.respond(w, r, http.StatusOK, f)
 		}
 	}
@@ -739,13 +896,19 @@ func (s *server) handleFlightInTicketGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleFlightsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		flights, err := s.store.Flight().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var flights *[]store.FlightModel
+		var err error
+		if p.page > 0 {
+			flights, err = s.store.Flight().FindAll(p.rowCount, p.offset())
+		} else {
+			flights, err = s.store.Flight().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Flight().TotalCount()
+		totalCount, err := p.totalCount(s.store.Flight().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -757,13 +920,10 @@ func (s *server) handleFlightsGet() http.HandlerFunc {
 		}
 
 		for i, v := range *flights {
-			response.Items[i] = Flight{
-				ID:        v.ID,
-				DepDate:   v.DepDate,
-				LineCode:  v.LineCode,
-				LinerCode: v.LinerCode,
-				IsHot:     v.IsHot,
-			}
+			response.Items[i] = flightFromModel(&v)
+		}
+		if n := len(*flights); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*flights)[0].ID), strconv.Itoa((*flights)[n-1].ID), n)
 		}
 		s.respond(w, r, 200, response)
 	}
@@ -788,7 +948,7 @@ func (s *server) handleFlightGetDeleteUpdate() http.HandlerFunc {
 		}
 
 		if r.Method == http.MethodGet {
-			f, err := s.store.Flight().Find(id)
+			f, err := s.store.Flight().FindLeg(id)
 			if err != nil {
 				if err == sql.ErrNoRows {
 					s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
@@ -797,13 +957,12 @@ func (s *server) handleFlightGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
-			s.respond(w, r, http.StatusOK, &Flight{
-				ID:        f.ID,
-				DepDate:   f.DepDate,
-				LineCode:  f.LineCode,
-				IsHot:     f.IsHot,
-				LinerCode: f.LinerCode,
-			})
+			leg, err := flightLegFromModel(f)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, leg)
 			return
 		}
 
@@ -827,13 +986,14 @@ func (s *server) handleFlightGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusBadRequest, err)
 				return
 			}
+			if err := s.checkRotation(id, f); err != nil {
+				code, err := rotationErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
 
-			if err := s.store.Flight().Update(id, &store.FlightModel{
-				DepDate:   f.DepDate,
-				LineCode:  f.LineCode,
-				IsHot:     f.IsHot,
-				LinerCode: f.LinerCode,
-			}); err != nil {
+			change, err := s.updateFlight(id, f)
+			if err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
 					return
@@ -841,7 +1001,7 @@ func (s *server) handleFlightGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
-			s.respond(w, r, http.StatusOK, f)
+			s.respond(w, r, http.StatusOK, change)
 		}
 	}
 }
@@ -849,13 +1009,19 @@ func (s *server) handleFlightGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleLinesGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		lines, err := s.store.Line().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var lines *[]store.LineModel
+		var err error
+		if p.page > 0 {
+			lines, err = s.store.Line().FindAll(p.rowCount, p.offset())
+		} else {
+			lines, err = s.store.Line().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Line().TotalCount()
+		totalCount, err := p.totalCount(s.store.Line().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -866,16 +1032,27 @@ func (s *server) handleLinesGet() http.HandlerFunc {
 			TotalCount: totalCount,
 		}
 
+		findAirport := airportFinder(s.store)
 		for i, v := range *lines {
 			response.Items[i] = Line{
-				LineCode:   v.LineCode,
-				DepTime:    v.DepTime,
-				ArrTime:    v.ArrTime,
-				BasePrice:  v.BasePrice,
-				DepAirport: v.DepAirport,
-				ArrAirport: v.ArrAirport,
+				LineCode:      v.LineCode,
+				DepTime:       v.DepTime,
+				ArrTime:       v.ArrTime,
+				ArrDayOffset:  v.ArrDayOffset,
+				BasePrice:     v.BasePrice,
+				FuelSurcharge: v.FuelSurcharge,
+				Currency:      v.Currency,
+				DepAirport:    v.DepAirport,
+				ArrAirport:    v.ArrAirport,
+			}
+			if err := lineGeodata(findAirport, &response.Items[i]); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
 			}
 		}
+		if n := len(*lines); n > 0 {
+			response.Prev, response.Next = p.cursors((*lines)[0].LineCode, (*lines)[n-1].LineCode, n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -903,14 +1080,22 @@ func (s *server) handleLineGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
-			s.respond(w, r, http.StatusOK, &Line{
-				LineCode:   l.LineCode,
-				DepTime:    l.DepTime,
-				ArrTime:    l.ArrTime,
-				BasePrice:  l.BasePrice,
-				DepAirport: l.DepAirport,
-				ArrAirport: l.ArrAirport,
-			})
+			response := &Line{
+				LineCode:      l.LineCode,
+				DepTime:       l.DepTime,
+				ArrTime:       l.ArrTime,
+				ArrDayOffset:  l.ArrDayOffset,
+				BasePrice:     l.BasePrice,
+				FuelSurcharge: l.FuelSurcharge,
+				Currency:      l.Currency,
+				DepAirport:    l.DepAirport,
+				ArrAirport:    l.ArrAirport,
+			}
+			if err := lineGeodata(airportFinder(s.store), response); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			s.respond(w, r, http.StatusOK, response)
 			return
 		}
 
@@ -934,14 +1119,27 @@ func (s *server) handleLineGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusBadRequest, err)
 				return
 			}
+			if err := s.checkLineSchedule(s.store, l); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			l.Currency = string(currencyOf(l.Currency))
+			if err := inCurrency(currencyOf(l.Currency), &l.BasePrice, &l.FuelSurcharge); err != nil {
+				code, err := currencyErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
 
 			if err := s.store.Line().Update(vars["code"], &store.LineModel{
-				LineCode:   l.LineCode,
-				DepTime:    l.DepTime,
-				ArrTime:    l.ArrTime,
-				BasePrice:  l.BasePrice,
-				DepAirport: l.DepAirport,
-				ArrAirport: l.ArrAirport,
+				LineCode:      l.LineCode,
+				DepTime:       l.DepTime,
+				ArrTime:       l.ArrTime,
+				ArrDayOffset:  l.ArrDayOffset,
+				BasePrice:     l.BasePrice,
+				FuelSurcharge: l.FuelSurcharge,
+				Currency:      l.Currency,
+				DepAirport:    l.DepAirport,
+				ArrAirport:    l.ArrAirport,
 			}); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
@@ -958,13 +1156,19 @@ func (s *server) handleLineGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleLinerModelsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		models, err := s.store.LinerModel().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var models *[]store.LinerModelModel
+		var err error
+		if p.page > 0 {
+			models, err = s.store.LinerModel().FindAll(p.rowCount, p.offset())
+		} else {
+			models, err = s.store.LinerModel().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.LinerModel().TotalCount()
+		totalCount, err := p.totalCount(s.store.LinerModel().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -981,6 +1185,9 @@ func (s *server) handleLinerModelsGet() http.HandlerFunc {
 				Name:         v.Name,
 			}
 		}
+		if n := len(*models); n > 0 {
+			response.Prev, response.Next = p.cursors((*models)[0].IATATypeCode, (*models)[n-1].IATATypeCode, n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -1055,13 +1262,19 @@ func (s *server) handleLinerModelGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleLinersGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		liners, err := s.store.Liner().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var liners *[]store.LinerModel
+		var err error
+		if p.page > 0 {
+			liners, err = s.store.Liner().FindAll(p.rowCount, p.offset())
+		} else {
+			liners, err = s.store.Liner().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Liner().TotalCount()
+		totalCount, err := p.totalCount(s.store.Liner().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1078,6 +1291,9 @@ func (s *server) handleLinersGet() http.HandlerFunc {
 				ModelCode: v.ModelCode,
 			}
 		}
+		if n := len(*liners); n > 0 {
+			response.Prev, response.Next = p.cursors((*liners)[0].IATACode, (*liners)[n-1].IATACode, n)
+		}
 		s.respond(w, r, 200, response)
 	}
 }
@@ -1150,13 +1366,19 @@ func (s *server) handleLinerGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handlePurchasesGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		purchases, err := s.store.Purchase().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var purchases *[]store.PurchaseModel
+		var err error
+		if p.page > 0 {
+			purchases, err = s.store.Purchase().FindAll(p.rowCount, p.offset())
+		} else {
+			purchases, err = s.store.Purchase().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Purchase().TotalCount()
+		totalCount, err := p.totalCount(s.store.Purchase().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1169,14 +1391,26 @@ func (s *server) handlePurchasesGet() http.HandlerFunc {
 
 		for i, v := range *purchases {
 			response.Items[i] = Purchase{
-				ID:              v.ID,
-				Date:            v.Date,
-				BookingOfficeID: v.BookingOfficeID,
-				TotalPrice:      v.TotalPrice,
-				ContactPhone:    v.ContactPhone,
-				ContactEmail:    v.ContactEmail,
-				CashierID:       v.CashierID,
-			}
+				ID:               v.ID,
+				Date:             v.Date,
+				BookingOfficeID:  v.BookingOfficeID,
+				TotalPrice:       v.TotalPrice,
+				ContactPhone:     v.ContactPhone,
+				ContactEmail:     v.ContactEmail,
+				CashierID:        v.CashierID,
+				Currency:         v.Currency,
+				ExchangeRate:     v.ExchangeRate,
+				RateDate:         v.RateDate,
+				BaseTotalPrice:   v.BaseTotalPrice,
+				PromoCode:        promoCodeOf(v.PromoCode),
+				Discount:         v.Discount,
+				LoyaltyAccountID: v.LoyaltyAccountID,
+				RedeemedMiles:    v.RedeemedMiles,
+				MilesAmount:      v.MilesAmount,
+			}
+		}
+		if n := len(*purchases); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*purchases)[0].ID), strconv.Itoa((*purchases)[n-1].ID), n)
 		}
 		s.respond(w, r, 200, response)
 	}
@@ -1192,7 +1426,8 @@ func (s *server) handlePurchaseGetDeleteUpdate() http.HandlerFunc {
 			return
 		}
 
-		if _, err := s.store.Purchase().Find(id); err != nil {
+		current, err := s.store.Purchase().Find(id)
+		if err != nil {
 			if err == sql.ErrNoRows {
 				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
 				return
@@ -1212,13 +1447,22 @@ func (s *server) handlePurchaseGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 			s.respond(w, r, http.StatusOK, &Purchase{
-				ID:              p.ID,
-				Date:            p.Date,
-				BookingOfficeID: p.BookingOfficeID,
-				TotalPrice:      p.TotalPrice,
-				ContactPhone:    p.ContactPhone,
-				ContactEmail:    p.ContactEmail,
-				CashierID:       p.CashierID,
+				ID:               p.ID,
+				Date:             p.Date,
+				BookingOfficeID:  p.BookingOfficeID,
+				TotalPrice:       p.TotalPrice,
+				ContactPhone:     p.ContactPhone,
+				ContactEmail:     p.ContactEmail,
+				CashierID:        p.CashierID,
+				Currency:         p.Currency,
+				ExchangeRate:     p.ExchangeRate,
+				RateDate:         p.RateDate,
+				BaseTotalPrice:   p.BaseTotalPrice,
+				PromoCode:        promoCodeOf(p.PromoCode),
+				Discount:         p.Discount,
+				LoyaltyAccountID: p.LoyaltyAccountID,
+				RedeemedMiles:    p.RedeemedMiles,
+				MilesAmount:      p.MilesAmount,
 			})
 		}
 
@@ -1242,15 +1486,24 @@ func (s *server) handlePurchaseGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 
-			if err := s.store.Purchase().Update(id, &store.PurchaseModel{
-				ID:              p.ID,
-				Date:            p.Date,
-				BookingOfficeID: p.BookingOfficeID,
-				TotalPrice:      p.TotalPrice,
-				ContactPhone:    p.ContactPhone,
-				ContactEmail:    p.ContactEmail,
-				CashierID:       p.CashierID,
-			}); err != nil {
+			m, err := s.purchaseModel(p)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+					return
+				}
+				code, err := currencyErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
+			// The promo code and the miles are applied on sale only, the discount given and the miles redeemed
+			// stay with the purchase
+			m.PromoCode, m.Discount = current.PromoCode, current.Discount
+			p.PromoCode, p.Discount = promoCodeOf(current.PromoCode), current.Discount
+			m.LoyaltyAccountID, m.RedeemedMiles, m.MilesAmount = current.LoyaltyAccountID, current.RedeemedMiles, current.MilesAmount
+			p.LoyaltyAccountID, p.RedeemedMiles, p.MilesAmount = current.LoyaltyAccountID, current.RedeemedMiles, current.MilesAmount
+
+			if err := s.store.Purchase().Update(id, m); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
 					return
@@ -1266,13 +1519,19 @@ func (s *server) handlePurchaseGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleSeatsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		seats, err := s.store.Seat().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var seats *[]store.SeatModel
+		var err error
+		if p.page > 0 {
+			seats, err = s.store.Seat().FindAll(p.rowCount, p.offset())
+		} else {
+			seats, err = s.store.Seat().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Seat().TotalCount()
+		totalCount, err := p.totalCount(s.store.Seat().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1291,6 +1550,9 @@ func (s *server) handleSeatsGet() http.HandlerFunc {
 				Class:          v.Class,
 			}
 		}
+		if n := len(*seats); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*seats)[0].ID), strconv.Itoa((*seats)[n-1].ID), n)
+		}
 		s.respond(w, r, 200, response)
 
 	}
@@ -1375,13 +1637,19 @@ func (s *server) handleSeatGetDeleteUpdate() http.HandlerFunc {
 func (s *server) handleTicketsGet() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := r.Context().Value(ctxKeyPagination).(paginationInfo)
-		ticket, err := s.store.Ticket().FindAll(p.rowCount, (p.page-1)*p.rowCount)
+		var ticket *[]store.TicketModel
+		var err error
+		if p.page > 0 {
+			ticket, err = s.store.Ticket().FindAll(p.rowCount, p.offset())
+		} else {
+			ticket, err = s.store.Ticket().FindPage(p.cursor, p.rowCount)
+		}
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		totalCount, err := s.store.Ticket().TotalCount()
+		totalCount, err := p.totalCount(s.store.Ticket().TotalCount)
 		if err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1394,14 +1662,22 @@ func (s *server) handleTicketsGet() http.HandlerFunc {
 
 		for i, v := range *ticket {
 			response.Items[i] = Ticket{
-				ID:                      v.ID,
-				PassengerLastName:       v.PassengerLastName,
-				PassengerGivenName:      v.PassengerGivenName,
-				PassengerBirthDate:      v.PassengerBirthDate,
-				PassengerPassportNumber: v.PassengerPassportNumber,
-				PassengerSex:            v.PassengerSex,
-				PurchaseID:              v.PurchaseID,
-			}
+				ID:                       v.ID,
+				PassengerLastName:        v.PassengerLastName,
+				PassengerGivenName:       v.PassengerGivenName,
+				PassengerBirthDate:       v.PassengerBirthDate,
+				PassengerPassportNumber:  v.PassengerPassportNumber,
+				PassengerSex:             v.PassengerSex,
+				PurchaseID:               v.PurchaseID,
+				PassengerID:              v.PassengerID,
+				PassengerDocumentType:    v.PassengerDocumentType,
+				PassengerDocumentCountry: v.PassengerDocumentCountry,
+				PassengerDocumentExpiry:  v.PassengerDocumentExpiry,
+				AccompanyingTicketID:     v.AccompanyingTicketID,
+			}
+		}
+		if n := len(*ticket); n > 0 {
+			response.Prev, response.Next = p.cursors(strconv.Itoa((*ticket)[0].ID), strconv.Itoa((*ticket)[n-1].ID), n)
 		}
 		s.respond(w, r, 200, response)
 	}
@@ -1436,22 +1712,35 @@ func (s *server) handleTicketGetDeleteUpdate() http.HandlerFunc {
 				return
 			}
 			s.respond(w, r, http.StatusOK, &Ticket{
-				ID:                      p.ID,
-				PassengerLastName:       p.PassengerLastName,
-				PassengerGivenName:      p.PassengerGivenName,
-				PassengerBirthDate:      p.PassengerBirthDate,
-				PassengerPassportNumber: p.PassengerPassportNumber,
-				PassengerSex:            p.PassengerSex,
-				PurchaseID:              p.PurchaseID,
+				ID:                       p.ID,
+				PassengerLastName:        p.PassengerLastName,
+				PassengerGivenName:       p.PassengerGivenName,
+				PassengerBirthDate:       p.PassengerBirthDate,
+				PassengerPassportNumber:  p.PassengerPassportNumber,
+				PassengerSex:             p.PassengerSex,
+				PurchaseID:               p.PurchaseID,
+				PassengerID:              p.PassengerID,
+				PassengerDocumentType:    p.PassengerDocumentType,
+				PassengerDocumentCountry: p.PassengerDocumentCountry,
+				PassengerDocumentExpiry:  p.PassengerDocumentExpiry,
+				AccompanyingTicketID:     p.AccompanyingTicketID,
 			})
 		}
 
 		if r.Method == http.MethodDelete {
+			segments, err := s.store.FlightInTicket().FindSegments(id)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
 			err = s.store.Ticket().Delete(id)
 			if err != nil {
 				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
+			for _, v := range segments {
+				s.offerFreedSeats(v.FlightID)
+			}
 			s.respond(w, r, http.StatusNoContent, nil)
 			return
 		}
@@ -1466,15 +1755,21 @@ func (s *server) handleTicketGetDeleteUpdate() http.HandlerFunc {
 				s.error(w, r, http.StatusBadRequest, err)
 				return
 			}
+			t.setDocument(t.document())
 
 			if err := s.store.Ticket().Update(id, &store.TicketModel{
-				ID:                      t.ID,
-				PassengerLastName:       t.PassengerLastName,
-				PassengerGivenName:      t.PassengerGivenName,
-				PassengerBirthDate:      t.PassengerBirthDate,
-				PassengerPassportNumber: t.PassengerPassportNumber,
-				PassengerSex:            t.PassengerSex,
-				PurchaseID:              t.PurchaseID,
+				ID:                       t.ID,
+				PassengerLastName:        t.PassengerLastName,
+				PassengerGivenName:       t.PassengerGivenName,
+				PassengerBirthDate:       t.PassengerBirthDate,
+				PassengerPassportNumber:  t.PassengerPassportNumber,
+				PassengerSex:             t.PassengerSex,
+				PurchaseID:               t.PurchaseID,
+				PassengerID:              t.PassengerID,
+				PassengerDocumentType:    t.PassengerDocumentType,
+				PassengerDocumentCountry: t.PassengerDocumentCountry,
+				PassengerDocumentExpiry:  t.PassengerDocumentExpiry,
+				AccompanyingTicketID:     t.AccompanyingTicketID,
 			}); err != nil {
 				if err == mysqlstore.ErrNoChanges {
 					s.error(w, r, http.StatusBadRequest, err)
@@ -1501,9 +1796,14 @@ func (s *server) handleAirportsCreate() http.HandlerFunc {
 		}
 
 		if err := s.store.Airport().Create(&store.AirportModel{
-			IATACode: a.IATACode,
-			City:     a.City,
-			Timezone: a.Timezone,
+			IATACode:  a.IATACode,
+			City:      a.City,
+			Timezone:  a.Timezone,
+			Latitude:  a.Latitude,
+			Longitude: a.Longitude,
+			ICAOCode:  a.ICAOCode,
+			Name:      a.Name,
+			Country:   a.Country,
 		}); err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1523,11 +1823,19 @@ func (s *server) handleBookingOfficesCreate() http.HandlerFunc {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
+		o.Currency = string(currencyOf(o.Currency))
+		if err := inCurrency(currencyOf(o.Currency), &o.ServiceFee); err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
 
 		if err := s.store.BookingOffice().Create(&store.BookingOfficeModel{
 			ID:          o.ID,
 			Address:     o.Address,
 			PhoneNumber: o.PhoneNumber,
+			ServiceFee:  o.ServiceFee,
+			Currency:    o.Currency,
 		}); err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1580,14 +1888,31 @@ func (s *server) handleFlightInTicketsCreate() http.HandlerFunc {
 			return
 		}
 
-		if err := s.store.FlightInTicket().Create(&store.FlightInTicketModel{
-			FlightID: f.FlightID,
-			SeatID:   f.SeatID,
-			TicketID: f.TicketID,
+		segment := &store.FlightInTicketModel{
+			FlightID:     f.FlightID,
+			SeatID:       f.SeatID,
+			TicketID:     f.TicketID,
+			Class:        f.Class,
+			BookingClass: bookingClassPtr(f.BookingClass),
+		}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if err := s.checkSegment(tx, segment); err != nil {
+				return err
+			}
+			taxes, err := s.sellSegment(tx, segment)
+			if err != nil {
+				return err
+			}
+			if err := tx.FlightInTicket().Create(segment); err != nil {
+				return err
+			}
+			return tx.FlightInTicket().SetTaxes(segment.ID, taxes)
 		}); err != nil {
-			s.error(w, r, http.StatusInternalServerError, err)
+			code, err := segmentErrorStatus(err)
+			s.error(w, r, code, err)
 			return
 		}
+		f.Class, f.BookingClass = segment.Class, bookingClassOf(segment.BookingClass)
 		s.respond(w, r, http.StatusOK, f)
 	}
 }
@@ -1603,6 +1928,11 @@ func (s *server) handleFlightsCreate() http.HandlerFunc {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
+		if err := s.checkRotation(0, f); err != nil {
+			code, err := rotationErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
 
 		if err := s.store.Flight().Create(&store.FlightModel{
 			DepDate:   f.DepDate,
@@ -1628,14 +1958,27 @@ func (s *server) handleLinesCreate() http.HandlerFunc {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
+		if err := s.checkLineSchedule(s.store, l); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		l.Currency = string(currencyOf(l.Currency))
+		if err := inCurrency(currencyOf(l.Currency), &l.BasePrice, &l.FuelSurcharge); err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
 
 		if err := s.store.Line().Create(&store.LineModel{
-			LineCode:   l.LineCode,
-			DepTime:    l.DepTime,
-			ArrTime:    l.ArrTime,
-			BasePrice:  l.BasePrice,
-			DepAirport: l.DepAirport,
-			ArrAirport: l.ArrAirport,
+			LineCode:      l.LineCode,
+			DepTime:       l.DepTime,
+			ArrTime:       l.ArrTime,
+			ArrDayOffset:  l.ArrDayOffset,
+			BasePrice:     l.BasePrice,
+			FuelSurcharge: l.FuelSurcharge,
+			Currency:      l.Currency,
+			DepAirport:    l.DepAirport,
+			ArrAirport:    l.ArrAirport,
 		}); err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1702,16 +2045,48 @@ func (s *server) handlePurchasesCreate() http.HandlerFunc {
 			return
 		}
 
-		if err := s.store.Purchase().Create(&store.PurchaseModel{
-			ID:              p.ID,
-			Date:            p.Date,
-			BookingOfficeID: p.BookingOfficeID,
-			TotalPrice:      p.TotalPrice,
-			ContactPhone:    p.ContactPhone,
-			ContactEmail:    p.ContactEmail,
-			CashierID:       p.CashierID,
+		m, err := s.purchaseModel(p)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+				return
+			}
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		if err := s.store.Transaction(func(tx store.Store) error {
+			var usage *store.PromoUsageModel
+			if p.PromoCode != "" {
+				if usage, err = s.applyPromo(tx, p, m); err != nil {
+					return err
+				}
+			}
+			var redemption *store.LoyaltyEntryModel
+			if p.RedeemedMiles > 0 {
+				if redemption, err = s.redeemMiles(tx, p, m); err != nil {
+					return err
+				}
+			}
+			if err := tx.Purchase().Create(m); err != nil {
+				return err
+			}
+			p.ID = m.ID
+			if usage != nil {
+				usage.PurchaseID = m.ID
+				if err := tx.PromoCampaign().AddUsage(usage); err != nil {
+					return err
+				}
+			}
+			if redemption != nil {
+				redemption.PurchaseID = &m.ID
+				return tx.Loyalty().AddEntry(redemption)
+			}
+			return nil
 		}); err != nil {
-			s.error(w, r, http.StatusInternalServerError, err)
+			code, err := loyaltyErrorStatus(err)
+			s.error(w, r, code, err)
 			return
 		}
 		s.respond(w, r, http.StatusOK, p)
@@ -1750,18 +2125,44 @@ func (s *server) handleTicketsCreate() http.HandlerFunc {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
+		if t.PassengerID != nil {
+			if err := s.fillTicketPassenger(t); err != nil {
+				if err == sql.ErrNoRows {
+					s.error(w, r, http.StatusBadRequest, errRequestedItemDoesNotExist)
+					return
+				}
+				if err == errPassengerHasNoDocument {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
 		if err := t.Validate(); err != nil {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
-		if err := s.store.Ticket().Create(&store.TicketModel{
-			ID:                      t.ID,
-			PassengerLastName:       t.PassengerLastName,
-			PassengerGivenName:      t.PassengerGivenName,
-			PassengerBirthDate:      t.PassengerBirthDate,
-			PassengerPassportNumber: t.PassengerPassportNumber,
-			PassengerSex:            t.PassengerSex,
-			PurchaseID:              t.PurchaseID,
+		if err := s.store.Transaction(func(tx store.Store) error {
+			if t.PassengerID == nil {
+				if err := s.linkTicketPassenger(tx, t); err != nil {
+					return err
+				}
+			}
+			return tx.Ticket().Create(&store.TicketModel{
+				ID:                       t.ID,
+				PassengerLastName:        t.PassengerLastName,
+				PassengerGivenName:       t.PassengerGivenName,
+				PassengerBirthDate:       t.PassengerBirthDate,
+				PassengerPassportNumber:  t.PassengerPassportNumber,
+				PassengerSex:             t.PassengerSex,
+				PurchaseID:               t.PurchaseID,
+				PassengerID:              t.PassengerID,
+				PassengerDocumentType:    t.PassengerDocumentType,
+				PassengerDocumentCountry: t.PassengerDocumentCountry,
+				PassengerDocumentExpiry:  t.PassengerDocumentExpiry,
+				AccompanyingTicketID:     t.AccompanyingTicketID,
+			})
 		}); err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
@@ -1795,19 +2196,92 @@ func (s *server) handleTicketReportGet() http.HandlerFunc {
 			return
 		}
 
+		// The report is in the local currency of the booking office unless asked otherwise, at the rates
+		// effective on the date of the purchase
+		currency := currencyOf(office.Currency)
+		if v := r.URL.Query().Get("currency"); v != "" {
+			currency = money.Currency(strings.ToUpper(v))
+			if err := exchange.ValidCurrency(currency); err != nil {
+				code, err := currencyErrorStatus(err)
+				s.error(w, r, code, err)
+				return
+			}
+		}
+		date := purchase.Date
+		if date.IsZero() {
+			date = time.Now()
+		}
+		convert, err := s.converter(s.store, currency, date)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := priceFlights(t, flights, currency, convert); err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		findAirport := airportFinder(s.store)
+		for _, f := range flights {
+			f.UnaccompaniedMinor, err = s.isUnaccompaniedMinor(t, f.FlightID, f.DepDate)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			f.FareRules, err = s.fareRule(s.store, bookingClassPtr(f.BookingClass))
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			f.DistanceKm, err = distanceKm(findAirport, f.DepAirport, f.ArrAirport)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		operations, err := s.store.Ticket().FindOperations(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		sold, err := s.soldTaxes(flights)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		breakdown, err := s.fareBreakdown(flights, sold, purchase, office, currency, convert)
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		reconciliation, err := s.purchaseReconciliation(purchase, office, date)
+		if err != nil {
+			code, err := currencyErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
 		report := &TicketReport{
 			Ticket: Ticket{
-				ID:                      t.ID,
-				PassengerLastName:       t.PassengerLastName,
-				PassengerGivenName:      t.PassengerGivenName,
-				PassengerBirthDate:      t.PassengerBirthDate,
-				PassengerPassportNumber: "******" + t.PassengerPassportNumber[6:10],
-				PassengerSex:            t.PassengerSex,
+				ID:                       t.ID,
+				PassengerLastName:        t.PassengerLastName,
+				PassengerGivenName:       t.PassengerGivenName,
+				PassengerBirthDate:       t.PassengerBirthDate,
+				PassengerPassportNumber:  maskDocumentNumber(t.PassengerPassportNumber),
+				PassengerDocumentType:    t.PassengerDocumentType,
+				PassengerDocumentCountry: t.PassengerDocumentCountry,
+				PassengerDocumentExpiry:  t.PassengerDocumentExpiry,
+				AccompanyingTicketID:     t.AccompanyingTicketID,
+				PassengerSex:             t.PassengerSex,
 			},
 			BookingOffice: BookingOffice{
 				ID:          office.ID,
 				Address:     office.Address,
 				PhoneNumber: office.PhoneNumber,
+				ServiceFee:  office.ServiceFee,
+				Currency:    office.Currency,
 			},
 			Cashier: Cashier{
 				ID:         cashier.ID,
@@ -1817,16 +2291,30 @@ func (s *server) handleTicketReportGet() http.HandlerFunc {
 				Login:      cashier.Login,
 			},
 			Purchase: Purchase{
-				ID:              purchase.ID,
-				Date:            purchase.Date,
-				TotalPrice:      purchase.TotalPrice,
-				ContactPhone:    purchase.ContactPhone,
-				ContactEmail:    purchase.ContactEmail,
-				BookingOfficeID: purchase.BookingOfficeID,
-				CashierID:       purchase.CashierID,
+				ID:               purchase.ID,
+				Date:             purchase.Date,
+				TotalPrice:       purchase.TotalPrice,
+				ContactPhone:     purchase.ContactPhone,
+				ContactEmail:     purchase.ContactEmail,
+				BookingOfficeID:  purchase.BookingOfficeID,
+				CashierID:        purchase.CashierID,
+				Currency:         purchase.Currency,
+				ExchangeRate:     purchase.ExchangeRate,
+				RateDate:         purchase.RateDate,
+				BaseTotalPrice:   purchase.BaseTotalPrice,
+				PromoCode:        promoCodeOf(purchase.PromoCode),
+				Discount:         purchase.Discount,
+				LoyaltyAccountID: purchase.LoyaltyAccountID,
+				RedeemedMiles:    purchase.RedeemedMiles,
+				MilesAmount:      purchase.MilesAmount,
 			},
-			Flights:   flights,
-			TotalTime: int(totalTime.Seconds()),
+			Flights:        flights,
+			TotalTime:      int(totalTime.Seconds()),
+			FareBreakdown:  *breakdown,
+			Reconciliation: *reconciliation,
+		}
+		for i := range operations {
+			report.Operations = append(report.Operations, ticketOperationFromModel(&operations[i]))
 		}
 		s.respond(w, r, http.StatusOK, report)
 	}
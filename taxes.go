@@ -0,0 +1,378 @@
+// Файл taxes.go содержит сборы аэропортов и расчёт состава цены билета: тариф, сборы аэропортов, топливный и сервисный сборы
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akionka/aviasales/internal/money"
+	"github.com/akionka/aviasales/internal/pricing"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// paysTaxes returns whether the passenger pays the airport taxes and the fuel surcharge. Infants without a seat pay neither
+func paysTaxes(passenger pricing.PassengerType, withSeat bool) bool {
+	return passenger != pricing.Infant || withSeat
+}
+
+// segmentTaxes returns the airport taxes levied now on a flight between the airports
+func segmentTaxes(st store.Store, depAirport, arrAirport string) ([]store.SegmentTaxModel, error) {
+	var levied []store.SegmentTaxModel
+	for _, a := range []struct{ code, applies string }{{depAirport, taxOnDeparture}, {arrAirport, taxOnArrival}} {
+		taxes, err := st.Airport().FindTaxes(a.code)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range taxes {
+			if t.Applies == a.applies {
+				levied = append(levied, store.SegmentTaxModel{
+					Airport:  a.code,
+					TaxCode:  t.TaxCode,
+					Name:     t.Name,
+					Amount:   t.Amount,
+					Currency: t.Currency,
+				})
+			}
+		}
+	}
+	return levied, nil
+}
+
+// priceFlights sets the passenger types and the prices of the flights of the ticket and puts their amounts
+// in the currency. The flights have the fares and the fuel surcharges their segments were sold with,
+// segments sold before those were recorded are priced at the current fares
+func priceFlights(t *store.TicketModel, flights []*store.TicketReportFlightModel, c money.Currency, convert func(money.Money) (money.Money, error)) error {
+	for _, f := range flights {
+		passenger := pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, f.DepDate))
+		f.PassengerType = string(passenger)
+		switch {
+		case f.SoldFare != nil:
+			f.Price = *f.SoldFare
+		case f.Multiplier != nil:
+			f.Price = pricing.BucketFare(f.BasePrice, *f.Multiplier, f.IsHot, passenger, f.WithSeat)
+		default:
+			f.Price = pricing.Fare(f.BasePrice, f.SeatClass, f.IsHot, passenger, f.WithSeat)
+		}
+		if f.SoldFuelSurcharge != nil {
+			f.FuelSurcharge = *f.SoldFuelSurcharge
+		} else if !paysTaxes(passenger, f.WithSeat) {
+			f.FuelSurcharge = money.Money{}
+		}
+		for _, m := range []*money.Money{&f.BasePrice, &f.Price, &f.FuelSurcharge} {
+			v, err := convert(*m)
+			if err != nil {
+				return err
+			}
+			*m = v
+		}
+		f.Currency = string(c)
+	}
+	return nil
+}
+
+// segmentCharges returns the airport taxes and the fuel surcharge the segment was sold with in total, in the currency
+// convert puts the amounts in. Segments sold before those were recorded have the current ones
+func (s *server) segmentCharges(st store.Store, t *store.TicketModel, f *store.FlightInTicketModel, leg *store.FlightLegModel, c money.Currency, convert func(money.Money) (money.Money, error)) (money.Money, error) {
+	fuel := money.Money{Currency: c}
+	var taxes []store.SegmentTaxModel
+	switch {
+	case f.FuelSurcharge != nil:
+		fuel = *f.FuelSurcharge
+		var err error
+		if taxes, err = st.FlightInTicket().FindTaxes(f.ID); err != nil {
+			return money.Money{}, err
+		}
+	case paysTaxes(pricing.PassengerTypeOf(ageAt(t.PassengerBirthDate, leg.DepDate)), f.SeatID != nil):
+		line, err := st.Line().Find(leg.LineCode)
+		if err != nil {
+			return money.Money{}, err
+		}
+		fuel = line.FuelSurcharge
+		if taxes, err = segmentTaxes(st, leg.DepAirport, leg.ArrAirport); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return chargesTotal(fuel, taxes, convert)
+}
+
+// chargesTotal returns the fuel surcharge and the airport taxes in total in the currency convert puts the amounts in
+func chargesTotal(fuel money.Money, taxes []store.SegmentTaxModel, convert func(money.Money) (money.Money, error)) (money.Money, error) {
+	amounts := []money.Money{fuel}
+	for _, t := range taxes {
+		amounts = append(amounts, t.Amount)
+	}
+	for i := range amounts {
+		v, err := convert(amounts[i])
+		if err != nil {
+			return money.Money{}, err
+		}
+		amounts[i] = v
+	}
+	return money.Sum(amounts...)
+}
+
+// soldTaxes returns by the segment the airport taxes the segments of the flights were sold with
+func (s *server) soldTaxes(flights []*store.TicketReportFlightModel) (map[int][]store.SegmentTaxModel, error) {
+	var ids []int
+	for _, f := range flights {
+		if f.SoldFuelSurcharge != nil {
+			ids = append(ids, f.SegmentID)
+		}
+	}
+	taxes, err := s.store.FlightInTicket().FindSegmentsTaxes(ids)
+	if err != nil {
+		return nil, err
+	}
+	sold := make(map[int][]store.SegmentTaxModel, len(ids))
+	for _, t := range taxes {
+		sold[t.SegmentID] = append(sold[t.SegmentID], t)
+	}
+	return sold, nil
+}
+
+// fareBreakdown itemises the price of the ticket in the currency with the flights priced in it by priceFlights.
+// The taxes are the ones the segments were sold with, given by soldTaxes, and the service fee is the one
+// of the purchase, both converted to the currency. Segments and purchases sold before those were recorded
+// have the current ones
+func (s *server) fareBreakdown(flights []*store.TicketReportFlightModel, sold map[int][]store.SegmentTaxModel, purchase *store.PurchaseModel, office *store.BookingOfficeModel, c money.Currency, convert func(money.Money) (money.Money, error)) (*FareBreakdown, error) {
+	fee := office.ServiceFee
+	if purchase.ServiceFee != nil {
+		fee = *purchase.ServiceFee
+	}
+	fee, err := convert(fee)
+	if err != nil {
+		return nil, err
+	}
+	b := pricing.Breakdown{ServiceFee: fee}
+	for _, f := range flights {
+		var taxes []store.SegmentTaxModel
+		switch {
+		case f.SoldFuelSurcharge != nil:
+			taxes = sold[f.SegmentID]
+		case paysTaxes(pricing.PassengerType(f.PassengerType), f.WithSeat):
+			taxes, err = segmentTaxes(s.store, f.DepAirport, f.ArrAirport)
+		}
+		if err != nil {
+			return nil, err
+		}
+		levied := make([]pricing.Tax, len(taxes))
+		for i, t := range taxes {
+			amount, err := convert(t.Amount)
+			if err != nil {
+				return nil, err
+			}
+			levied[i] = pricing.Tax{Code: t.TaxCode, Name: t.Name, Airport: t.Airport, Amount: amount}
+		}
+		if err := b.AddFlight(f.Price, levied, f.FuelSurcharge); err != nil {
+			return nil, err
+		}
+	}
+	taxTotal, err := b.TaxTotal()
+	if err != nil {
+		return nil, err
+	}
+	total, err := b.Total()
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &FareBreakdown{
+		BaseFare:      b.BaseFare,
+		Taxes:         make([]TaxItem, len(b.Taxes)),
+		TaxTotal:      taxTotal,
+		FuelSurcharge: b.FuelSurcharge,
+		ServiceFee:    b.ServiceFee,
+		Total:         total,
+		Currency:      string(c),
+	}
+	for i, t := range b.Taxes {
+		breakdown.Taxes[i] = TaxItem{Code: t.Code, Name: t.Name, Airport: t.Airport, Amount: t.Amount}
+	}
+	return breakdown, nil
+}
+
+// purchaseReconciliation checks the total of the purchase against the breakdowns of its tickets in the currency
+// of the purchase. The total paid is before the discount and the miles, which are taken off the price of the tickets.
+// The refunds and the exchanges are taken off or added to the total paid, and what they kept of the segments
+// they removed or replaced is added to the tickets
+func (s *server) purchaseReconciliation(purchase *store.PurchaseModel, office *store.BookingOfficeModel, date time.Time) (*PurchaseReconciliation, error) {
+	c := currencyOf(purchase.Currency)
+	convert, err := s.converter(s.store, c, date)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := s.store.Ticket().FindByPurchase(purchase.ID)
+	if err != nil {
+		return nil, err
+	}
+	flights, err := s.store.Ticket().ReportPurchaseFlights(purchase.ID)
+	if err != nil {
+		return nil, err
+	}
+	sold, err := s.soldTaxes(flights)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := s.store.Ticket().FindPurchaseOperations(purchase.ID)
+	if err != nil {
+		return nil, err
+	}
+	byTicket := make(map[int][]*store.TicketReportFlightModel, len(tickets))
+	for _, f := range flights {
+		byTicket[f.TicketID] = append(byTicket[f.TicketID], f)
+	}
+
+	totals := []money.Money{{Currency: c}}
+	for i := range tickets {
+		flights := byTicket[tickets[i].ID]
+		if err := priceFlights(&tickets[i], flights, c, convert); err != nil {
+			return nil, err
+		}
+		b, err := s.fareBreakdown(flights, sold, purchase, office, c, convert)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, b.Total)
+	}
+	refunded, exchanged, retained := []money.Money{{Currency: c}}, []money.Money{{Currency: c}}, []money.Money{{Currency: c}}
+	for i := range operations {
+		kept, err := operationRetained(&operations[i])
+		if err != nil {
+			return nil, err
+		}
+		if kept, err = convert(kept); err != nil {
+			return nil, err
+		}
+		retained = append(retained, kept)
+		amount, err := convert(operations[i].Amount)
+		if err != nil {
+			return nil, err
+		}
+		if operations[i].Operation == operationRefund {
+			refunded = append(refunded, amount)
+		} else {
+			exchanged = append(exchanged, amount)
+		}
+	}
+
+	r := &PurchaseReconciliation{Currency: string(c)}
+	if r.Retained, err = money.Sum(retained...); err != nil {
+		return nil, err
+	}
+	if r.TicketsTotal, err = money.Sum(append(totals, r.Retained)...); err != nil {
+		return nil, err
+	}
+	if r.Refunded, err = money.Sum(refunded...); err != nil {
+		return nil, err
+	}
+	if r.Exchanged, err = money.Sum(exchanged...); err != nil {
+		return nil, err
+	}
+	if r.Paid, err = money.Sum(purchase.TotalPrice, purchase.Discount, purchase.MilesAmount, r.Refunded.Neg(), r.Exchanged); err != nil {
+		return nil, err
+	}
+	if r.Difference, err = r.Paid.Sub(r.TicketsTotal); err != nil {
+		return nil, err
+	}
+	r.Reconciled = r.Difference.IsZero()
+	return r, nil
+}
+
+// operationRetained returns what the refund or the exchange kept of the segment in the currency of the operation:
+// the price of the segment it removed less the amount returned, or the amount collected less the rise in the price
+// of the segment it replaced
+func operationRetained(o *store.TicketOperationModel) (money.Money, error) {
+	price, err := o.Fare.Add(o.Taxes)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if o.Operation == operationRefund {
+		return price.Sub(o.Amount)
+	}
+	newPrice := price
+	if o.NewFare != nil {
+		newTaxes := money.Money{Currency: o.NewFare.Currency}
+		if o.NewTaxes != nil {
+			newTaxes = *o.NewTaxes
+		}
+		if newPrice, err = o.NewFare.Add(newTaxes); err != nil {
+			return money.Money{}, err
+		}
+	}
+	rise, err := newPrice.Sub(price)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return o.Amount.Sub(rise)
+}
+
+// handleAirportTaxesGetUpdate returns or replaces the taxes levied by the airport
+func (s *server) handleAirportTaxesGetUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := strings.ToUpper(mux.Vars(r)["code"])
+		if _, err := s.store.Airport().Find(code); err != nil {
+			if err == sql.ErrNoRows {
+				s.error(w, r, http.StatusNotFound, errRequestedItemDoesNotExist)
+				return
+			}
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var taxes []AirportTax
+			if err := json.NewDecoder(r.Body).Decode(&taxes); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			models := make([]store.AirportTaxModel, len(taxes))
+			for i := range taxes {
+				if err := taxes[i].Validate(); err != nil {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				taxes[i].Currency = string(currencyOf(taxes[i].Currency))
+				if err := inCurrency(currencyOf(taxes[i].Currency), &taxes[i].Amount); err != nil {
+					code, err := currencyErrorStatus(err)
+					s.error(w, r, code, err)
+					return
+				}
+				models[i] = store.AirportTaxModel{
+					IATACode: code,
+					TaxCode:  taxes[i].TaxCode,
+					Name:     taxes[i].Name,
+					Applies:  taxes[i].Applies,
+					Amount:   taxes[i].Amount,
+					Currency: taxes[i].Currency,
+				}
+			}
+			if err := s.store.Transaction(func(tx store.Store) error {
+				return tx.Airport().SetTaxes(code, models)
+			}); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		models, err := s.store.Airport().FindTaxes(code)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]AirportTax, len(models))
+		for i, v := range models {
+			response[i] = AirportTax{
+				TaxCode:  v.TaxCode,
+				Name:     v.Name,
+				Applies:  v.Applies,
+				Amount:   v.Amount,
+				Currency: v.Currency,
+			}
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,471 @@
+// Файл waitlist.go содержит лист ожидания мест на рейс: постановку в очередь, предложение освободившихся мест и управление очередью
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akionka/aviasales/internal/flightstatus"
+	"github.com/akionka/aviasales/internal/store"
+	"github.com/akionka/aviasales/internal/store/mysqlstore"
+	"github.com/akionka/aviasales/internal/waitlist"
+	"github.com/gorilla/mux"
+)
+
+// The waitlists with passengers waiting are run that often to expire the offers not accepted in time
+const waitlistSweepInterval = time.Minute
+
+var (
+	errAlreadyOnFlight   = segmentRuleError("у билета уже есть этот рейс")
+	errAlreadyWaitlisted = segmentRuleError("билет уже в листе ожидания этого рейса")
+)
+
+// waitlistErrors are the messages shown for the errors of the waitlist
+var waitlistErrors = map[error]error{
+	waitlist.ErrNotOffered:   errors.New("пассажиру не предложено место"),
+	waitlist.ErrOfferExpired: errors.New("срок предложения места истёк"),
+	waitlist.ErrClosed:       errors.New("пассажир уже не в листе ожидания"),
+}
+
+// waitlistErrorStatus returns the status code for an error of a waitlist operation
+func waitlistErrorStatus(err error) (int, error) {
+	if e, ok := waitlistErrors[err]; ok {
+		return http.StatusBadRequest, e
+	}
+	if _, ok := err.(segmentRuleError); ok {
+		return http.StatusBadRequest, err
+	}
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, errRequestedItemDoesNotExist
+	}
+	if err == mysqlstore.ErrNoChanges {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+func waitlistEntryFromModel(m *store.WaitlistEntryModel) waitlist.Entry {
+	return waitlist.Entry{
+		ID:             m.ID,
+		Class:          m.Class,
+		Priority:       m.Priority,
+		CreatedAt:      m.CreatedAt,
+		Status:         waitlist.Status(m.Status),
+		SeatID:         m.SeatID,
+		OfferExpiresAt: m.OfferExpiresAt,
+	}
+}
+
+// activeOffers returns the waitlist entries of the flight that hold a seat
+func (s *server) activeOffers(st store.Store, flightID int) ([]store.WaitlistEntryModel, error) {
+	entries, err := st.Waitlist().FindByFlight(flightID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var offers []store.WaitlistEntryModel
+	for i := range entries {
+		e := waitlistEntryFromModel(&entries[i])
+		if e.Status == waitlist.Offered && e.Active(now) {
+			offers = append(offers, entries[i])
+		}
+	}
+	return offers, nil
+}
+
+// runWaitlist expires the overdue offers of the flight and offers its free seats to the waitlist.
+// The waitlist of a flight no longer on sale is closed
+func (s *server) runWaitlist(tx store.Store, flightID int, now time.Time) error {
+	models, err := tx.Waitlist().FindByFlight(flightID)
+	if err != nil || len(models) == 0 {
+		return err
+	}
+	flight, err := tx.Flight().Find(flightID)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*store.WaitlistEntryModel, len(models))
+	entries := make([]waitlist.Entry, len(models))
+	for i := range models {
+		byID[models[i].ID] = &models[i]
+		entries[i] = waitlistEntryFromModel(&models[i])
+	}
+
+	if !flightstatus.OnSale(flightstatus.Status(flight.Status)) {
+		for i, e := range entries {
+			if e.Status != waitlist.Waiting && e.Status != waitlist.Offered {
+				continue
+			}
+			models[i].Status = string(waitlist.Expired)
+			if err := tx.Waitlist().Update(models[i].ID, &models[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	vacant, err := s.vacantSeats(tx, flight)
+	if err != nil {
+		return err
+	}
+	free := make([]waitlist.Seat, len(vacant))
+	numbers := make(map[int]string, len(vacant))
+	for i, v := range vacant {
+		free[i] = waitlist.Seat{ID: v.ID, Number: v.Number, Class: v.Class}
+		numbers[v.ID] = v.Number
+	}
+	loads, err := s.classLoads(tx, flight, 0)
+	if err != nil {
+		return err
+	}
+	available := make(map[string]int, len(loads))
+	for class, l := range loads {
+		available[class] = l.Authorized() - l.Sold()
+	}
+
+	plan := waitlist.Plan(entries, free, available, now)
+	for _, id := range plan.Expired {
+		m := byID[id]
+		m.Status = string(waitlist.Expired)
+		if err := tx.Waitlist().Update(id, m); err != nil {
+			return err
+		}
+	}
+	for _, id := range plan.Withdrawn {
+		m := byID[id]
+		m.Status = string(waitlist.Waiting)
+		m.SeatID, m.OfferedAt, m.OfferExpiresAt = nil, nil, nil
+		if err := tx.Waitlist().Update(id, m); err != nil {
+			return err
+		}
+	}
+	for _, o := range plan.Offers {
+		m := byID[o.EntryID]
+		seatID, expires := o.Seat.ID, o.ExpiresAt
+		m.Status = string(waitlist.Offered)
+		m.SeatID = &seatID
+		m.OfferedAt = &now
+		m.OfferExpiresAt = &expires
+		if err := tx.Waitlist().Update(m.ID, m); err != nil {
+			return err
+		}
+		log.Printf("waitlist: seat %s of flight %d offered to ticket %d until %s",
+			numbers[seatID], flightID, m.TicketID, expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// offerFreedSeats runs the waitlists of the flights after seats were freed on them. Failures are only logged
+// since the sweep runs the waitlists again
+func (s *server) offerFreedSeats(flightIDs ...int) {
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, id := range flightIDs {
+		if err := s.store.Transaction(func(tx store.Store) error {
+			return s.runWaitlist(tx, id, now)
+		}); err != nil {
+			log.Printf("waitlist of flight %d: %v", id, err)
+		}
+	}
+}
+
+// sweepWaitlists runs the waitlists with passengers waiting every interval
+func (s *server) sweepWaitlists(interval time.Duration) {
+	for range time.Tick(interval) {
+		flights, err := s.store.Waitlist().FindOpenFlights()
+		if err != nil {
+			log.Printf("waitlist sweep: %v", err)
+			continue
+		}
+		s.offerFreedSeats(flights...)
+	}
+}
+
+func (s *server) waitlistEntryResponse(m *store.WaitlistEntryModel) (*WaitlistEntry, error) {
+	t, err := s.store.Ticket().Find(m.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	status := m.Status
+	if e := waitlistEntryFromModel(m); e.Status == waitlist.Offered && !e.Active(time.Now()) {
+		status = string(waitlist.Expired)
+	}
+	return &WaitlistEntry{
+		ID:                 m.ID,
+		FlightID:           m.FlightID,
+		Class:              m.Class,
+		TicketID:           m.TicketID,
+		PassengerLastName:  t.PassengerLastName,
+		PassengerGivenName: t.PassengerGivenName,
+		Priority:           m.Priority,
+		Status:             status,
+		SeatID:             m.SeatID,
+		OfferedAt:          m.OfferedAt,
+		OfferExpiresAt:     m.OfferExpiresAt,
+		CreatedAt:          m.CreatedAt,
+		CashierID:          m.CashierID,
+	}, nil
+}
+
+func (s *server) handleFlightWaitlistGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := s.store.Flight().Find(id); err != nil {
+			code, err := waitlistErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		entries, err := s.store.Waitlist().FindByFlight(id)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		response := make([]WaitlistEntry, len(entries))
+		for i := range entries {
+			entry, err := s.waitlistEntryResponse(&entries[i])
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			response[i] = *entry
+		}
+		s.respond(w, r, http.StatusOK, response)
+	}
+}
+
+// handleFlightWaitlistCreate puts a ticket in the waitlist of the class of the flight.
+// A seat already free is offered right away
+func (s *server) handleFlightWaitlistCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		req := &WaitlistRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := r.Context().Value(ctxKeyCashier).(*store.CashierModel)
+		entry := &store.WaitlistEntryModel{
+			FlightID:  id,
+			Class:     req.Class,
+			TicketID:  req.TicketID,
+			Priority:  req.Priority,
+			Status:    string(waitlist.Waiting),
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+			CashierID: c.ID,
+		}
+		if err := s.store.Transaction(func(tx store.Store) error {
+			flight, err := tx.Flight().Find(id)
+			if err != nil {
+				return err
+			}
+			if !flightstatus.OnSale(flightstatus.Status(flight.Status)) {
+				return errFlightNotOnSale
+			}
+			if _, err := tx.Ticket().Find(req.TicketID); err != nil {
+				return err
+			}
+			if _, err := tx.FlightInTicket().FindOnFlight(req.TicketID, id); err != sql.ErrNoRows {
+				if err == nil {
+					return errAlreadyOnFlight
+				}
+				return err
+			}
+			entries, err := tx.Waitlist().FindByFlight(id)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			for i := range entries {
+				e := waitlistEntryFromModel(&entries[i])
+				if entries[i].TicketID == req.TicketID && e.Active(now) {
+					return errAlreadyWaitlisted
+				}
+			}
+			return tx.Waitlist().Create(entry)
+		}); err != nil {
+			code, err := waitlistErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		s.offerFreedSeats(id)
+		s.respondWaitlistEntry(w, r, entry.ID)
+	}
+}
+
+func (s *server) respondWaitlistEntry(w http.ResponseWriter, r *http.Request, id int) {
+	m, err := s.store.Waitlist().Find(id)
+	if err != nil {
+		code, err := waitlistErrorStatus(err)
+		s.error(w, r, code, err)
+		return
+	}
+	entry, err := s.waitlistEntryResponse(m)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	s.respond(w, r, http.StatusOK, entry)
+}
+
+// handleWaitlistEntryGetDeleteUpdate returns the entry, changes its priority or removes it from the waitlist
+func (s *server) handleWaitlistEntryGetDeleteUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if r.Method == http.MethodGet {
+			s.respondWaitlistEntry(w, r, id)
+			return
+		}
+
+		var priority *WaitlistPriority
+		if r.Method == http.MethodPut {
+			priority = &WaitlistPriority{}
+			if err := json.NewDecoder(r.Body).Decode(priority); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if err := priority.Validate(); err != nil {
+				s.error(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		var flightID int
+		if err := s.store.Transaction(func(tx store.Store) error {
+			m, err := tx.Waitlist().Find(id)
+			if err != nil {
+				return err
+			}
+			e := waitlistEntryFromModel(m)
+			if err := waitlist.Close(&e, time.Now()); err != nil {
+				return err
+			}
+			flightID = m.FlightID
+			if priority != nil {
+				m.Priority = priority.Priority
+			} else {
+				m.Status = string(waitlist.Cancelled)
+			}
+			return tx.Waitlist().Update(id, m)
+		}); err != nil {
+			code, err := waitlistErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+
+		// A seat held by the entry goes to the next one and a raised priority may take the next offer
+		s.offerFreedSeats(flightID)
+		if r.Method == http.MethodDelete {
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+		s.respondWaitlistEntry(w, r, id)
+	}
+}
+
+// handleWaitlistEntryAccept sells the seat offered to the waitlisted ticket. The entry is locked while the seat
+// is sold, so an offer accepted twice at once sells one seat
+func (s *server) handleWaitlistEntryAccept() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		var segmentErr error
+		if err := s.store.Transaction(func(tx store.Store) error {
+			m, err := tx.Waitlist().Lock(id)
+			if err != nil {
+				return err
+			}
+			e := waitlistEntryFromModel(m)
+			if err := waitlist.Accept(&e, time.Now()); err != nil {
+				return err
+			}
+
+			segment := &store.FlightInTicketModel{
+				FlightID: m.FlightID,
+				SeatID:   m.SeatID,
+				TicketID: m.TicketID,
+			}
+			if segmentErr = s.checkSegment(tx, segment); segmentErr != nil {
+				return segmentErr
+			}
+			taxes, err := s.sellSegment(tx, segment)
+			if err != nil {
+				return err
+			}
+			if err := tx.FlightInTicket().Create(segment); err != nil {
+				return err
+			}
+			if err := tx.FlightInTicket().SetTaxes(segment.ID, taxes); err != nil {
+				return err
+			}
+			m.Status = string(waitlist.Accepted)
+			return tx.Waitlist().Update(id, m)
+		}); err != nil {
+			code, err := waitlistErrorStatus(err)
+			if segmentErr != nil {
+				code, err = segmentErrorStatus(segmentErr)
+			}
+			s.error(w, r, code, err)
+			return
+		}
+		s.respondWaitlistEntry(w, r, id)
+	}
+}
+
+// handleWaitlistEntryDecline turns down the seat offered and hands it to the next waitlisted ticket
+func (s *server) handleWaitlistEntryDecline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		var flightID int
+		if err := s.store.Transaction(func(tx store.Store) error {
+			m, err := tx.Waitlist().Lock(id)
+			if err != nil {
+				return err
+			}
+			// Only a seat still held may be declined, same as accepted
+			e := waitlistEntryFromModel(m)
+			if err := waitlist.Accept(&e, time.Now()); err != nil {
+				return err
+			}
+			flightID = m.FlightID
+			m.Status = string(waitlist.Declined)
+			return tx.Waitlist().Update(id, m)
+		}); err != nil {
+			code, err := waitlistErrorStatus(err)
+			s.error(w, r, code, err)
+			return
+		}
+		s.offerFreedSeats(flightID)
+		s.respondWaitlistEntry(w, r, id)
+	}
+}
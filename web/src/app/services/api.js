@@ -55,7 +55,7 @@ export const api = createApi({
     }),
 
     getAirports: builder.query({
-      query: ({ page, count }) => `airports?page=${page}&count=${count}`,
+      query: ({ page, count }) => `airports?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -80,7 +80,7 @@ export const api = createApi({
     }),
 
     getOffices: builder.query({
-      query: ({ page, count }) => `booking_offices?page=${page}&count=${count}`,
+      query: ({ page, count }) => `booking_offices?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -102,7 +102,7 @@ export const api = createApi({
     }),
 
     getCashiers: builder.query({
-      query: ({ page, count }) => `cashiers?page=${page}&count=${count}`,
+      query: ({ page, count }) => `cashiers?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -125,7 +125,7 @@ export const api = createApi({
 
     getFlightInTickets: builder.query({
       query: ({ page, count }) =>
-        `flight_in_tickets?page=${page}&count=${count}`,
+        `flight_in_tickets?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -147,7 +147,7 @@ export const api = createApi({
     }),
 
     getFlights: builder.query({
-      query: ({ page, count }) => `flights?page=${page}&count=${count}`,
+      query: ({ page, count }) => `flights?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -169,7 +169,7 @@ export const api = createApi({
     }),
 
     getLines: builder.query({
-      query: ({ page, count }) => `lines?page=${page}&count=${count}`,
+      query: ({ page, count }) => `lines?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -194,7 +194,7 @@ export const api = createApi({
     }),
 
     getLiners: builder.query({
-      query: ({ page, count }) => `liners?page=${page}&count=${count}`,
+      query: ({ page, count }) => `liners?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -219,7 +219,7 @@ export const api = createApi({
     }),
 
     getLinerModels: builder.query({
-      query: ({ page, count }) => `liner_models?page=${page}&count=${count}`,
+      query: ({ page, count }) => `liner_models?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -244,7 +244,7 @@ export const api = createApi({
     }),
 
     getPurchases: builder.query({
-      query: ({ page, count }) => `purchases?page=${page}&count=${count}`,
+      query: ({ page, count }) => `purchases?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -266,7 +266,7 @@ export const api = createApi({
     }),
 
     getSeats: builder.query({
-      query: ({ page, count }) => `seats?page=${page}&count=${count}`,
+      query: ({ page, count }) => `seats?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -288,7 +288,7 @@ export const api = createApi({
     }),
 
     getTickets: builder.query({
-      query: ({ page, count }) => `tickets?page=${page}&count=${count}`,
+      query: ({ page, count }) => `tickets?page=${page}&count=${count}&total=true`,
       providesTags: (result, error, params) =>
         result
           ? [
@@ -142,7 +142,14 @@ export const AirportsPage = () => {
       type: "singleSelect",
       width: 200,
       editable: true,
-      valueOptions: useMemo(() => timezones || [], [timezones]),
+      valueOptions: useMemo(
+        () =>
+          (timezones || []).map((tz) => ({
+            value: tz.name,
+            label: `${tz.name} (UTC${tz.utc_offset})`,
+          })),
+        [timezones]
+      ),
     }]
     // if (auth.user.role_id === 2) {
       columns.push({
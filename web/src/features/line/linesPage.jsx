@@ -36,7 +36,7 @@ const EditToolbar = ({ setRows, setRowModesModel }) => {
   const handleClick = () => {
     setRows((oldRows) => [
       ...oldRows,
-      { line_code: "JR", dep_time: "10:10", arr_time: "12:12", isNew: true },
+      { line_code: "JR", dep_time: "10:10", arr_time: "12:12", arr_day_offset: 0, isNew: true },
     ]);
     setRowModesModel((oldModel) => ({
       ...oldModel,
@@ -192,6 +192,13 @@ export const LinesPage = () => {
       editable: true,
       ...timeType,
     },
+    {
+      field: "arr_day_offset",
+      headerName: "Прибытие через, сут.",
+      width: 150,
+      editable: true,
+      type: "number",
+    },
     {
       field: "base_price",
       headerName: "Базовая цена",
@@ -131,8 +131,14 @@ export const ReportPage = () => {
           ))}
           <GridItem
             label="Общая стоимость"
-            value={data.flights.map(v => v.price).reduce((acc, v) => acc+=v)}
+            value={data.fare_breakdown.total}
           />
+          {!data.reconciliation.reconciled && (
+            <GridItem
+              label="Расхождение с суммой покупки"
+              value={`${data.reconciliation.difference} ${data.reconciliation.currency}`}
+            />
+          )}
           <GridItem
             label="Общее время в пути"
             value={localDatetimeToUTC(